@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ArchitectureCheck is the result of evaluating a layering rules file
+// against the resolved import graph
+type ArchitectureCheck struct {
+	RulesFile  string                   `json:"rules_file"`
+	Layers     []ArchitectureLayer      `json:"layers"`
+	Rules      []ArchitectureRule       `json:"rules"`
+	Violations []*ArchitectureViolation `json:"violations"`
+}
+
+// ArchitectureLayer is a named group of files selected by path globs
+type ArchitectureLayer struct {
+	Name  string   `json:"name"`
+	Paths []string `json:"paths"`
+	Files int      `json:"files"`
+}
+
+// ArchitectureRule restricts which layers the files of one layer may import:
+// Allow lists the only layers it may depend on besides itself, Forbid the
+// layers it must not depend on
+type ArchitectureRule struct {
+	From   string   `json:"from"`
+	Allow  []string `json:"allow,omitempty"`
+	Forbid []string `json:"forbid,omitempty"`
+}
+
+// ArchitectureViolation is an import from one layer into a layer its rule does not permit
+type ArchitectureViolation struct {
+	From   string `json:"from"` // layer of the importing file
+	To     string `json:"to"`   // layer of the imported file
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Import string `json:"import"`
+	Target string `json:"target"`
+}
+
+// loadArchitectureRules reads a YAML rules file of the form
+//
+//	layers:
+//	  - name: domain
+//	    paths: [internal/domain/**]
+//	  - name: http
+//	    paths: [internal/http/**]
+//	rules:
+//	  - from: domain
+//	    forbid: [http]
+//	  - from: http
+//	    allow: [domain]
+//
+// A file belongs to the first layer with a glob matching its repository-relative
+// path or one of its parent directories
+func loadArchitectureRules(path string) (*ArchitectureCheck, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseYAML(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	root, _ := doc.(map[string]interface{})
+
+	check := &ArchitectureCheck{RulesFile: path, Layers: []ArchitectureLayer{}, Rules: []ArchitectureRule{}}
+	layers := make(map[string]bool)
+	for i, item := range yamlList(root["layers"]) {
+		entry, _ := item.(map[string]interface{})
+		layer := ArchitectureLayer{Name: yamlString(item, "name"), Paths: yamlStrings(entry["paths"])}
+		switch {
+		case layer.Name == "":
+			return nil, fmt.Errorf("%s: layer %d has no name", path, i+1)
+		case layers[layer.Name]:
+			return nil, fmt.Errorf("%s: layer %q is defined twice", path, layer.Name)
+		case len(layer.Paths) == 0:
+			return nil, fmt.Errorf("%s: layer %q has no paths", path, layer.Name)
+		}
+		layers[layer.Name] = true
+		check.Layers = append(check.Layers, layer)
+	}
+
+	for i, item := range yamlList(root["rules"]) {
+		entry, _ := item.(map[string]interface{})
+		rule := ArchitectureRule{From: yamlString(item, "from"), Allow: yamlStrings(entry["allow"]), Forbid: yamlStrings(entry["forbid"])}
+		if _, ok := entry["allow"]; ok && rule.Allow == nil {
+			rule.Allow = []string{} // "allow: []" permits no other layer
+		}
+		if !layers[rule.From] {
+			return nil, fmt.Errorf("%s: rule %d: unknown layer %q", path, i+1, rule.From)
+		}
+		if rule.Allow == nil && rule.Forbid == nil {
+			return nil, fmt.Errorf("%s: rule %d: %q needs allow or forbid", path, i+1, rule.From)
+		}
+		for _, name := range append(append([]string{}, rule.Allow...), rule.Forbid...) {
+			if !layers[name] {
+				return nil, fmt.Errorf("%s: rule %d: unknown layer %q", path, i+1, name)
+			}
+		}
+		check.Rules = append(check.Rules, rule)
+	}
+	if len(check.Layers) == 0 {
+		return nil, fmt.Errorf("%s: no layers defined", path)
+	}
+	return check, nil
+}
+
+// CheckArchitecture evaluates the rules file given by Config.RulesFile against
+// the internal import edges and records every import a rule does not permit
+func (c *Crawler) CheckArchitecture() error {
+	check, err := loadArchitectureRules(c.Config.RulesFile)
+	if err != nil {
+		return err
+	}
+
+	layerOf := make(map[string]string)
+	counts := make(map[string]int)
+	for _, files := range c.Analysis.FilesByType {
+		for _, file := range files {
+			if layer := check.layerOf(filepath.ToSlash(relativePath(c.Config.TargetPath, file.Path))); layer != "" {
+				layerOf[file.Path] = layer
+				counts[layer]++
+			}
+		}
+	}
+	for i := range check.Layers {
+		check.Layers[i].Files = counts[check.Layers[i].Name]
+	}
+
+	rules := make(map[string][]ArchitectureRule)
+	for _, rule := range check.Rules {
+		rules[rule.From] = append(rules[rule.From], rule)
+	}
+
+	check.Violations = []*ArchitectureViolation{}
+	seen := make(map[string]bool) // an import resolving to a whole package is reported once per layer
+	for _, edge := range c.Analysis.Dependencies.ImportEdges {
+		from, to := layerOf[edge.From], layerOf[edge.To]
+		if edge.Class != importInternal || from == "" || to == "" || from == to {
+			continue
+		}
+		permitted := true
+		for _, rule := range rules[from] {
+			if containsString(rule.Forbid, to) || (rule.Allow != nil && !containsString(rule.Allow, to)) {
+				permitted = false
+			}
+		}
+		key := fmt.Sprintf("%s|%d|%s|%s", edge.From, edge.Line, edge.Import, to)
+		if permitted || seen[key] {
+			continue
+		}
+		seen[key] = true
+		check.Violations = append(check.Violations, &ArchitectureViolation{
+			From: from, To: to, File: edge.From, Line: edge.Line, Import: edge.Import, Target: edge.To,
+		})
+	}
+	sort.SliceStable(check.Violations, func(i, j int) bool {
+		a, b := check.Violations[i], check.Violations[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+
+	c.Analysis.Dependencies.Architecture = check
+	return nil
+}
+
+// layerOf returns the first layer with a glob matching the path or one of its
+// parent directories, or ""
+func (check *ArchitectureCheck) layerOf(rel string) string {
+	for _, layer := range check.Layers {
+		for _, pattern := range layer.Paths {
+			for p := rel; p != "." && p != "/" && p != ""; p = filepath.ToSlash(filepath.Dir(p)) {
+				if matchPathGlob(pattern, p) {
+					return layer.Name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// yamlList returns the items of a YAML sequence
+func yamlList(node interface{}) []interface{} {
+	items, _ := node.([]interface{})
+	return items
+}
+
+// yamlStrings returns a YAML sequence of strings, or a single scalar as a one-item list
+func yamlStrings(node interface{}) []string {
+	if s, ok := node.(string); ok {
+		return []string{strings.TrimSpace(s)}
+	}
+	return stringList(node)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const layeringRules = `layers:
+  - name: domain
+    paths: [internal/domain/**]
+  - name: http
+    paths: [internal/http/**]
+  - name: db
+    paths: [internal/db]
+rules:
+  - from: domain
+    allow: []
+  - from: http
+    forbid: [db]
+`
+
+// writeRules writes a rules file outside the scanned fixture
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadArchitectureRules(t *testing.T) {
+	check, err := loadArchitectureRules(writeRules(t, layeringRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ArchitectureRule{{From: "domain", Allow: []string{}}, {From: "http", Forbid: []string{"db"}}}
+	if !reflect.DeepEqual(check.Rules, want) {
+		t.Errorf("rules = %+v, want %+v", check.Rules, want)
+	}
+	for rel, layer := range map[string]string{
+		"internal/domain/user.go":    "domain",
+		"internal/db/sql/query.go":   "db",
+		"internal/http/handler.go":   "http",
+		"cmd/server/main.go":         "",
+		"internal/domainish/file.go": "",
+	} {
+		if got := check.layerOf(rel); got != layer {
+			t.Errorf("layerOf(%q) = %q, want %q", rel, got, layer)
+		}
+	}
+
+	for _, bad := range []string{
+		"layers: []\n",
+		"layers:\n  - name: a\n    paths: [a]\n  - name: a\n    paths: [b]\n",
+		"layers:\n  - name: a\n    paths: [a]\nrules:\n  - from: a\n    forbid: [b]\n",
+		"layers:\n  - name: a\n    paths: [a]\nrules:\n  - from: a\n",
+	} {
+		if _, err := loadArchitectureRules(writeRules(t, bad)); err == nil {
+			t.Errorf("rules %q loaded without error", bad)
+		}
+	}
+}
+
+func TestCheckArchitecture(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"internal/domain/user.js":  "import { db } from '../db/conn';\nexport const user = 1;\n",
+		"internal/http/handler.js": "import { user } from '../domain/user';\nimport { db } from '../db/conn';\n",
+		"internal/db/conn.js":      "export const db = 1;\n",
+	})
+	c.Config.RulesFile = writeRules(t, layeringRules)
+	if err := c.CheckArchitecture(); err != nil {
+		t.Fatal(err)
+	}
+
+	check := c.Analysis.Dependencies.Architecture
+	var got []string
+	for _, v := range check.Violations {
+		got = append(got, v.From+" -> "+v.To+" "+relativePath(root, v.File)+":"+strconv.Itoa(v.Line))
+	}
+	want := []string{
+		"domain -> db internal/domain/user.js:1",
+		"http -> db internal/http/handler.js:2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("violations = %q, want %q", got, want)
+	}
+
+	var counts []string
+	for _, layer := range check.Layers {
+		counts = append(counts, layer.Name+"="+strconv.Itoa(layer.Files))
+	}
+	if strings.Join(counts, " ") != "domain=1 http=1 db=1" {
+		t.Errorf("layer file counts = %q", counts)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// cargoDependencyTables are the dependency tables of a Cargo manifest, also
+// found under [target.'cfg(...)']
+var cargoDependencyTables = []string{"dependencies", "dev-dependencies", "build-dependencies"}
+
+// parseCargoToml parses Cargo.toml; dependencies are recorded by
+// resolveCargoWorkspaces once `workspace = true` entries can be inherited
+func (c *Crawler) parseCargoToml(content []byte, m *Manifest) {
+	doc, err := parseTOML(content)
+	if err != nil {
+		return
+	}
+	m.cargo = doc
+	m.Name = tomlString(tomlTable(doc, "package"), "name")
+}
+
+// resolveCargoWorkspaces records the dependencies of every Cargo.toml,
+// inheriting from [workspace] roots, and records each workspace's crate graph
+func (c *Crawler) resolveCargoWorkspaces() {
+	var crates, roots []*Manifest
+	byDir := make(map[string]*Manifest)
+	for _, m := range c.Analysis.Dependencies.Manifests {
+		if m.cargo == nil {
+			continue
+		}
+		crates = append(crates, m)
+		byDir[filepath.Dir(m.Path)] = m
+		if tomlTable(m.cargo, "workspace") != nil {
+			roots = append(roots, m)
+		}
+	}
+
+	// Each crate belongs to the innermost workspace that lists it as a member
+	members := make(map[*Manifest][]*Manifest)
+	workspaceOf := make(map[*Manifest]*Manifest)
+	for _, root := range roots {
+		for _, m := range crates {
+			if cargoWorkspaceIncludes(root, m) {
+				if current := workspaceOf[m]; current == nil || len(current.Path) < len(root.Path) {
+					workspaceOf[m] = root
+				}
+			}
+		}
+	}
+	for _, m := range crates {
+		if root := workspaceOf[m]; root != nil {
+			members[root] = append(members[root], m)
+		}
+	}
+
+	for _, m := range crates {
+		c.recordCargoDependencies(m, workspaceOf[m])
+	}
+
+	for _, root := range roots {
+		c.recordCargoWorkspace(root, members[root], byDir)
+	}
+}
+
+// cargoWorkspaceIncludes reports whether a crate is a member of the workspace
+// rooted at root, honouring package.workspace, members and exclude
+func cargoWorkspaceIncludes(root, m *Manifest) bool {
+	rootDir, dir := filepath.Dir(root.Path), filepath.Dir(m.Path)
+	if root == m {
+		return tomlTable(m.cargo, "package") != nil
+	}
+	if explicit := tomlString(tomlTable(m.cargo, "package"), "workspace"); explicit != "" {
+		return filepath.Join(dir, filepath.FromSlash(explicit)) == rootDir
+	}
+
+	rel, err := filepath.Rel(rootDir, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	workspace := tomlTable(root.cargo, "workspace")
+	for _, pattern := range tomlStrings(workspace, "exclude") {
+		if matchPathGlob(pattern, rel) {
+			return false
+		}
+	}
+	for _, pattern := range tomlStrings(workspace, "members") {
+		if matchPathGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordCargoDependencies adds the dependencies of every dependency table of a
+// crate, resolving `workspace = true` entries against the workspace root
+func (c *Crawler) recordCargoDependencies(m *Manifest, root *Manifest) {
+	var workspace map[string]interface{}
+	if root != nil {
+		workspace = tomlTable(root.cargo, "workspace")
+	}
+
+	pkg := tomlTable(m.cargo, "package")
+	if version := tomlString(pkg, "version"); version != "" {
+		m.Version = version
+	} else if inheritsFromWorkspace(pkg["version"]) {
+		m.Version = tomlString(tomlTable(workspace, "package"), "version")
+	}
+
+	type table struct {
+		group, target string
+		deps          map[string]interface{}
+	}
+	var tables []table
+	for _, group := range cargoDependencyTables {
+		tables = append(tables, table{group, "", tomlTable(m.cargo, group)})
+	}
+	targets := tomlTable(m.cargo, "target")
+	for _, target := range sortedKeys(targets) {
+		for _, group := range cargoDependencyTables {
+			tables = append(tables, table{group, target, tomlTable(targets, target, group)})
+		}
+	}
+
+	for _, t := range tables {
+		for _, key := range sortedKeys(t.deps) {
+			spec := t.deps[key]
+			var rootSpec interface{}
+			if inheritsFromWorkspace(spec) {
+				rootSpec = tomlTable(workspace, "dependencies")[key]
+			}
+			dep := m.addCargoDependency(t.group, key, spec, rootSpec)
+			dep.Target = t.target
+			if dep.Source == "path" {
+				// Paths inherited from the workspace are relative to its root
+				base := filepath.Dir(m.Path)
+				if rootSpec != nil && tomlString(asTable(spec), "path") == "" {
+					base = filepath.Dir(root.Path)
+				}
+				dep.URL = filepath.Join(base, filepath.FromSlash(dep.URL))
+			}
+		}
+	}
+}
+
+// addCargoDependency records one dependency entry; rootSpec is the matching
+// [workspace.dependencies] entry for `workspace = true` dependencies
+func (m *Manifest) addCargoDependency(group, key string, spec, rootSpec interface{}) *Dependency {
+	name, version := key, ""
+	local := asTable(spec)
+	base := asTable(rootSpec)
+	if s, ok := spec.(string); ok {
+		version = s
+	}
+	if s, ok := rootSpec.(string); ok {
+		base = map[string]interface{}{"version": s}
+	}
+
+	field := func(key string) string {
+		if v := tomlString(local, key); v != "" {
+			return v
+		}
+		return tomlString(base, key)
+	}
+	if v := field("version"); v != "" {
+		version = v
+	}
+	if pkg := field("package"); pkg != "" {
+		name = pkg
+	}
+
+	dep := m.addDependency(group, name, version)
+	if name != key {
+		dep.Alias = key
+	}
+	dep.Optional, _ = local["optional"].(bool)
+	dep.Features = append(tomlStrings(base, "features"), tomlStrings(local, "features")...)
+
+	switch {
+	case field("path") != "":
+		dep.Source = "path"
+		dep.URL = field("path")
+	case field("git") != "":
+		dep.Source = "git"
+		dep.URL = field("git")
+		for _, ref := range []string{"rev", "tag", "branch"} {
+			if v := field(ref); v != "" {
+				dep.URL += "#" + ref + "=" + v
+				break
+			}
+		}
+	}
+	return dep
+}
+
+// asTable returns v as a TOML table, or nil
+func asTable(v interface{}) map[string]interface{} {
+	t, _ := v.(map[string]interface{})
+	return t
+}
+
+// inheritsFromWorkspace reports whether a value is `{ workspace = true }`
+func inheritsFromWorkspace(v interface{}) bool {
+	inherit, _ := asTable(v)["workspace"].(bool)
+	return inherit
+}
+
+// recordCargoWorkspace records a workspace and the path dependencies between
+// its member crates
+func (c *Crawler) recordCargoWorkspace(root *Manifest, members []*Manifest, byDir map[string]*Manifest) {
+	sort.Slice(members, func(i, j int) bool { return members[i].Path < members[j].Path })
+	inWorkspace := make(map[*Manifest]bool)
+	for _, m := range members {
+		inWorkspace[m] = true
+	}
+
+	ws := &Workspace{Kind: "cargo", Root: root.Path, Name: root.Name, Members: []string{}, Edges: []WorkspaceEdge{}}
+	for _, m := range members {
+		ws.Members = append(ws.Members, m.Path)
+		for _, dep := range m.Dependencies {
+			if dep.Source != "path" {
+				continue
+			}
+			if target := byDir[dep.URL]; target != nil && inWorkspace[target] {
+				ws.Edges = append(ws.Edges, WorkspaceEdge{From: m.Path, To: target.Path, Kind: dep.Group})
+			}
+		}
+	}
+	c.Analysis.Dependencies.Workspaces = append(c.Analysis.Dependencies.Workspaces, ws)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCargoWorkspace(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"Cargo.toml": `[workspace]
+members = ["crates/*"]
+exclude = ["crates/skip"]
+
+[workspace.dependencies]
+serde = { version = "1.0", features = ["derive"] }
+`,
+		"crates/core/Cargo.toml": `[package]
+name = "core"
+version = "0.1.0"
+
+[dependencies]
+serde = { workspace = true, features = ["rc"] }
+rand = "0.8"
+
+[dev-dependencies]
+tokio = { version = "1", features = ["full"] }
+
+[target.'cfg(unix)'.dependencies]
+libc = "0.2"
+`,
+		"crates/app/Cargo.toml": `[package]
+name = "app"
+version = "0.1.0"
+
+[dependencies]
+core = { path = "../core" }
+log = { git = "https://github.com/rust-lang/log", branch = "main" }
+
+[build-dependencies]
+cc = "1"
+`,
+		"crates/skip/Cargo.toml": "[package]\nname = \"skip\"\nversion = \"0.1.0\"\n",
+	})
+
+	core := findManifest(c, root, "crates/core/Cargo.toml")
+	want := []string{"dependencies rand 0.8", "dependencies serde 1.0", "dev-dependencies tokio 1", "dependencies libc 0.2"}
+	if got := manifestDeps(core); !reflect.DeepEqual(got, want) {
+		t.Errorf("core dependencies = %q, want %q", got, want)
+	}
+	// Workspace inheritance merges features; target tables keep their cfg
+	if serde := core.Dependencies[1]; !reflect.DeepEqual(serde.Features, []string{"derive", "rc"}) {
+		t.Errorf("serde features = %v, want the workspace and member features", serde.Features)
+	}
+	if libc := core.Dependencies[3]; libc.Target != "cfg(unix)" {
+		t.Errorf("libc target = %q, want cfg(unix)", libc.Target)
+	}
+
+	app := findManifest(c, root, "crates/app/Cargo.toml")
+	want = []string{"dependencies core ", "dependencies log ", "build-dependencies cc 1"}
+	if got := manifestDeps(app); !reflect.DeepEqual(got, want) {
+		t.Errorf("app dependencies = %q, want %q", got, want)
+	}
+	if log := app.Dependencies[1]; log.Source != "git" || log.URL != "https://github.com/rust-lang/log#branch=main" {
+		t.Errorf("log: source %q, url %q", log.Source, log.URL)
+	}
+
+	var members []string
+	for _, ws := range c.Analysis.Dependencies.Workspaces {
+		for _, member := range ws.Members {
+			members = append(members, relativePath(root, member))
+		}
+	}
+	// Excluded crates stay out of the workspace
+	if want := []string{"crates/app/Cargo.toml", "crates/core/Cargo.toml"}; !reflect.DeepEqual(members, want) {
+		t.Errorf("workspace members = %v, want %v", members, want)
+	}
+	want = []string{"crates/app/Cargo.toml -> crates/core/Cargo.toml (dependencies)"}
+	if got := workspaceEdges(c, root, "Cargo.toml"); !reflect.DeepEqual(got, want) {
+		t.Errorf("crate graph = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// parseComposerJSON parses composer.json. Platform requirements such as php
+// and ext-json have no vendor prefix and are not packages, so they are skipped.
+func (c *Crawler) parseComposerJSON(content []byte, m *Manifest) {
+	var data struct {
+		Name       string            `json:"name"`
+		Version    string            `json:"version"`
+		Require    map[string]string `json:"require"`
+		RequireDev map[string]string `json:"require-dev"`
+	}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return
+	}
+	m.Name = data.Name
+	m.Version = data.Version
+
+	for _, group := range []struct {
+		name string
+		deps map[string]string
+	}{{"require", data.Require}, {"require-dev", data.RequireDev}} {
+		for _, name := range sortedKeys(group.deps) {
+			if !strings.Contains(name, "/") {
+				continue
+			}
+			m.addDependency(group.name, strings.ToLower(name), group.deps[name])
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseComposerJSON(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"composer.json": `{
+  "name": "acme/site",
+  "version": "1.2.0",
+  "require": {"php": ">=8.1", "ext-json": "*", "laravel/framework": "^10.0"},
+  "require-dev": {"phpunit/phpunit": "^10.0"}
+}`,
+	})
+
+	m := findManifest(c, root, "composer.json")
+	if m.Name != "acme/site" || m.Version != "1.2.0" {
+		t.Errorf("manifest = %s@%s, want acme/site@1.2.0", m.Name, m.Version)
+	}
+	// Platform requirements are not packages
+	want := []string{"require laravel/framework ^10.0", "require-dev phpunit/phpunit ^10.0"}
+	if got := manifestDeps(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("dependencies = %q, want %q", got, want)
+	}
+}
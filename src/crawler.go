@@ -14,6 +14,14 @@ type CrawlerConfig struct {
 	OutputPath  string
 	ExcludeDirs []string
 	Verbose     bool
+	TopN        int
+	OSVDatabase string // OSV export (directory or zip) to match dependencies against
+
+	LicenseAllow []string // SPDX identifiers dependencies may use; empty allows all but denied ones
+	LicenseDeny  []string // SPDX identifiers dependencies must not use
+
+	SBOMFormat string // "cyclonedx-json" or "spdx-json"; "" writes no SBOM
+	RulesFile  string // architecture layering rules to check the import graph against
 }
 
 // Crawler is the main crawler instance
@@ -66,13 +74,105 @@ type FileInfo struct {
 
 // DependencyAnalysis holds dependency information
 type DependencyAnalysis struct {
-	PackageManagers map[string]*PackageManager `json:"package_managers"`
-	ImportGraph     map[string][]string        `json:"import_graph"`
-	FileNamespaces  map[string]string          `json:"file_namespaces"`
-	ExternalDeps    []string                   `json:"external_deps"`
+	Manifests        []*Manifest                `json:"manifests"`
+	Lockfiles        []*Lockfile                `json:"lockfiles"`
+	DependencyTrees  []*DependencyTree          `json:"dependency_trees"`
+	Workspaces       []*Workspace               `json:"workspaces"`
+	Vulnerabilities  []*Vulnerability           `json:"vulnerabilities"`
+	LicenseFiles     []*LicenseFile             `json:"license_files"`
+	Licenses         []*DependencyLicense       `json:"licenses"`
+	VersionDrift     []*VersionDrift            `json:"version_drift"`
+	UnusedDeps       []*UnusedDependency        `json:"unused_dependencies"`
+	UndeclaredDeps   []*UndeclaredDependency    `json:"undeclared_dependencies"`
+	PackageManagers  map[string]*PackageManager `json:"package_managers"`
+	ImportGraph      map[string][]string        `json:"import_graph"`
+	FileImports      map[string][]ImportRef     `json:"file_imports"`
+	ImportEdges      []ImportEdge               `json:"import_edges"`
+	ImportCycles     []*ImportCycle             `json:"import_cycles"`
+	PackageMetrics   []*PackageMetrics          `json:"package_metrics"`
+	Architecture     *ArchitectureCheck         `json:"architecture,omitempty"`
+	FileNamespaces   map[string]string          `json:"file_namespaces"`
+	BuildConstraints map[string]string          `json:"build_constraints,omitempty"`
+	ExternalDeps     []string                   `json:"external_deps"`
+}
+
+// ImportRef is a single import statement found in a source file
+type ImportRef struct {
+	Path  string   `json:"path"`
+	Alias string   `json:"alias,omitempty"`
+	Kind  string   `json:"kind,omitempty"`  // "blank"/"dot" (Go), "type" (type-only), "reexport", "dynamic" (import()) or "reference" (triple-slash)
+	Names []string `json:"names,omitempty"` // names imported from the module, e.g. by Python's from-import
+	Line  int      `json:"line"`
+
+	// Filled in by resolveImports
+	Class    string   `json:"class,omitempty"`    // "internal", "external" or "stdlib"
+	Package  string   `json:"package,omitempty"`  // package directory or namespace, when the import names one
+	Resolved []string `json:"resolved,omitempty"` // repository files the import points to
+}
+
+// Manifest is a single parsed package manager file
+type Manifest struct {
+	Path         string        `json:"path"`
+	Manager      string        `json:"manager"`
+	Name         string        `json:"name,omitempty"`
+	Version      string        `json:"version,omitempty"`
+	Dependencies []*Dependency `json:"dependencies"`
+	Frameworks   []string      `json:"frameworks,omitempty"` // target frameworks, e.g. net8.0
+	Error        string        `json:"error,omitempty"`      // why the file could not be parsed
+	Go           *GoModule     `json:"go,omitempty"`
+	Node         *NodePackage  `json:"node,omitempty"`
+
+	Lockfile   string   `json:"lockfile,omitempty"`
+	LockStatus string   `json:"lock_status,omitempty"` // "locked", "missing" or "out-of-sync"
+	LockIssues []string `json:"lock_issues,omitempty"`
+
+	// Parsed build files, resolved against the rest of the repo
+	pom     *pomProject
+	gradle  *gradleBuild
+	cargo   map[string]interface{}
+	msbuild *msbuildProject
+}
+
+// Dependency is a dependency declared in a manifest
+type Dependency struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Group   string `json:"group"` // e.g. "dependencies", "devDependencies", "require"
+
+	Optional bool     `json:"optional,omitempty"`
+	Extras   []string `json:"extras,omitempty"`
+	Markers  string   `json:"markers,omitempty"` // environment markers, e.g. python_version < "3.8"
+	Source   string   `json:"source,omitempty"`  // "workspace", "path", "git", "vcs" or "url" when not from a registry
+	URL      string   `json:"url,omitempty"`     // location for path, VCS and URL sources
+	Editable bool     `json:"editable,omitempty"`
+	Features []string `json:"features,omitempty"`
+	Target   string   `json:"target,omitempty"`   // platform condition, e.g. cfg(windows)
+	Alias    string   `json:"alias,omitempty"`    // name the dependency is imported under, when renamed
+	Indirect bool     `json:"indirect,omitempty"` // only needed by other dependencies (go.mod // indirect)
+
+	// Filled in from the manifest's lockfile
+	Resolved  string `json:"resolved,omitempty"`
+	Integrity string `json:"integrity,omitempty"`
+}
+
+// Workspace is a multi-project build whose members depend on each other
+type Workspace struct {
+	Kind    string          `json:"kind"` // e.g. "maven"
+	Root    string          `json:"root"`
+	Name    string          `json:"name,omitempty"`
+	Members []string        `json:"members"`
+	Edges   []WorkspaceEdge `json:"edges"`
+	Issues  []string        `json:"issues,omitempty"` // e.g. two members with the same package name
 }
 
-// PackageManager represents a detected package manager
+// WorkspaceEdge is a dependency of one workspace member on another
+type WorkspaceEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"` // dependency group, or "parent"
+}
+
+// PackageManager is the per-manager summary aggregated over all its manifests
 type PackageManager struct {
 	Name         string            `json:"name"`
 	ConfigFiles  []string          `json:"config_files"`
@@ -88,6 +188,13 @@ type Statistics struct {
 	BlankLines      int            `json:"blank_lines"`
 	AvgFileSize     int64          `json:"avg_file_size"`
 	FilesByLanguage map[string]int `json:"files_by_language"`
+
+	// Keyed by metric name ("size", "lines")
+	TopFiles      map[string][]FileInfo    `json:"top_files"`
+	Distributions map[string]*Distribution `json:"distributions"`
+
+	// Keyed by language, then by metric name
+	LanguageDistributions map[string]map[string]*Distribution `json:"language_distributions"`
 }
 
 // NewCrawler creates a new crawler instance
@@ -99,16 +206,30 @@ func NewCrawler(config *CrawlerConfig) *Crawler {
 			AnalyzedAt:  time.Now(),
 			FilesByType: make(map[string][]FileInfo),
 			Dependencies: &DependencyAnalysis{
-				PackageManagers: make(map[string]*PackageManager),
-				ImportGraph:     make(map[string][]string),
-				FileNamespaces:  make(map[string]string),
-				ExternalDeps:    []string{},
+				Manifests:        []*Manifest{},
+				Lockfiles:        []*Lockfile{},
+				DependencyTrees:  []*DependencyTree{},
+				Workspaces:       []*Workspace{},
+				Vulnerabilities:  []*Vulnerability{},
+				LicenseFiles:     []*LicenseFile{},
+				Licenses:         []*DependencyLicense{},
+				VersionDrift:     []*VersionDrift{},
+				PackageManagers:  make(map[string]*PackageManager),
+				ImportGraph:      make(map[string][]string),
+				FileImports:      make(map[string][]ImportRef),
+				ImportEdges:      []ImportEdge{},
+				FileNamespaces:   make(map[string]string),
+				BuildConstraints: make(map[string]string),
+				ExternalDeps:     []string{},
 			},
 			Summary: &Summary{
 				Languages: make(map[string]int),
 			},
 			Statistics: &Statistics{
-				FilesByLanguage: make(map[string]int),
+				FilesByLanguage:       make(map[string]int),
+				TopFiles:              make(map[string][]FileInfo),
+				Distributions:         make(map[string]*Distribution),
+				LanguageDistributions: make(map[string]map[string]*Distribution),
 			},
 		},
 	}
@@ -255,29 +376,30 @@ func (c *Crawler) shouldExclude(name string) bool {
 
 // calculateSummary calculates summary statistics
 func (c *Crawler) calculateSummary() {
-	// Find largest files
 	var allFiles []FileInfo
 	for _, files := range c.Analysis.FilesByType {
 		allFiles = append(allFiles, files...)
 	}
 
-	// Sort and get top 10 largest files
-	if len(allFiles) > 0 {
-		// Simple bubble sort for top 10
-		for i := 0; i < len(allFiles) && i < 10; i++ {
-			for j := i + 1; j < len(allFiles); j++ {
-				if allFiles[j].Size > allFiles[i].Size {
-					allFiles[i], allFiles[j] = allFiles[j], allFiles[i]
-				}
+	topN := c.Config.TopN
+	if topN <= 0 {
+		topN = 10
+	}
+
+	for _, metric := range fileMetrics {
+		c.Analysis.Statistics.TopFiles[metric.Name] = topFiles(allFiles, metric, topN)
+		c.Analysis.Statistics.Distributions[metric.Name] = newDistribution(allFiles, metric)
+
+		for lang, files := range c.Analysis.FilesByType {
+			if c.Analysis.Statistics.LanguageDistributions[lang] == nil {
+				c.Analysis.Statistics.LanguageDistributions[lang] = make(map[string]*Distribution)
 			}
-		}
-		if len(allFiles) > 10 {
-			c.Analysis.Summary.LargestFiles = allFiles[:10]
-		} else {
-			c.Analysis.Summary.LargestFiles = allFiles
+			c.Analysis.Statistics.LanguageDistributions[lang][metric.Name] = newDistribution(files, metric)
 		}
 	}
 
+	c.Analysis.Summary.LargestFiles = c.Analysis.Statistics.TopFiles["size"]
+
 	// Calculate average file size
 	if c.Analysis.Summary.TotalFiles > 0 {
 		c.Analysis.Statistics.AvgFileSize = c.Analysis.Summary.TotalSize / int64(c.Analysis.Summary.TotalFiles)
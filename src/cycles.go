@@ -0,0 +1,217 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Import cycle levels
+const (
+	cycleFile      = "file"
+	cycleDirectory = "directory"
+	cyclePackage   = "package"
+)
+
+// ImportCycle is a strongly connected component of the internal import graph,
+// with files grouped into directories or packages at the coarser levels
+type ImportCycle struct {
+	Level   string       `json:"level"`   // "file", "directory" or "package"
+	Members []string     `json:"members"` // every node of the component
+	Path    []string     `json:"path"`    // a shortest cycle through the component, ending where it starts
+	Steps   []ImportEdge `json:"steps"`   // one import statement for each hop of Path
+}
+
+// cycleGraph is the internal import graph at one level; edges keep the
+// indexes of the ImportEdges each hop stands for
+type cycleGraph struct {
+	nodes []string
+	edges map[string]map[string][]int
+}
+
+// analyzeImportCycles finds the strongly connected components of the resolved
+// internal import graph at file, directory and package level, records a
+// shortest example cycle for each and marks the import edges inside them
+func (c *Crawler) analyzeImportCycles() {
+	deps := c.Analysis.Dependencies
+	fileLang := make(map[string]string)
+	for lang, files := range c.Analysis.FilesByType {
+		for _, file := range files {
+			fileLang[file.Path] = lang
+		}
+	}
+
+	packageOf := func(file string) string {
+		return sourcePackage(file, fileLang[file], deps.FileNamespaces[file])
+	}
+	levels := []struct {
+		name string
+		key  func(string) string
+	}{
+		{cycleFile, func(file string) string { return file }},
+		{cycleDirectory, filepath.Dir},
+		{cyclePackage, packageOf},
+	}
+
+	deps.ImportCycles = []*ImportCycle{}
+	seen := make(map[string]bool) // package cycles that repeat a directory cycle are skipped
+	for _, level := range levels {
+		g := &cycleGraph{edges: make(map[string]map[string][]int)}
+		for i, edge := range deps.ImportEdges {
+			if edge.Class != importInternal || fileLang[edge.To] == "" {
+				continue
+			}
+			from, to := level.key(edge.From), level.key(edge.To)
+			if from != to {
+				g.addEdge(from, to, i)
+			}
+		}
+
+		for _, members := range g.components() {
+			signature := strings.Join(members, "\x00")
+			if len(members) < 2 || seen[signature] {
+				continue
+			}
+			seen[signature] = true
+
+			inside := setOf(members...)
+			for _, from := range members {
+				for to, indexes := range g.edges[from] {
+					if !inside[to] {
+						continue
+					}
+					for _, i := range indexes {
+						if !containsString(deps.ImportEdges[i].Cycles, level.name) {
+							deps.ImportEdges[i].Cycles = append(deps.ImportEdges[i].Cycles, level.name)
+						}
+					}
+				}
+			}
+
+			cycle := &ImportCycle{Level: level.name, Members: members, Path: g.shortestCycle(inside, members)}
+			for i := 0; i+1 < len(cycle.Path); i++ {
+				step := deps.ImportEdges[g.edges[cycle.Path[i]][cycle.Path[i+1]][0]]
+				step.Cycles = nil
+				cycle.Steps = append(cycle.Steps, step)
+			}
+			deps.ImportCycles = append(deps.ImportCycles, cycle)
+		}
+	}
+}
+
+// sourcePackage returns the package a file belongs to: "<language>:<namespace>"
+// for Java and C#, whose namespaces may span directories, and the directory
+// for every other language
+func sourcePackage(file, lang, namespace string) string {
+	if namespace != "" && (lang == "Java" || lang == "C#") {
+		return lang + ":" + namespace
+	}
+	return filepath.Dir(file)
+}
+
+// addEdge records that import edge i leads from one node to another
+func (g *cycleGraph) addEdge(from, to string, i int) {
+	for _, node := range []string{from, to} {
+		if _, ok := g.edges[node]; !ok {
+			g.edges[node] = make(map[string][]int)
+			g.nodes = append(g.nodes, node)
+		}
+	}
+	g.edges[from][to] = append(g.edges[from][to], i)
+}
+
+// components returns the strongly connected components of the graph using
+// Tarjan's algorithm, each sorted, in order of their first member
+func (g *cycleGraph) components() [][]string {
+	sort.Strings(g.nodes)
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var connect func(node string)
+	connect = func(node string) {
+		index[node] = len(index)
+		low[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range sortedKeys(g.edges[node]) {
+			if _, visited := index[next]; !visited {
+				connect(next)
+				low[node] = min(low[node], low[next])
+			} else if onStack[next] {
+				low[node] = min(low[node], index[next])
+			}
+		}
+
+		if low[node] == index[node] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == node {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range g.nodes {
+		if _, visited := index[node]; !visited {
+			connect(node)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	return components
+}
+
+// shortestCycle finds a shortest cycle within a component by breadth-first
+// search from each member back to itself; the first member wins ties
+func (g *cycleGraph) shortestCycle(inside map[string]bool, members []string) []string {
+	var best []string
+	for _, start := range members {
+		parent := map[string]string{start: ""}
+		depth := map[string]int{start: 0}
+		queue := []string{start}
+		var found []string
+		for len(queue) > 0 && found == nil {
+			node := queue[0]
+			queue = queue[1:]
+			if best != nil && depth[node]+1 >= len(best)-1 {
+				break // cannot beat the cycle already found
+			}
+			for _, next := range sortedKeys(g.edges[node]) {
+				if !inside[next] {
+					continue
+				}
+				if next == start {
+					found = []string{start}
+					for n := node; n != start; n = parent[n] {
+						found = append(found, n)
+					}
+					found = append(found, start)
+					break
+				}
+				if _, queued := parent[next]; !queued {
+					parent[next] = node
+					depth[next] = depth[node] + 1
+					queue = append(queue, next)
+				}
+			}
+		}
+		if found != nil && (best == nil || len(found) < len(best)) {
+			// found was built backwards from the closing edge
+			for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+				found[i], found[j] = found[j], found[i]
+			}
+			best = found
+		}
+	}
+	return best
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCycleGraph(t *testing.T) {
+	g := &cycleGraph{edges: make(map[string]map[string][]int)}
+	for i, e := range [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}, {"b", "a"}, {"c", "d"}, {"d", "e"}, {"e", "d"}, {"f", "a"}} {
+		g.addEdge(e[0], e[1], i)
+	}
+
+	got := g.components()
+	want := [][]string{{"a", "b", "c"}, {"d", "e"}, {"f"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("components = %q, want %q", got, want)
+	}
+	if path := g.shortestCycle(setOf(got[0]...), got[0]); !reflect.DeepEqual(path, []string{"a", "b", "a"}) {
+		t.Errorf("shortest cycle = %q, want [a b a]", path)
+	}
+}
+
+func TestImportCycles(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"a/x.js": "import { y } from '../b/y';\nexport const x = 1;\n",
+		"a/z.js": "import { x } from './x';\n",
+		"b/y.js": "import { x } from '../a/x';\nexport const y = 2;\n",
+	})
+
+	var got []string
+	for _, cycle := range c.Analysis.Dependencies.ImportCycles {
+		desc := cycle.Level + ":"
+		for _, node := range cycle.Path {
+			desc += " " + relativePath(root, node)
+		}
+		if len(cycle.Steps) != len(cycle.Path)-1 {
+			t.Errorf("%s cycle has %d steps for a path of %d", cycle.Level, len(cycle.Steps), len(cycle.Path))
+		}
+		got = append(got, desc)
+	}
+	want := []string{"file: a/x.js b/y.js a/x.js", "directory: a b a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cycles = %q, want %q", got, want)
+	}
+
+	for _, e := range c.Analysis.Dependencies.ImportEdges {
+		onCycle := relativePath(root, e.From) != "a/z.js"
+		if (len(e.Cycles) > 0) != onCycle {
+			t.Errorf("edge %s -> %s marked %q", relativePath(root, e.From), relativePath(root, e.To), e.Cycles)
+		}
+	}
+}
@@ -1,226 +1,238 @@
 package main
 
 import (
-	"encoding/json"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 )
 
 // AnalyzeDependencies analyzes dependencies across different languages
 func (c *Crawler) AnalyzeDependencies() {
 	c.analyzePackageManagers()
+	c.analyzeLockfiles()
+	c.analyzeVersionDrift()
 	c.analyzeImports()
+	c.resolveImports()
+	c.analyzeImportCycles()
+	c.analyzePackageMetrics()
+	c.analyzeDependencyUsage()
 }
 
-// analyzePackageManagers detects and parses package manager files
-func (c *Crawler) analyzePackageManagers() {
-	packageFiles := map[string]string{
-		"package.json":     "npm",
-		"requirements.txt": "pip",
-		"Pipfile":          "pipenv",
-		"poetry.lock":      "poetry",
-		"go.mod":           "go modules",
-		"Cargo.toml":       "cargo",
-		"composer.json":    "composer",
-		"Gemfile":          "bundler",
-		"pom.xml":          "maven",
-		"build.gradle":     "gradle",
-		"Package.swift":    "swift pm",
-		"pubspec.yaml":     "pub",
+// packageFiles maps manifest file names to their package manager
+var packageFiles = map[string]string{
+	"package.json":     "npm",
+	"requirements.txt": "pip",
+	"Pipfile":          "pipenv",
+	"pyproject.toml":   "pip",
+	"setup.cfg":        "pip",
+	"setup.py":         "pip",
+	"go.mod":           "go modules",
+	"Cargo.toml":       "cargo",
+	"composer.json":    "composer",
+	"Gemfile":          "bundler",
+	"pom.xml":          "maven",
+	"build.gradle":     "gradle",
+	"build.gradle.kts": "gradle",
+	"Package.swift":    "swift pm",
+	"pubspec.yaml":     "pub",
+	"packages.config":  "nuget",
+}
+
+// packageFileExtensions maps project file extensions to their package manager
+var packageFileExtensions = map[string]string{
+	".csproj": "nuget",
+	".fsproj": "nuget",
+	".vbproj": "nuget",
+}
+
+// packageManagerFor returns the package manager of a manifest file, if it is one
+func packageManagerFor(path string) (string, bool) {
+	if pm, ok := packageFiles[filepath.Base(path)]; ok {
+		return pm, true
 	}
+	pm, ok := packageFileExtensions[filepath.Ext(path)]
+	return pm, ok
+}
 
+// analyzePackageManagers detects and parses package manager files
+func (c *Crawler) analyzePackageManagers() {
+	var manifestPaths []string
 	for _, files := range c.Analysis.FilesByType {
 		for _, file := range files {
-			baseName := filepath.Base(file.Path)
-			if pmName, exists := packageFiles[baseName]; exists {
-				c.parsePackageFile(file.Path, pmName)
+			if _, exists := packageManagerFor(file.Path); exists {
+				manifestPaths = append(manifestPaths, file.Path)
 			}
 		}
 	}
+	sort.Strings(manifestPaths)
+
+	for _, path := range manifestPaths {
+		pmName, _ := packageManagerFor(path)
+		if manifest := c.parsePackageFile(path, pmName); manifest != nil {
+			c.Analysis.Dependencies.Manifests = append(c.Analysis.Dependencies.Manifests, manifest)
+		}
+	}
+
+	// Some manifests can only be resolved against the other manifests of the repo
+	c.resolveMavenProjects()
+	c.resolveGradleProjects()
+	c.resolveCargoWorkspaces()
+	c.resolveDotNetProjects()
+	c.resolveGoWorkspaces()
+	c.resolveNodeWorkspaces()
+
+	c.summarizePackageManagers()
 }
 
-// parsePackageFile parses a package manager file
-func (c *Crawler) parsePackageFile(filePath, pmName string) {
+// parsePackageFile parses a package manager file into a manifest record
+func (c *Crawler) parsePackageFile(filePath, pmName string) *Manifest {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return
+		return nil
 	}
 
-	pm := &PackageManager{
-		Name:         pmName,
-		ConfigFiles:  []string{filePath},
-		Dependencies: make(map[string]string),
-		DevDeps:      make(map[string]string),
+	m := &Manifest{
+		Path:         filePath,
+		Manager:      pmName,
+		Dependencies: []*Dependency{},
 	}
 
 	switch pmName {
 	case "npm":
-		c.parsePackageJSON(content, pm)
-	case "pip", "pipenv":
-		c.parseRequirementsTxt(content, pm)
+		c.parsePackageJSON(content, m)
+	case "pip":
+		switch filepath.Base(filePath) {
+		case "pyproject.toml":
+			c.parsePyproject(content, m)
+		case "setup.cfg":
+			c.parseSetupCfg(content, m)
+		case "setup.py":
+			c.parseSetupPy(content, m)
+		default:
+			c.parseRequirementsTxt(content, m)
+		}
+	case "pipenv":
+		c.parsePipfile(content, m)
 	case "go modules":
-		c.parseGoMod(content, pm)
+		c.parseGoMod(content, m)
 	case "cargo":
-		c.parseCargoToml(content, pm)
-	default:
-		// Generic parsing
-		c.parseGenericDeps(content, pm)
-	}
-
-	if len(pm.Dependencies) > 0 || len(pm.DevDeps) > 0 {
-		c.Analysis.Dependencies.PackageManagers[pmName] = pm
-
-		// Collect external dependencies
-		for dep := range pm.Dependencies {
-			c.Analysis.Dependencies.ExternalDeps = append(c.Analysis.Dependencies.ExternalDeps, dep)
+		c.parseCargoToml(content, m)
+	case "maven":
+		c.parsePomXML(content, m)
+	case "gradle":
+		c.parseGradleBuild(content, m)
+	case "composer":
+		c.parseComposerJSON(content, m)
+	case "bundler":
+		c.parseGemfile(content, m)
+	case "pub":
+		c.parsePubspec(content, m)
+	case "swift pm":
+		c.parsePackageSwift(content, m)
+	case "nuget":
+		if filepath.Base(filePath) == "packages.config" {
+			c.parsePackagesConfig(content, m)
+		} else {
+			c.parseMSBuildProject(content, m)
 		}
 	}
-}
 
-// parsePackageJSON parses package.json
-func (c *Crawler) parsePackageJSON(content []byte, pm *PackageManager) {
-	var data map[string]interface{}
-	if err := json.Unmarshal(content, &data); err != nil {
-		return
-	}
-
-	if deps, ok := data["dependencies"].(map[string]interface{}); ok {
-		for name, version := range deps {
-			if v, ok := version.(string); ok {
-				pm.Dependencies[name] = v
-			}
-		}
-	}
-
-	if devDeps, ok := data["devDependencies"].(map[string]interface{}); ok {
-		for name, version := range devDeps {
-			if v, ok := version.(string); ok {
-				pm.DevDeps[name] = v
-			}
-		}
-	}
+	return m
 }
 
-// parseRequirementsTxt parses requirements.txt
-func (c *Crawler) parseRequirementsTxt(content []byte, pm *PackageManager) {
-	lines := strings.Split(string(content), "\n")
-	re := regexp.MustCompile(`^([a-zA-Z0-9\-_]+)([>=<~!]+.*)?$`)
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		matches := re.FindStringSubmatch(line)
-		if len(matches) >= 2 {
-			name := matches[1]
-			version := "unspecified"
-			if len(matches) >= 3 && matches[2] != "" {
-				version = matches[2]
-			}
-			pm.Dependencies[name] = version
-		}
-	}
+// addDependency records a dependency in the given group of the manifest
+func (m *Manifest) addDependency(group, name, version string) *Dependency {
+	dep := &Dependency{Name: name, Version: version, Group: group}
+	m.Dependencies = append(m.Dependencies, dep)
+	return dep
 }
 
-// parseGoMod parses go.mod
-func (c *Crawler) parseGoMod(content []byte, pm *PackageManager) {
-	lines := strings.Split(string(content), "\n")
-	inRequire := false
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if strings.HasPrefix(line, "require (") {
-			inRequire = true
-			continue
-		}
-
-		if inRequire && line == ")" {
-			inRequire = false
-			continue
-		}
-
-		if inRequire || strings.HasPrefix(line, "require ") {
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				name := parts[0]
-				if name == "require" && len(parts) >= 3 {
-					name = parts[1]
-					pm.Dependencies[name] = parts[2]
-				} else {
-					version := parts[1]
-					pm.Dependencies[name] = version
-				}
-			}
-		}
-	}
+// isDevGroup reports whether a dependency group only matters for development
+func isDevGroup(group string) bool {
+	group = strings.ToLower(group)
+	return strings.Contains(group, "dev") || strings.Contains(group, "test")
 }
 
-// parseCargoToml parses Cargo.toml
-func (c *Crawler) parseCargoToml(content []byte, pm *PackageManager) {
-	lines := strings.Split(string(content), "\n")
-	inDeps := false
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+// summarizePackageManagers derives the per-manager view from the manifest records
+func (c *Crawler) summarizePackageManagers() {
+	deps := c.Analysis.Dependencies
+	deps.PackageManagers = make(map[string]*PackageManager)
+	external := make(map[string]bool)
 
-		if line == "[dependencies]" {
-			inDeps = true
+	for _, m := range deps.Manifests {
+		if len(m.Dependencies) == 0 {
 			continue
 		}
 
-		if strings.HasPrefix(line, "[") && line != "[dependencies]" {
-			inDeps = false
+		pm, exists := deps.PackageManagers[m.Manager]
+		if !exists {
+			pm = &PackageManager{
+				Name:         m.Manager,
+				ConfigFiles:  []string{},
+				Dependencies: make(map[string]string),
+				DevDeps:      make(map[string]string),
+			}
+			deps.PackageManagers[m.Manager] = pm
 		}
-
-		if inDeps && strings.Contains(line, "=") {
-			parts := strings.Split(line, "=")
-			if len(parts) == 2 {
-				name := strings.TrimSpace(parts[0])
-				version := strings.Trim(strings.TrimSpace(parts[1]), "\"")
-				pm.Dependencies[name] = version
+		pm.ConfigFiles = append(pm.ConfigFiles, m.Path)
+
+		for _, dep := range m.Dependencies {
+			target := pm.Dependencies
+			if isDevGroup(dep.Group) {
+				target = pm.DevDeps
+			} else if dep.Source != "workspace" && dep.Source != "path" {
+				external[dep.Name] = true
+			}
+			// First manifest to declare a package wins
+			if _, seen := target[dep.Name]; !seen {
+				target[dep.Name] = dep.Version
 			}
 		}
 	}
-}
 
-// parseGenericDeps attempts generic dependency parsing
-func (c *Crawler) parseGenericDeps(content []byte, pm *PackageManager) {
-	lines := strings.Split(string(content), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
-			continue
-		}
-		pm.Dependencies[line] = "unknown"
+	deps.ExternalDeps = []string{}
+	for name := range external {
+		deps.ExternalDeps = append(deps.ExternalDeps, name)
 	}
+	sort.Strings(deps.ExternalDeps)
 }
 
 // analyzeImports analyzes import statements in source files
 func (c *Crawler) analyzeImports() {
 	importPatterns := map[string]*regexp.Regexp{
-		"Python":     regexp.MustCompile(`(?m)^(?:from\s+([\w\.]+)|import\s+([\w\.]+))`),
 		"JavaScript": regexp.MustCompile(`(?m)^(?:import.*from\s+['"]([^'"]+)['"]|require\(['"]([^'"]+)['"]\))`),
-		"Go":         regexp.MustCompile(`(?m)^import\s+(?:[\w]+\s+)?["']([^"']+)["']`),
 		"Rust":       regexp.MustCompile(`(?m)^use\s+([\w:]+)`),
-		"Java":       regexp.MustCompile(`(?m)^import\s+([\w\.]+)`),
-		"C#":         regexp.MustCompile(`(?m)^using\s+([\w\.]+)`),
+		"Java":       regexp.MustCompile(`(?m)^import\s+(?:static\s+)?([\w\.]+)`),
+		"C#":         regexp.MustCompile(`(?m)^\s*using\s+(?:static\s+)?(?:\w+\s*=\s*)?([\w\.]+)\s*;`),
 		"Ruby":       regexp.MustCompile(`(?m)^require\s+['"]([^'"]+)['"]`),
 	}
 
 	namespacePatterns := map[string]*regexp.Regexp{
 		"C#":         regexp.MustCompile(`(?m)^\s*namespace\s+([\w\.]+)`),
 		"Java":       regexp.MustCompile(`(?m)^\s*package\s+([\w\.]+)`),
-		"Go":         regexp.MustCompile(`(?m)^\s*package\s+([\w]+)`),
 		"JavaScript": regexp.MustCompile(`(?m)^export\s+(?:default\s+)?(?:class|function|const)\s+(\w+)`),
 		"TypeScript": regexp.MustCompile(`(?m)^export\s+(?:default\s+)?(?:class|function|const|interface|type)\s+(\w+)`),
 	}
 
 	for lang, files := range c.Analysis.FilesByType {
+		switch lang {
+		case "Go":
+			for _, file := range files {
+				c.analyzeGoImports(file)
+			}
+			continue
+		case "Python":
+			c.analyzePythonImports(files)
+			continue
+		case "TypeScript":
+			for _, file := range files {
+				c.analyzeTypeScriptImports(file, namespacePatterns[lang])
+			}
+			continue
+		}
+
 		pattern, exists := importPatterns[lang]
 		if !exists {
 			continue
@@ -244,20 +256,95 @@ func (c *Crawler) analyzeImports() {
 			}
 
 			// Extract imports
-			matches := pattern.FindAllStringSubmatch(contentStr, -1)
-			var imports []string
+			matches := pattern.FindAllStringSubmatchIndex(contentStr, -1)
+			var refs []ImportRef
 
 			for _, match := range matches {
-				for i := 1; i < len(match); i++ {
-					if match[i] != "" {
-						imports = append(imports, match[i])
+				for i := 1; i < len(match)/2; i++ {
+					start, end := match[2*i], match[2*i+1]
+					if start >= 0 && end > start {
+						refs = append(refs, ImportRef{
+							Path: contentStr[start:end],
+							Line: lineAt(contentStr, start),
+						})
 					}
 				}
 			}
 
-			if len(imports) > 0 {
-				c.Analysis.Dependencies.ImportGraph[file.Path] = imports
-			}
+			c.recordImports(file.Path, refs)
 		}
 	}
 }
+
+// analyzeGoImports extracts imports, the package clause and build constraints from a Go file
+func (c *Crawler) analyzeGoImports(file FileInfo) {
+	content, err := os.ReadFile(file.Path)
+	if err != nil {
+		return
+	}
+
+	goFile, err := parseGoImports(file.Path, content)
+	if err != nil {
+		return
+	}
+
+	if goFile.Package != "" {
+		c.Analysis.Dependencies.FileNamespaces[file.Path] = goFile.Package
+	}
+	if goFile.Constraint != "" {
+		c.Analysis.Dependencies.BuildConstraints[file.Path] = goFile.Constraint
+	}
+
+	c.recordImports(file.Path, goFile.Imports)
+}
+
+// analyzePythonImports extracts the imports of Python files and records the
+// dotted package of each file that lives in a regular (__init__.py) package
+func (c *Crawler) analyzePythonImports(files []FileInfo) {
+	packageDirs := make(map[string]bool)
+	for _, file := range files {
+		if file.Name == "__init__.py" {
+			packageDirs[filepath.Dir(file.Path)] = true
+		}
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file.Path)
+		if err != nil {
+			continue
+		}
+		if pkg := pythonPackage(file.Path, packageDirs); pkg != "" {
+			c.Analysis.Dependencies.FileNamespaces[file.Path] = pkg
+		}
+		c.recordImports(file.Path, parsePythonImports(content))
+	}
+}
+
+// analyzeTypeScriptImports extracts the imports and the first exported name of a TypeScript file
+func (c *Crawler) analyzeTypeScriptImports(file FileInfo, namespacePattern *regexp.Regexp) {
+	content, err := os.ReadFile(file.Path)
+	if err != nil {
+		return
+	}
+
+	if nsMatch := namespacePattern.FindSubmatch(content); len(nsMatch) > 1 {
+		c.Analysis.Dependencies.FileNamespaces[file.Path] = string(nsMatch[1])
+	}
+
+	c.recordImports(file.Path, parseTypeScriptImports(content))
+}
+
+// recordImports stores a file's imports in both the detailed and the path-only graph
+func (c *Crawler) recordImports(filePath string, refs []ImportRef) {
+	if len(refs) == 0 {
+		return
+	}
+
+	imports := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		imports = append(imports, ref.Path)
+	}
+
+	c.Analysis.Dependencies.FileImports[filePath] = refs
+	c.Analysis.Dependencies.ImportGraph[filePath] = imports
+}
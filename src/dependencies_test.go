@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// manifestDeps lists a manifest's dependencies as "group name version"
+func manifestDeps(m *Manifest) []string {
+	var deps []string
+	for _, dep := range m.Dependencies {
+		deps = append(deps, dep.Group+" "+dep.Name+" "+dep.Version)
+	}
+	return deps
+}
+
+// findManifest returns the manifest at path relative to the fixture root
+func findManifest(c *Crawler, root, path string) *Manifest {
+	for _, m := range c.Analysis.Dependencies.Manifests {
+		if relativePath(root, m.Path) == path {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestManifestsAndSummary(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"package.json":        `{"name": "web", "version": "1.0.0", "dependencies": {"react": "^18.2.0"}, "devDependencies": {"jest": "^29.0.0"}}`,
+		"server/package.json": `{"name": "server", "dependencies": {"react": "^17.0.0", "express": "^4.18.0"}}`,
+	})
+
+	m := findManifest(c, root, "package.json")
+	if m == nil {
+		t.Fatal("package.json was not recorded as a manifest")
+	}
+	if m.Manager != "npm" || m.Name != "web" || m.Version != "1.0.0" {
+		t.Errorf("manifest = %s %s@%s, want npm web@1.0.0", m.Manager, m.Name, m.Version)
+	}
+	if got, want := manifestDeps(m), []string{"dependencies react ^18.2.0", "devDependencies jest ^29.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dependencies = %v, want %v", got, want)
+	}
+
+	pm := c.Analysis.Dependencies.PackageManagers["npm"]
+	if pm == nil {
+		t.Fatal("no npm summary")
+	}
+	if len(pm.ConfigFiles) != 2 {
+		t.Errorf("config files = %v, want both manifests", pm.ConfigFiles)
+	}
+	// Manifests are visited in path order, so package.json wins over server/package.json
+	if pm.Dependencies["react"] != "^18.2.0" || pm.Dependencies["express"] != "^4.18.0" {
+		t.Errorf("summary dependencies = %v", pm.Dependencies)
+	}
+	if pm.DevDeps["jest"] != "^29.0.0" {
+		t.Errorf("summary dev dependencies = %v", pm.DevDeps)
+	}
+	if want := []string{"express", "react"}; !reflect.DeepEqual(c.Analysis.Dependencies.ExternalDeps, want) {
+		t.Errorf("external deps = %v, want %v", c.Analysis.Dependencies.ExternalDeps, want)
+	}
+}
+
+func TestIsDevGroup(t *testing.T) {
+	for group, want := range map[string]bool{
+		"dependencies":       false,
+		"devDependencies":    true,
+		"require-dev":        true,
+		"testImplementation": true,
+		"implementation":     false,
+	} {
+		if got := isDevGroup(group); got != want {
+			t.Errorf("isDevGroup(%q) = %v, want %v", group, got, want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DependencyTree is the transitive dependency graph of one manifest, built from its lockfile
+type DependencyTree struct {
+	Manifest         string          `json:"manifest"`
+	Lockfile         string          `json:"lockfile"`
+	Direct           int             `json:"direct"`
+	Transitive       int             `json:"transitive"` // reachable only through other packages
+	Total            int             `json:"total"`
+	MaxDepth         int             `json:"max_depth"`
+	Packages         []*TreeNode     `json:"packages"`
+	MostDependedUpon []*PackageUsage `json:"most_depended_upon"`
+
+	roots []string
+	edges map[string][]string // name@version -> required name@version
+}
+
+// TreeNode places a package in a dependency tree at its shallowest depth
+type TreeNode struct {
+	Package string   `json:"package"` // name@version
+	Depth   int      `json:"depth"`   // 1 for direct dependencies
+	Path    []string `json:"path"`    // from a direct dependency down to this package
+	Dev     bool     `json:"dev,omitempty"`
+}
+
+// PackageUsage counts how many packages in a tree depend on a package
+type PackageUsage struct {
+	Package    string `json:"package"`
+	Dependents int    `json:"dependents"`
+}
+
+// mostDependedUponLimit caps the per-tree list of most-depended-upon packages
+const mostDependedUponLimit = 10
+
+// buildDependencyTree walks the lockfile graph breadth-first from the
+// manifest's direct dependencies
+func buildDependencyTree(m *Manifest, lock *Lockfile, importer string) *DependencyTree {
+	tree := &DependencyTree{
+		Manifest:         m.Path,
+		Lockfile:         lock.Path,
+		Packages:         []*TreeNode{},
+		MostDependedUpon: []*PackageUsage{},
+		edges:            make(map[string][]string),
+	}
+
+	// Duplicate installs of the same name@version share one node
+	for _, pkg := range lock.Packages {
+		id := pkg.ID()
+		for _, dep := range pkg.Requires {
+			if !containsString(tree.edges[id], dep) {
+				tree.edges[id] = append(tree.edges[id], dep)
+			}
+		}
+	}
+
+	nodes := make(map[string]*TreeNode)
+	var queue []*TreeNode
+
+	for _, dep := range m.Dependencies {
+		pkg := lock.lookup(importer, dep)
+		if pkg == nil || pkg.Version == "" {
+			continue
+		}
+		if _, seen := nodes[pkg.ID()]; seen {
+			continue
+		}
+		tree.roots = append(tree.roots, pkg.ID())
+		node := &TreeNode{Package: pkg.ID(), Depth: 1, Path: []string{pkg.ID()}, Dev: isDevGroup(dep.Group)}
+		nodes[pkg.ID()] = node
+		queue = append(queue, node)
+	}
+	tree.Direct = len(queue)
+
+	dependents := make(map[string]map[string]bool)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		tree.Packages = append(tree.Packages, node)
+		if node.Depth > tree.MaxDepth {
+			tree.MaxDepth = node.Depth
+		}
+
+		for _, id := range tree.edges[node.Package] {
+			if dependents[id] == nil {
+				dependents[id] = make(map[string]bool)
+			}
+			dependents[id][node.Package] = true
+
+			if _, seen := nodes[id]; seen {
+				continue
+			}
+			path := append(append([]string{}, node.Path...), id)
+			child := &TreeNode{Package: id, Depth: node.Depth + 1, Path: path, Dev: node.Dev}
+			nodes[id] = child
+			queue = append(queue, child)
+		}
+	}
+
+	tree.Total = len(tree.Packages)
+	tree.Transitive = tree.Total - tree.Direct
+
+	for id, from := range dependents {
+		tree.MostDependedUpon = append(tree.MostDependedUpon, &PackageUsage{Package: id, Dependents: len(from)})
+	}
+	sort.Slice(tree.MostDependedUpon, func(i, j int) bool {
+		a, b := tree.MostDependedUpon[i], tree.MostDependedUpon[j]
+		if a.Dependents != b.Dependents {
+			return a.Dependents > b.Dependents
+		}
+		return a.Package < b.Package
+	})
+	if len(tree.MostDependedUpon) > mostDependedUponLimit {
+		tree.MostDependedUpon = tree.MostDependedUpon[:mostDependedUponLimit]
+	}
+
+	return tree
+}
+
+// whyPathLimit caps how many dependency chains Why reports per tree
+const whyPathLimit = 25
+
+// Why returns every chain of dependencies, from a direct dependency of some
+// manifest, that pulls in the named package (name or name@version)
+func (c *Crawler) Why(query string) map[string][][]string {
+	result := make(map[string][][]string)
+	for _, tree := range c.Analysis.Dependencies.DependencyTrees {
+		if paths := tree.why(query); len(paths) > 0 {
+			result[tree.Manifest] = paths
+		}
+	}
+	return result
+}
+
+// why enumerates acyclic paths from the tree's roots to packages matching query
+func (t *DependencyTree) why(query string) [][]string {
+	matches := func(id string) bool {
+		if id == query {
+			return true
+		}
+		name := id
+		if idx := strings.LastIndex(id, "@"); idx > 0 {
+			name = id[:idx]
+		}
+		return strings.EqualFold(name, query)
+	}
+
+	// Only walk packages from which a match can be reached, so that a rare or
+	// missing query does not enumerate every path of the tree
+	importers := make(map[string][]string)
+	reaches := make(map[string]bool)
+	var queue []string
+	for id, deps := range t.edges {
+		for _, dep := range deps {
+			importers[dep] = append(importers[dep], id)
+		}
+	}
+	for _, id := range append(sortedKeys(importers), t.roots...) {
+		if matches(id) && !reaches[id] {
+			reaches[id] = true
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, importer := range importers[id] {
+			if !reaches[importer] {
+				reaches[importer] = true
+				queue = append(queue, importer)
+			}
+		}
+	}
+
+	var paths [][]string
+	onPath := make(map[string]bool)
+	var walk func(id string, path []string)
+	walk = func(id string, path []string) {
+		if len(paths) >= whyPathLimit || onPath[id] || !reaches[id] {
+			return
+		}
+		path = append(path, id)
+		if matches(id) {
+			paths = append(paths, append([]string{}, path...))
+			return
+		}
+		onPath[id] = true
+		for _, dep := range t.edges[id] {
+			walk(dep, path)
+		}
+		onPath[id] = false
+	}
+
+	for _, root := range t.roots {
+		walk(root, nil)
+	}
+	return paths
+}
+
+// printWhy writes the result of a why query in a human-readable form
+func printWhy(query string, result map[string][][]string) {
+	if len(result) == 0 {
+		fmt.Printf("%s is not in any locked dependency tree\n", query)
+		return
+	}
+	for _, manifest := range sortedKeys(result) {
+		fmt.Printf("\n📦 %s\n", manifest)
+		for _, path := range result[manifest] {
+			fmt.Printf("   %s\n", strings.Join(path, " → "))
+		}
+		if len(result[manifest]) >= whyPathLimit {
+			fmt.Printf("   … (showing the first %d paths)\n", whyPathLimit)
+		}
+	}
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
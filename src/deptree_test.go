@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// layeredTree builds a tree of fully connected layers, which has width^depth
+// paths from the roots to the last layer
+func layeredTree(depth, width int) *DependencyTree {
+	t := &DependencyTree{edges: make(map[string][]string)}
+	for i := 0; i < width; i++ {
+		t.roots = append(t.roots, fmt.Sprintf("p0-%d@1.0.0", i))
+	}
+	for layer := 0; layer+1 < depth; layer++ {
+		for i := 0; i < width; i++ {
+			id := fmt.Sprintf("p%d-%d@1.0.0", layer, i)
+			for j := 0; j < width; j++ {
+				t.edges[id] = append(t.edges[id], fmt.Sprintf("p%d-%d@1.0.0", layer+1, j))
+			}
+		}
+	}
+	return t
+}
+
+func TestWhy(t *testing.T) {
+	tree := layeredTree(8, 8)
+
+	tests := []struct {
+		query string
+		paths int
+	}{
+		{"missing", 0},
+		{"p0-3", 1},
+		{"p1-2@1.0.0", 8},
+		{"p7-0", whyPathLimit},
+	}
+	for _, tt := range tests {
+		paths := tree.why(tt.query)
+		if len(paths) != tt.paths {
+			t.Errorf("why(%q) returned %d paths, want %d", tt.query, len(paths), tt.paths)
+		}
+		for _, path := range paths {
+			name := strings.SplitN(tt.query, "@", 2)[0]
+			if last := path[len(path)-1]; !strings.HasPrefix(last, name+"@") {
+				t.Errorf("why(%q) path %v does not end in a match", tt.query, path)
+			}
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// msbuildProject is the subset of an MSBuild project (.csproj, .fsproj,
+// Directory.Build.props, Directory.Packages.props) the crawler understands
+type msbuildProject struct {
+	PropertyGroups []msbuildProperties `xml:"PropertyGroup"`
+	ItemGroups     []msbuildItemGroup  `xml:"ItemGroup"`
+	Imports        []struct {
+		Project string `xml:"Project,attr"`
+	} `xml:"Import"`
+
+	path string
+}
+
+// msbuildProperties is a <PropertyGroup>
+type msbuildProperties struct {
+	Condition string `xml:"Condition,attr"`
+	Entries   []struct {
+		XMLName   xml.Name
+		Condition string `xml:"Condition,attr"`
+		Value     string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+// msbuildItemGroup is an <ItemGroup> with the item types that describe dependencies
+type msbuildItemGroup struct {
+	Condition      string        `xml:"Condition,attr"`
+	Packages       []msbuildItem `xml:"PackageReference"`
+	Projects       []msbuildItem `xml:"ProjectReference"`
+	Frameworks     []msbuildItem `xml:"FrameworkReference"`
+	Versions       []msbuildItem `xml:"PackageVersion"`
+	GlobalPackages []msbuildItem `xml:"GlobalPackageReference"`
+}
+
+// msbuildItem is one item; metadata may be given as attributes or child elements
+type msbuildItem struct {
+	Include                string `xml:"Include,attr"`
+	Update                 string `xml:"Update,attr"`
+	Condition              string `xml:"Condition,attr"`
+	Version                string `xml:"Version,attr"`
+	VersionElement         string `xml:"Version"`
+	VersionOverride        string `xml:"VersionOverride,attr"`
+	VersionOverrideElement string `xml:"VersionOverride"`
+	PrivateAssets          string `xml:"PrivateAssets,attr"`
+	PrivateAssetsElement   string `xml:"PrivateAssets"`
+}
+
+// nugetPackages is a packages.config file
+type nugetPackages struct {
+	Packages []struct {
+		ID                    string `xml:"id,attr"`
+		Version               string `xml:"version,attr"`
+		TargetFramework       string `xml:"targetFramework,attr"`
+		DevelopmentDependency string `xml:"developmentDependency,attr"`
+	} `xml:"package"`
+}
+
+var (
+	msbuildPropertyRef = regexp.MustCompile(`\$\(([\w.]+)\)`)
+	slnProject         = regexp.MustCompile(`^Project\("\{[^}]+\}"\)\s*=\s*"([^"]*)"\s*,\s*"([^"]*)"\s*,\s*"\{([^}]+)\}"`)
+	slnDependency      = regexp.MustCompile(`^\{([^}]+)\}\s*=\s*\{[^}]+\}$`)
+	slnxProject        = regexp.MustCompile(`<Project\s+[^>]*Path="([^"]+)"`)
+)
+
+// parseMSBuildProject parses a .csproj, .fsproj or .vbproj file; its
+// dependencies are recorded by resolveDotNetProjects once Directory.*.props
+// files and the other projects are known
+func (c *Crawler) parseMSBuildProject(content []byte, m *Manifest) {
+	if p := parseMSBuild(content, m.Path); p != nil {
+		m.msbuild = p
+	}
+}
+
+// parseMSBuild decodes an MSBuild XML file
+func parseMSBuild(content []byte, path string) *msbuildProject {
+	var p msbuildProject
+	if err := xml.Unmarshal(content, &p); err != nil {
+		return nil
+	}
+	p.path = path
+	return &p
+}
+
+// parsePackagesConfig parses a legacy NuGet packages.config
+func (c *Crawler) parsePackagesConfig(content []byte, m *Manifest) {
+	var config nugetPackages
+	if err := xml.Unmarshal(content, &config); err != nil {
+		return
+	}
+	frameworks := make(map[string]bool)
+	for _, pkg := range config.Packages {
+		if pkg.ID == "" {
+			continue
+		}
+		group := "dependencies"
+		if strings.EqualFold(pkg.DevelopmentDependency, "true") {
+			group = "development"
+		}
+		m.addDependency(group, pkg.ID, pkg.Version)
+		if pkg.TargetFramework != "" {
+			frameworks[pkg.TargetFramework] = true
+		}
+	}
+	m.Frameworks = sortedKeys(frameworks)
+}
+
+// resolveDotNetProjects records the dependencies of every MSBuild project,
+// applying Directory.Build.props and central package versions from
+// Directory.Packages.props, and records each solution's project graph
+func (c *Crawler) resolveDotNetProjects() {
+	var projects []*Manifest
+	byPath := make(map[string]*Manifest)
+	for _, m := range c.Analysis.Dependencies.Manifests {
+		if m.msbuild != nil {
+			projects = append(projects, m)
+			byPath[m.Path] = m
+		}
+	}
+	if len(projects) == 0 {
+		return
+	}
+
+	buildProps := make(map[string]*msbuildProject)   // dir -> Directory.Build.props
+	packageProps := make(map[string]*msbuildProject) // dir -> Directory.Packages.props
+	var solutions []string
+	for _, files := range c.Analysis.FilesByType {
+		for _, file := range files {
+			switch {
+			case file.Name == "Directory.Build.props" || file.Name == "Directory.Packages.props":
+				content, err := os.ReadFile(file.Path)
+				if err != nil {
+					continue
+				}
+				if p := parseMSBuild(content, file.Path); p != nil {
+					if file.Name == "Directory.Build.props" {
+						buildProps[filepath.Dir(file.Path)] = p
+					} else {
+						packageProps[filepath.Dir(file.Path)] = p
+					}
+				}
+			case file.Extension == ".sln" || file.Extension == ".slnx":
+				solutions = append(solutions, file.Path)
+			}
+		}
+	}
+
+	// Names first, so project references can be recorded under the name of
+	// the project they point to
+	props := make(map[*Manifest]map[string]string)
+	layers := make(map[*Manifest][]*msbuildProject)
+	for _, m := range projects {
+		dir := filepath.Dir(m.Path)
+		layers[m] = append(append(c.msbuildChain(buildProps, dir), c.msbuildChain(packageProps, dir)...), m.msbuild)
+		props[m] = evaluateMSBuildProperties(layers[m], m.Path)
+		m.Name = props[m]["AssemblyName"]
+		m.Version = props[m]["Version"]
+		m.Frameworks = msbuildFrameworks(props[m])
+	}
+
+	for _, m := range projects {
+		recordMSBuildItems(m, layers[m], props[m], byPath)
+	}
+
+	sort.Strings(solutions)
+	for _, sln := range solutions {
+		c.recordSolution(sln, byPath)
+	}
+}
+
+// msbuildChain returns the nearest file above dir and the files it imports
+// from further up, outermost first
+func (c *Crawler) msbuildChain(files map[string]*msbuildProject, dir string) []*msbuildProject {
+	var chain []*msbuildProject
+	for {
+		var found *msbuildProject
+		for ; ; dir = filepath.Dir(dir) {
+			if found = files[dir]; found != nil || dir == c.Config.TargetPath || filepath.Dir(dir) == dir {
+				break
+			}
+		}
+		if found == nil {
+			break
+		}
+		chain = append([]*msbuildProject{found}, chain...)
+		if !importsFileAbove(found) || dir == c.Config.TargetPath || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	return chain
+}
+
+// importsFileAbove reports whether a Directory.*.props file imports the next
+// file of the same name up the tree
+func importsFileAbove(p *msbuildProject) bool {
+	name := filepath.Base(p.path)
+	for _, imp := range p.Imports {
+		if strings.Contains(imp.Project, "GetPathOfFileAbove") || strings.HasSuffix(strings.ReplaceAll(imp.Project, "\\", "/"), "../"+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateMSBuildProperties evaluates the unconditional properties of the given
+// layers in order, with the MSBuildProjectName built-in
+func evaluateMSBuildProperties(layers []*msbuildProject, projectPath string) map[string]string {
+	name := strings.TrimSuffix(filepath.Base(projectPath), filepath.Ext(projectPath))
+	props := map[string]string{
+		"MSBuildProjectName": name,
+		"AssemblyName":       name,
+	}
+	for _, layer := range layers {
+		for _, group := range layer.PropertyGroups {
+			if group.Condition != "" {
+				continue
+			}
+			for _, entry := range group.Entries {
+				if entry.Condition == "" {
+					props[entry.XMLName.Local] = interpolateMSBuild(strings.TrimSpace(entry.Value), props)
+				}
+			}
+		}
+	}
+	if props["Version"] == "" && props["VersionPrefix"] != "" {
+		props["Version"] = props["VersionPrefix"]
+		if props["VersionSuffix"] != "" {
+			props["Version"] += "-" + props["VersionSuffix"]
+		}
+	}
+	return props
+}
+
+// interpolateMSBuild substitutes $(Property) references, leaving unknown ones as-is
+func interpolateMSBuild(s string, props map[string]string) string {
+	return msbuildPropertyRef.ReplaceAllStringFunc(s, func(ref string) string {
+		if v, ok := props[ref[2:len(ref)-1]]; ok {
+			return v
+		}
+		return ref
+	})
+}
+
+// msbuildFrameworks returns the target frameworks of a project; legacy
+// TargetFrameworkVersion values such as v4.7.2 become net472
+func msbuildFrameworks(props map[string]string) []string {
+	var frameworks []string
+	for _, tfm := range strings.Split(props["TargetFrameworks"]+";"+props["TargetFramework"], ";") {
+		if tfm = strings.TrimSpace(tfm); tfm != "" && !containsString(frameworks, tfm) {
+			frameworks = append(frameworks, tfm)
+		}
+	}
+	if v := props["TargetFrameworkVersion"]; len(frameworks) == 0 && v != "" {
+		frameworks = append(frameworks, "net"+strings.ReplaceAll(strings.TrimPrefix(v, "v"), ".", ""))
+	}
+	return frameworks
+}
+
+// recordMSBuildItems records the package, project and framework references of
+// a project. Versions come from VersionOverride, the reference itself, an
+// Update item or, with ManagePackageVersionsCentrally, the central
+// PackageVersion, in that order.
+func recordMSBuildItems(m *Manifest, layers []*msbuildProject, props map[string]string, byPath map[string]*Manifest) {
+	centrally := strings.EqualFold(props["ManagePackageVersionsCentrally"], "true")
+	central := make(map[string]string) // lower-cased package id -> version
+	updates := make(map[string]string)
+	for _, layer := range layers {
+		for _, group := range layer.ItemGroups {
+			for _, item := range group.Versions {
+				if centrally {
+					central[strings.ToLower(item.Include)] = interpolateMSBuild(item.version(), props)
+				}
+			}
+			for _, item := range group.Packages {
+				if item.Include == "" && item.Update != "" && item.version() != "" {
+					updates[strings.ToLower(item.Update)] = interpolateMSBuild(item.version(), props)
+				}
+			}
+		}
+	}
+
+	dir := filepath.Dir(m.Path)
+	for _, layer := range layers {
+		for _, group := range layer.ItemGroups {
+			for _, item := range group.GlobalPackages {
+				if !centrally {
+					break
+				}
+				dep := m.addDependency("development", item.Include, interpolateMSBuild(item.version(), props))
+				dep.Target = item.condition(group)
+			}
+
+			for _, item := range group.Packages {
+				for _, name := range msbuildIncludes(item.Include) {
+					version := interpolateMSBuild(item.VersionOverride+item.VersionOverrideElement, props)
+					for _, candidate := range []string{interpolateMSBuild(item.version(), props), updates[strings.ToLower(name)], central[strings.ToLower(name)]} {
+						if version == "" {
+							version = candidate
+						}
+					}
+					depGroup := "dependencies"
+					if strings.EqualFold(item.PrivateAssets+item.PrivateAssetsElement, "all") {
+						depGroup = "development"
+					}
+					dep := m.addDependency(depGroup, name, version)
+					dep.Target = item.condition(group)
+				}
+			}
+
+			for _, item := range group.Projects {
+				for _, ref := range msbuildIncludes(item.Include) {
+					target := filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(interpolateMSBuild(ref, props), "\\", "/")))
+					name := strings.TrimSuffix(filepath.Base(target), filepath.Ext(target))
+					if project := byPath[target]; project != nil && project.Name != "" {
+						name = project.Name
+					}
+					dep := m.addDependency("projects", name, "")
+					dep.Source = "path"
+					dep.URL = target
+					dep.Target = item.condition(group)
+				}
+			}
+
+			for _, item := range group.Frameworks {
+				for _, name := range msbuildIncludes(item.Include) {
+					dep := m.addDependency("frameworks", name, "")
+					dep.Source = "sdk"
+					dep.Target = item.condition(group)
+				}
+			}
+		}
+	}
+}
+
+// version returns the Version metadata of an item
+func (item msbuildItem) version() string {
+	if item.Version != "" {
+		return strings.TrimSpace(item.Version)
+	}
+	return strings.TrimSpace(item.VersionElement)
+}
+
+// condition returns the condition that applies to an item, if any
+func (item msbuildItem) condition(group msbuildItemGroup) string {
+	if item.Condition != "" {
+		return strings.TrimSpace(item.Condition)
+	}
+	return strings.TrimSpace(group.Condition)
+}
+
+// msbuildIncludes splits an Include attribute into its items
+func msbuildIncludes(include string) []string {
+	var items []string
+	for _, item := range strings.Split(include, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// recordSolution records the projects of a .sln or .slnx solution as a
+// workspace, with project references and solution build dependencies as edges
+func (c *Crawler) recordSolution(sln string, byPath map[string]*Manifest) {
+	content, err := os.ReadFile(sln)
+	if err != nil {
+		return
+	}
+	dir := filepath.Dir(sln)
+	resolve := func(ref string) string {
+		return filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(ref, "\\", "/")))
+	}
+
+	var paths []string
+	byGUID := make(map[string]string)
+	buildDeps := make(map[string][]string) // project path -> GUIDs it must build after
+	if filepath.Ext(sln) == ".slnx" {
+		for _, match := range slnxProject.FindAllStringSubmatch(string(content), -1) {
+			paths = append(paths, resolve(match[1]))
+		}
+	} else {
+		var current string
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if match := slnProject.FindStringSubmatch(line); match != nil {
+				current = resolve(match[2])
+				paths = append(paths, current)
+				byGUID[strings.ToUpper(match[3])] = current
+				continue
+			}
+			if line == "EndProject" {
+				current = ""
+				continue
+			}
+			if match := slnDependency.FindStringSubmatch(line); match != nil && current != "" {
+				buildDeps[current] = append(buildDeps[current], strings.ToUpper(match[1]))
+			}
+		}
+	}
+
+	// Solution folders are listed as projects too; only real projects count
+	members := make(map[string]*Manifest)
+	for _, path := range paths {
+		if m := byPath[path]; m != nil {
+			members[path] = m
+		}
+	}
+	if len(members) == 0 {
+		return
+	}
+
+	name := strings.TrimSuffix(filepath.Base(sln), filepath.Ext(sln))
+	ws := &Workspace{Kind: "dotnet", Root: sln, Name: name, Members: []string{}, Edges: []WorkspaceEdge{}}
+	for _, path := range sortedKeys(members) {
+		m := members[path]
+		ws.Members = append(ws.Members, path)
+		for _, dep := range m.Dependencies {
+			if dep.Group == "projects" && members[dep.URL] != nil {
+				ws.Edges = append(ws.Edges, WorkspaceEdge{From: path, To: dep.URL, Kind: "project"})
+			}
+		}
+		for _, guid := range buildDeps[path] {
+			if target := byGUID[guid]; members[target] != nil {
+				ws.Edges = append(ws.Edges, WorkspaceEdge{From: path, To: target, Kind: "build order"})
+			}
+		}
+	}
+	c.Analysis.Dependencies.Workspaces = append(c.Analysis.Dependencies.Workspaces, ws)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDotNetProjects(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"Directory.Build.props": `<Project>
+  <PropertyGroup>
+    <TargetFramework>net8.0</TargetFramework>
+    <SerilogVersion>3.1.1</SerilogVersion>
+  </PropertyGroup>
+</Project>
+`,
+		"Directory.Packages.props": `<Project>
+  <PropertyGroup>
+    <ManagePackageVersionsCentrally>true</ManagePackageVersionsCentrally>
+  </PropertyGroup>
+  <ItemGroup>
+    <PackageVersion Include="Newtonsoft.Json" Version="13.0.3" />
+    <PackageVersion Include="xunit" Version="2.6.0" />
+  </ItemGroup>
+</Project>
+`,
+		"App.sln": `Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Api", "src\Api\Api.csproj", "{11111111-1111-1111-1111-111111111111}"
+EndProject
+Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "Core", "src\Core\Core.csproj", "{22222222-2222-2222-2222-222222222222}"
+EndProject
+`,
+		"src/Api/Api.csproj": `<Project Sdk="Microsoft.NET.Sdk.Web">
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" />
+    <PackageReference Include="Serilog" Version="$(SerilogVersion)" />
+    <PackageReference Include="Polly">
+      <Version>8.2.0</Version>
+    </PackageReference>
+    <ProjectReference Include="..\Core\Core.csproj" />
+  </ItemGroup>
+  <ItemGroup Condition="'$(Configuration)' == 'Debug'">
+    <PackageReference Include="xunit" />
+  </ItemGroup>
+</Project>
+`,
+		"src/Core/Core.csproj": `<Project Sdk="Microsoft.NET.Sdk">
+  <PropertyGroup>
+    <TargetFrameworks>net8.0;netstandard2.0</TargetFrameworks>
+  </PropertyGroup>
+</Project>
+`,
+		"legacy/packages.config": `<?xml version="1.0"?>
+<packages>
+  <package id="log4net" version="2.0.15" targetFramework="net48" />
+  <package id="NUnit" version="3.13.3" developmentDependency="true" />
+</packages>
+`,
+	})
+
+	tests := []struct {
+		path       string
+		frameworks []string
+		deps       []string
+	}{
+		{"src/Api/Api.csproj", []string{"net8.0"}, []string{
+			"dependencies Newtonsoft.Json 13.0.3",
+			"dependencies Serilog 3.1.1",
+			"dependencies Polly 8.2.0",
+			"projects Core ",
+			"dependencies xunit 2.6.0",
+		}},
+		{"src/Core/Core.csproj", []string{"net8.0", "netstandard2.0"}, nil},
+		{"legacy/packages.config", []string{"net48"}, []string{
+			"dependencies log4net 2.0.15",
+			"development NUnit 3.13.3",
+		}},
+	}
+	for _, tt := range tests {
+		m := findManifest(c, root, tt.path)
+		if m == nil {
+			t.Errorf("%s: no manifest", tt.path)
+			continue
+		}
+		if !reflect.DeepEqual(m.Frameworks, tt.frameworks) {
+			t.Errorf("%s: frameworks %v, want %v", tt.path, m.Frameworks, tt.frameworks)
+		}
+		if got := manifestDeps(m); !reflect.DeepEqual(got, tt.deps) {
+			t.Errorf("%s: dependencies = %q, want %q", tt.path, got, tt.deps)
+		}
+	}
+
+	api := findManifest(c, root, "src/Api/Api.csproj")
+	if xunit := api.Dependencies[4]; xunit.Target != "'$(Configuration)' == 'Debug'" {
+		t.Errorf("xunit condition = %q", xunit.Target)
+	}
+	want := []string{"src/Api/Api.csproj -> src/Core/Core.csproj (project)"}
+	if got := workspaceEdges(c, root, "App.sln"); !reflect.DeepEqual(got, want) {
+		t.Errorf("solution graph = %v, want %v", got, want)
+	}
+}
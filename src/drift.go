@@ -0,0 +1,242 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// VersionDrift is a package declared by several manifests, or declared with a
+// floating, pre-release or git-sourced spec
+type VersionDrift struct {
+	Ecosystem string      `json:"ecosystem"`
+	Package   string      `json:"package"`
+	Specs     []DriftSpec `json:"specs"`            // one per distinct normalized constraint
+	Conflict  bool        `json:"conflict"`         // declared with different constraints by different manifests
+	Issues    []string    `json:"issues,omitempty"` // "floating", "prerelease" or "git"
+}
+
+// DriftSpec is one normalized constraint of a package and where it is declared
+type DriftSpec struct {
+	Constraint string   `json:"constraint"` // "*" for floating specs, "git:<url>" for VCS sources
+	Manifests  []string `json:"manifests"`
+	Issues     []string `json:"issues,omitempty"`
+}
+
+// DriftMatrix is the package × manifest view of the packages declared by
+// more than one manifest
+type DriftMatrix struct {
+	Manifests []string
+	Rows      []DriftRow
+}
+
+// DriftRow is one package of the matrix; Cells holds its constraint per
+// manifest column, "" where the manifest does not declare it
+type DriftRow struct {
+	Ecosystem string
+	Package   string
+	Conflict  bool
+	Cells     []string
+}
+
+var (
+	leadingV      = regexp.MustCompile(`(^|[\s<>=^~,|])v(\d)`)
+	leadingEquals = regexp.MustCompile(`(^|\s)=(\d)`)
+	versionInSpec = regexp.MustCompile(`\d[0-9A-Za-z.+_-]*`)
+	floatingSpecs = map[string]bool{"latest": true, "any": true, ">=0": true, "LATEST": true, "RELEASE": true}
+)
+
+// normalizeConstraint rewrites a declared constraint into one canonical form
+// per ecosystem, so that equivalent spellings compare equal: spacing, "v" and
+// "=" prefixes, clause order, and Cargo's implicit caret. Specs that accept
+// any version become "*".
+func normalizeConstraint(ecosystem, spec string) string {
+	spec = constraintOperator.ReplaceAllString(strings.TrimSpace(spec), "$1")
+	switch ecosystem {
+	case "Go":
+	case "PyPI", "RubyGems":
+		clauses := strings.Split(spec, ",")
+		for i := range clauses {
+			clauses[i] = strings.ReplaceAll(strings.TrimSpace(clauses[i]), " ", "")
+		}
+		sort.Strings(clauses)
+		spec = strings.Join(clauses, ",")
+		if ecosystem == "PyPI" {
+			spec = strings.ToLower(spec)
+		}
+	case "Maven", "NuGet":
+		spec = strings.ReplaceAll(spec, " ", "")
+	default:
+		spec = strings.Join(strings.Fields(leadingV.ReplaceAllString(spec, "$1$2")), " ")
+		spec = leadingEquals.ReplaceAllString(spec, "$1$2")
+		if ecosystem == "crates.io" && spec != "" && spec[0] >= '0' && spec[0] <= '9' {
+			spec = "^" + spec // a bare Cargo version is a caret requirement
+		}
+	}
+	if strings.Trim(spec, "*.xX") == "" || floatingSpecs[spec] {
+		return "*"
+	}
+	return spec
+}
+
+// isPrerelease reports whether a constraint names a pre-release version, by
+// the ecosystem's own rules: SemVer's "-" suffix (and Go pseudo-versions),
+// PEP 440 a/b/rc/dev, Maven qualifiers ordered before the release, and
+// RubyGems letter segments
+func isPrerelease(ecosystem, constraint string) bool {
+	for _, v := range versionInSpec.FindAllString(constraint, -1) {
+		switch ecosystem {
+		case "PyPI":
+			if p, ok := parsePEP440(v); ok && (p.pre != "" || p.dev != "") {
+				return true
+			}
+		case "Maven":
+			for _, item := range mavenItems(v) {
+				if rank, known := mavenQualifiers[item]; known && !isDigits(item) && rank < 6 {
+					return true
+				}
+			}
+		case "RubyGems":
+			for _, segment := range versionSegments(v) {
+				if !isDigits(segment) {
+					return true
+				}
+			}
+		default:
+			if _, pre := splitSemver(v); pre != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// specIssues lists what makes a declared dependency drift-prone
+func specIssues(ecosystem string, dep *Dependency, constraint string) []string {
+	var issues []string
+	switch {
+	case dep.Source == "git" || dep.Source == "vcs" || dep.Source == "url":
+		return []string{"git"}
+	case constraint == "*",
+		ecosystem == "Packagist" && strings.HasPrefix(constraint, "dev-"),
+		ecosystem == "NuGet" && strings.Contains(constraint, "*"):
+		issues = append(issues, "floating")
+	}
+	if isPrerelease(ecosystem, constraint) {
+		issues = append(issues, "prerelease")
+	}
+	return issues
+}
+
+// analyzeVersionDrift compares the constraints every manifest declares for
+// the same package and flags floating, pre-release and git-sourced specs
+func (c *Crawler) analyzeVersionDrift() {
+	type declaration struct {
+		specs map[string]*DriftSpec
+	}
+	packages := make(map[string]*declaration) // ecosystem|name
+	for _, m := range c.Analysis.Dependencies.Manifests {
+		ecosystem := osvEcosystems[m.Manager]
+		if ecosystem == "" {
+			ecosystem = m.Manager
+		}
+		for _, dep := range m.Dependencies {
+			switch dep.Source {
+			case "workspace", "path", "sdk":
+				continue
+			}
+			if dep.Group == "peerDependencies" {
+				continue // a compatibility range, not the version the project uses
+			}
+			constraint := normalizeConstraint(ecosystem, dep.Version)
+			if dep.Source == "git" || dep.Source == "vcs" || dep.Source == "url" {
+				constraint = "git:" + dep.URL
+				if dep.Version != "" && dep.Version != dep.URL {
+					constraint += " " + dep.Version
+				}
+			}
+			key := ecosystem + "|" + dep.Name
+			d := packages[key]
+			if d == nil {
+				d = &declaration{specs: make(map[string]*DriftSpec)}
+				packages[key] = d
+			}
+			spec := d.specs[constraint]
+			if spec == nil {
+				spec = &DriftSpec{Constraint: constraint, Manifests: []string{}}
+				d.specs[constraint] = spec
+			}
+			if !containsString(spec.Manifests, m.Path) {
+				spec.Manifests = append(spec.Manifests, m.Path)
+			}
+			for _, issue := range specIssues(ecosystem, dep, constraint) {
+				if !containsString(spec.Issues, issue) {
+					spec.Issues = append(spec.Issues, issue)
+				}
+			}
+		}
+	}
+
+	drift := []*VersionDrift{}
+	for _, key := range sortedKeys(packages) {
+		d := packages[key]
+		ecosystem, name, _ := strings.Cut(key, "|")
+		v := &VersionDrift{Ecosystem: ecosystem, Package: name}
+		manifests := make(map[string]bool)
+		for _, constraint := range sortedKeys(d.specs) {
+			spec := d.specs[constraint]
+			v.Specs = append(v.Specs, *spec)
+			for _, manifest := range spec.Manifests {
+				manifests[manifest] = true
+			}
+			for _, issue := range spec.Issues {
+				if !containsString(v.Issues, issue) {
+					v.Issues = append(v.Issues, issue)
+				}
+			}
+		}
+		// Differing constraints within a single manifest are not drift
+		v.Conflict = len(d.specs) > 1 && len(manifests) > 1
+		if len(manifests) > 1 || len(v.Issues) > 0 {
+			drift = append(drift, v)
+		}
+	}
+	sort.SliceStable(drift, func(i, j int) bool { return drift[i].Conflict && !drift[j].Conflict })
+	c.Analysis.Dependencies.VersionDrift = drift
+}
+
+// DriftMatrix lays out the packages declared by more than one manifest
+// against those manifests, conflicting packages first
+func (d *DependencyAnalysis) DriftMatrix() *DriftMatrix {
+	columns := make(map[string]bool)
+	var shared []*VersionDrift
+	for _, v := range d.VersionDrift {
+		var manifests []string
+		for _, spec := range v.Specs {
+			manifests = append(manifests, spec.Manifests...)
+		}
+		if len(manifests) < 2 {
+			continue
+		}
+		shared = append(shared, v)
+		for _, m := range manifests {
+			columns[m] = true
+		}
+	}
+
+	matrix := &DriftMatrix{Manifests: sortedKeys(columns)}
+	index := make(map[string]int)
+	for i, m := range matrix.Manifests {
+		index[m] = i
+	}
+	for _, v := range shared {
+		row := DriftRow{Ecosystem: v.Ecosystem, Package: v.Package, Conflict: v.Conflict, Cells: make([]string, len(matrix.Manifests))}
+		for _, spec := range v.Specs {
+			for _, m := range spec.Manifests {
+				row.Cells[index[m]] = spec.Constraint
+			}
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	return matrix
+}
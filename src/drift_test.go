@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeConstraint(t *testing.T) {
+	tests := []struct {
+		ecosystem, spec, want string
+	}{
+		{"npm", "^ 18.2.0", "^18.2.0"},
+		{"npm", "=v4.17.21", "4.17.21"},
+		{"npm", "x", "*"},
+		{"npm", "latest", "*"},
+		{"PyPI", "<3, >=2.0", "<3,>=2.0"},
+		{"PyPI", ">=2.0,<3", "<3,>=2.0"},
+		{"crates.io", "1.0", "^1.0"},
+		{"Maven", "[1.0, 2.0)", "[1.0,2.0)"},
+	}
+	for _, tt := range tests {
+		if got := normalizeConstraint(tt.ecosystem, tt.spec); got != tt.want {
+			t.Errorf("normalizeConstraint(%q, %q) = %q, want %q", tt.ecosystem, tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	tests := []struct {
+		ecosystem, constraint string
+		want                  bool
+	}{
+		{"npm", "^2.0.0-beta.1", true},
+		{"npm", "^2.0.0", false},
+		{"Go", "v0.0.0-20230101000000-abcdef123456", true},
+		{"PyPI", "==2.0rc1", true},
+		{"PyPI", ">=2.0.post1", false},
+		{"Maven", "1.0-SNAPSHOT", true},
+		{"Maven", "1.0.Final", false},
+		{"RubyGems", "~>7.1.0.rc2", true},
+	}
+	for _, tt := range tests {
+		if got := isPrerelease(tt.ecosystem, tt.constraint); got != tt.want {
+			t.Errorf("isPrerelease(%q, %q) = %v, want %v", tt.ecosystem, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestVersionDrift(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"web/package.json":   `{"name": "web", "dependencies": {"react": "^18.2.0", "lodash": "=v4.17.21", "next": "latest"}}`,
+		"admin/package.json": `{"name": "admin", "dependencies": {"react": "^17.0.0", "lodash": "4.17.21"}, "peerDependencies": {"react": ">=16"}}`,
+		"lib/package.json":   `{"name": "lib", "dependencies": {"zod": "^3.0.0"}, "devDependencies": {"zod": "^3.22.0"}}`,
+	})
+
+	got := make(map[string]string)
+	for _, v := range c.Analysis.Dependencies.VersionDrift {
+		desc := ""
+		for _, spec := range v.Specs {
+			desc += spec.Constraint + " "
+		}
+		if v.Conflict {
+			desc += "conflict"
+		}
+		desc += " " + strings.Join(v.Issues, ",")
+		got[v.Package] = desc
+	}
+	want := map[string]string{
+		"react":  "^17.0.0 ^18.2.0 conflict ",
+		"lodash": "4.17.21  ",
+		"next":   "*  floating",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("drift = %q, want %q", got, want)
+	}
+
+	matrix := c.Analysis.Dependencies.DriftMatrix()
+	var columns []string
+	for _, m := range matrix.Manifests {
+		columns = append(columns, relativePath(root, m))
+	}
+	if want := []string{"admin/package.json", "web/package.json"}; !reflect.DeepEqual(columns, want) {
+		t.Errorf("matrix columns = %q, want %q", columns, want)
+	}
+	if len(matrix.Rows) != 2 || matrix.Rows[0].Package != "react" || !reflect.DeepEqual(matrix.Rows[0].Cells, []string{"^17.0.0", "^18.2.0"}) {
+		t.Errorf("matrix rows = %+v", matrix.Rows)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// gemfileBlock is an open `do ... end` block of a Gemfile
+type gemfileBlock struct {
+	groups []string // from group blocks
+	source string   // "git" or "path" for git/github/path blocks
+	url    string
+}
+
+var (
+	gemfileGem       = regexp.MustCompile(`^gem\s*\(?\s*["']([^"']+)["']\s*(.*?)\)?$`)
+	gemfileBlockHead = regexp.MustCompile(`^(group|platforms?|source|git|github|path|install_if|env)\b\s*\(?(.*?)\)?\s*do(?:\s*\|[^|]*\|)?$`)
+	gemfileOpener    = regexp.MustCompile(`^(if|unless|case|begin|while|until|def|class|module)\b|\bdo(?:\s*\|[^|]*\|)?$`)
+	gemfileSymbol    = regexp.MustCompile(`:(\w+)|["'](\w+)["']`)
+	gemfileOption    = regexp.MustCompile(`^:?(\w+)(?::\s*|\s*=>\s*)(.+)$`)
+)
+
+// parseGemfile parses a Gemfile, tracking group, git and path blocks. Version
+// constraints are joined with ", " the way Gemfile.lock prints them.
+func (c *Crawler) parseGemfile(content []byte, m *Manifest) {
+	var stack []gemfileBlock
+
+	for _, raw := range strings.Split(string(content), "\n") {
+		line := strings.TrimSpace(stripRubyComment(raw))
+		if line == "" {
+			continue
+		}
+
+		if line == "end" || strings.HasPrefix(line, "end ") || strings.HasPrefix(line, "end.") {
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		if match := gemfileBlockHead.FindStringSubmatch(line); match != nil {
+			block := gemfileBlock{}
+			switch match[1] {
+			case "group":
+				block.groups = rubySymbols(match[2])
+			case "git", "github", "path":
+				block.source = map[string]string{"git": "git", "github": "git", "path": "path"}[match[1]]
+				block.url, _ = quotedString(splitTopLevel(match[2], ',')[0])
+				if match[1] == "github" {
+					block.url = "https://github.com/" + block.url
+				}
+			}
+			stack = append(stack, block)
+			continue
+		}
+
+		if match := gemfileGem.FindStringSubmatch(line); match != nil {
+			addGem(m, match[1], match[2], stack)
+			continue
+		}
+
+		if gemfileOpener.MatchString(line) {
+			stack = append(stack, gemfileBlock{})
+		}
+	}
+}
+
+// addGem records a gem declaration with the groups and source of its blocks
+func addGem(m *Manifest, name, args string, stack []gemfileBlock) {
+	var constraints, groups []string
+	var source, url, ref string
+	for _, block := range stack {
+		groups = append(groups, block.groups...)
+		if block.source != "" {
+			source, url = block.source, block.url
+		}
+	}
+
+	for _, arg := range splitTopLevel(strings.TrimPrefix(strings.TrimSpace(args), ","), ',') {
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			continue
+		}
+		if s, ok := quotedString(arg); ok {
+			constraints = append(constraints, s)
+			continue
+		}
+		option := gemfileOption.FindStringSubmatch(arg)
+		if option == nil {
+			continue
+		}
+		value, _ := quotedString(option[2])
+		switch option[1] {
+		case "group", "groups":
+			groups = append(groups, rubySymbols(option[2])...)
+		case "git":
+			source, url = "git", value
+		case "github":
+			source, url = "git", "https://github.com/"+value
+		case "path":
+			source, url = "path", value
+		case "branch", "tag", "ref":
+			ref = "#" + option[1] + "=" + value
+		}
+	}
+	if source == "git" {
+		url += ref
+	}
+
+	group := "default"
+	if len(groups) > 0 {
+		group = strings.Join(groups, ",")
+	}
+	dep := m.addDependency(group, name, strings.Join(constraints, ", "))
+	dep.Source = source
+	dep.URL = url
+}
+
+// rubySymbols returns the symbol or string names in a Ruby argument list
+func rubySymbols(args string) []string {
+	var names []string
+	for _, match := range gemfileSymbol.FindAllStringSubmatch(args, -1) {
+		names = append(names, match[1]+match[2])
+	}
+	return names
+}
+
+// stripRubyComment removes a trailing # comment outside string literals
+func stripRubyComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch ch := line[i]; {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
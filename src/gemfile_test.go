@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGemfile(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"Gemfile": `source 'https://rubygems.org'
+
+gem 'rails', '~> 7.1', '>= 7.1.2'
+gem 'pg' # db
+
+group :development, :test do
+  gem 'rspec-rails', require: false
+end
+
+gem 'mylib', git: 'https://github.com/org/mylib.git', branch: 'main'
+gem 'local', path: '../local'
+
+github 'org/tools' do
+  gem 'tool'
+end
+`,
+	})
+
+	m := findManifest(c, root, "Gemfile")
+	want := []string{
+		"default rails ~> 7.1, >= 7.1.2",
+		"default pg ",
+		"development,test rspec-rails ",
+		"default mylib ",
+		"default local ",
+		"default tool ",
+	}
+	if got := manifestDeps(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("dependencies = %q, want %q", got, want)
+	}
+
+	sources := []struct{ source, url string }{
+		{"git", "https://github.com/org/mylib.git#branch=main"},
+		{"path", "../local"},
+		{"git", "https://github.com/org/tools"},
+	}
+	for i, want := range sources {
+		dep := m.Dependencies[3+i]
+		if dep.Source != want.source || dep.URL != want.url {
+			t.Errorf("%s: source %q, url %q, want %q, %q", dep.Name, dep.Source, dep.URL, want.source, want.url)
+		}
+	}
+}
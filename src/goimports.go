@@ -0,0 +1,97 @@
+package main
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// goFileImports is what the crawler extracts from the header of a Go file
+type goFileImports struct {
+	Package    string
+	Imports    []ImportRef
+	Constraint string
+}
+
+// parseGoImports parses the package clause, imports and build constraints of a Go file
+func parseGoImports(filePath string, content []byte) (*goFileImports, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filePath, content, parser.ImportsOnly|parser.ParseComments)
+	if f == nil {
+		return nil, err
+	}
+
+	result := &goFileImports{}
+	if f.Name != nil {
+		result.Package = f.Name.Name
+	}
+
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		ref := ImportRef{
+			Path: path,
+			Line: fset.Position(spec.Pos()).Line,
+		}
+		if spec.Name != nil {
+			ref.Alias = spec.Name.Name
+			switch spec.Name.Name {
+			case "_":
+				ref.Kind = "blank"
+			case ".":
+				ref.Kind = "dot"
+			}
+		}
+		result.Imports = append(result.Imports, ref)
+	}
+
+	result.Constraint = goBuildConstraint(f.Comments, f.Package)
+
+	// A syntax error further down still leaves a usable import list
+	return result, nil
+}
+
+// goBuildConstraint returns the file's build constraint expression, preferring
+// //go:build over legacy // +build lines as the go command does
+func goBuildConstraint(groups []*ast.CommentGroup, pkgPos token.Pos) string {
+	var goBuild constraint.Expr
+	var plusBuild []constraint.Expr
+
+	for _, group := range groups {
+		if group.End() >= pkgPos {
+			break
+		}
+		for _, comment := range group.List {
+			text := comment.Text
+			switch {
+			case constraint.IsGoBuild(text):
+				if expr, err := constraint.Parse(text); err == nil && goBuild == nil {
+					goBuild = expr
+				}
+			case constraint.IsPlusBuild(text):
+				if expr, err := constraint.Parse(text); err == nil {
+					plusBuild = append(plusBuild, expr)
+				}
+			}
+		}
+	}
+
+	if goBuild != nil {
+		return goBuild.String()
+	}
+	if len(plusBuild) == 0 {
+		return ""
+	}
+
+	expr := plusBuild[0]
+	for _, next := range plusBuild[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: next}
+	}
+	return strings.TrimSpace(expr.String())
+}
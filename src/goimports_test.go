@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGoImports(t *testing.T) {
+	src := `//go:build linux && !cgo
+// +build linux,!cgo
+
+// Package demo does things
+package demo
+
+import (
+	"fmt"
+	str "strings"
+	_ "embed"
+	. "math"
+)
+
+import "os"
+
+func broken( {
+`
+	got, err := parseGoImports("demo.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Package != "demo" {
+		t.Errorf("package %q, want demo", got.Package)
+	}
+	if got.Constraint != "linux && !cgo" {
+		t.Errorf("constraint %q, want the //go:build expression", got.Constraint)
+	}
+	want := []ImportRef{
+		{Path: "fmt", Line: 8},
+		{Path: "strings", Alias: "str", Line: 9},
+		{Path: "embed", Alias: "_", Kind: "blank", Line: 10},
+		{Path: "math", Alias: ".", Kind: "dot", Line: 11},
+		{Path: "os", Line: 14},
+	}
+	if !reflect.DeepEqual(got.Imports, want) {
+		t.Errorf("imports:\ngot  %+v\nwant %+v", got.Imports, want)
+	}
+}
+
+func TestGoBuildConstraint(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"// +build linux darwin\n// +build amd64\n\npackage p\n", "(linux || darwin) && amd64"},
+		{"// Copyright notice\n\n//go:build ignore\n\npackage p\n", "ignore"},
+		{"package p\n\n//go:build linux\n", ""},
+	}
+	for _, tt := range tests {
+		got, err := parseGoImports("p.go", []byte(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Constraint != tt.want {
+			t.Errorf("constraint of %q = %q, want %q", tt.src, got.Constraint, tt.want)
+		}
+	}
+}
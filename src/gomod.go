@@ -0,0 +1,280 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GoModule is the module metadata of a go.mod file beyond its requirements
+type GoModule struct {
+	GoVersion string      `json:"go,omitempty"`
+	Toolchain string      `json:"toolchain,omitempty"`
+	Replace   []GoReplace `json:"replace,omitempty"`
+	Exclude   []string    `json:"exclude,omitempty"` // module@version
+	Retract   []string    `json:"retract,omitempty"` // versions or [low, high] ranges
+	Tools     []string    `json:"tools,omitempty"`
+}
+
+// GoReplace is a replace directive
+type GoReplace struct {
+	Old   string `json:"old"` // module path, with @version when only one version is replaced
+	New   string `json:"new"` // module path@version, or a directory for local replacements
+	Local bool   `json:"local,omitempty"`
+}
+
+// goModLine is one directive of a go.mod or go.work file, with block
+// directives such as `require ( ... )` flattened into one line per entry
+type goModLine struct {
+	verb    string
+	args    []string
+	comment string
+}
+
+// parseGoModLines tokenizes go.mod or go.work content the way
+// golang.org/x/mod/modfile does: quoted and raw strings, `=>`, trailing
+// comments and parenthesized blocks
+func parseGoModLines(content []byte) []goModLine {
+	var lines []goModLine
+	var block string
+	for _, raw := range strings.Split(string(content), "\n") {
+		tokens, comment := goModTokens(raw)
+		switch {
+		case len(tokens) == 0:
+			continue
+		case block != "" && len(tokens) == 1 && tokens[0] == ")":
+			block = ""
+		case block != "":
+			lines = append(lines, goModLine{verb: block, args: tokens, comment: comment})
+		case len(tokens) == 2 && tokens[1] == "(":
+			block = tokens[0]
+		default:
+			lines = append(lines, goModLine{verb: tokens[0], args: tokens[1:], comment: comment})
+		}
+	}
+	return lines
+}
+
+// goModTokens splits a line into tokens and its trailing // comment
+func goModTokens(line string) ([]string, string) {
+	var tokens []string
+	for i := 0; i < len(line); {
+		switch ch := line[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			i++
+		case strings.HasPrefix(line[i:], "//"):
+			return tokens, strings.TrimSpace(line[i+2:])
+		case strings.HasPrefix(line[i:], "=>"):
+			tokens = append(tokens, "=>")
+			i += 2
+		case ch == '"' || ch == '`':
+			end := i + 1
+			for end < len(line) && line[end] != ch {
+				if ch == '"' && line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return append(tokens, line[i+1:]), ""
+			}
+			s, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				s = line[i+1 : end]
+			}
+			tokens = append(tokens, s)
+			i = end + 1
+		default:
+			end := i
+			for end < len(line) && !strings.ContainsRune(" \t\r\"`", rune(line[end])) &&
+				!strings.HasPrefix(line[end:], "//") && !strings.HasPrefix(line[end:], "=>") {
+				end++
+			}
+			tokens = append(tokens, line[i:end])
+			i = end
+		}
+	}
+	return tokens, ""
+}
+
+// isGoIndirect reports whether a requirement's comment marks it // indirect
+func isGoIndirect(comment string) bool {
+	return comment == "indirect" || strings.HasPrefix(comment, "indirect;")
+}
+
+// isGoLocalPath reports whether a replacement target is a directory rather
+// than a module path
+func isGoLocalPath(path string) bool {
+	if path == "." || path == ".." || filepath.IsAbs(path) {
+		return true
+	}
+	for _, prefix := range []string{"./", "../", ".\\", "..\\", "/"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return len(path) >= 3 && path[1] == ':' && (path[2] == '\\' || path[2] == '/')
+}
+
+// parseGoReplace parses the arguments of a replace directive
+func parseGoReplace(args []string) (GoReplace, bool) {
+	arrow := -1
+	for i, arg := range args {
+		if arg == "=>" {
+			arrow = i
+		}
+	}
+	if arrow < 1 || arrow > 2 || len(args)-arrow < 2 || len(args)-arrow > 3 {
+		return GoReplace{}, false
+	}
+	r := GoReplace{Old: strings.Join(args[:arrow], "@"), New: strings.Join(args[arrow+1:], "@")}
+	r.Local = len(args)-arrow == 2 && isGoLocalPath(args[arrow+1])
+	return r, true
+}
+
+// findGoReplace returns the replace directive that applies to a module
+// version; a directive naming the version wins over one for all versions
+func findGoReplace(replaces []GoReplace, module, version string) (GoReplace, bool) {
+	var found GoReplace
+	ok := false
+	for _, r := range replaces {
+		if r.Old == module+"@"+version {
+			return r, true
+		}
+		if r.Old == module {
+			found, ok = r, true
+		}
+	}
+	return found, ok
+}
+
+// parseGoMod parses go.mod: the module path, go and toolchain versions, direct
+// and // indirect requirements, and replace, exclude, retract and tool directives
+func (c *Crawler) parseGoMod(content []byte, m *Manifest) {
+	mod := &GoModule{}
+	m.Go = mod
+	for _, line := range parseGoModLines(content) {
+		switch line.verb {
+		case "module":
+			if len(line.args) > 0 {
+				m.Name = line.args[0]
+			}
+		case "go":
+			if len(line.args) > 0 {
+				mod.GoVersion = line.args[0]
+			}
+		case "toolchain":
+			if len(line.args) > 0 {
+				mod.Toolchain = line.args[0]
+			}
+		case "require":
+			if len(line.args) >= 2 {
+				dep := m.addDependency("require", line.args[0], line.args[1])
+				dep.Indirect = isGoIndirect(line.comment)
+			}
+		case "replace":
+			if r, ok := parseGoReplace(line.args); ok {
+				mod.Replace = append(mod.Replace, r)
+			}
+		case "exclude":
+			if len(line.args) >= 2 {
+				mod.Exclude = append(mod.Exclude, line.args[0]+"@"+line.args[1])
+			}
+		case "retract":
+			if len(line.args) > 0 {
+				mod.Retract = append(mod.Retract, strings.Join(line.args, " "))
+			}
+		case "tool":
+			if len(line.args) > 0 {
+				mod.Tools = append(mod.Tools, line.args[0])
+			}
+		}
+	}
+	applyGoReplaces(m, mod.Replace, filepath.Dir(m.Path))
+}
+
+// applyGoReplaces points requirements replaced by a local directory at that
+// directory, resolved relative to dir
+func applyGoReplaces(m *Manifest, replaces []GoReplace, dir string) {
+	for _, dep := range m.Dependencies {
+		r, ok := findGoReplace(replaces, dep.Name, dep.Version)
+		switch {
+		case !ok:
+		case r.Local:
+			dep.Source = "path"
+			dep.URL = filepath.Join(dir, filepath.FromSlash(r.New))
+		default:
+			dep.Source, dep.URL = "", ""
+		}
+	}
+}
+
+// resolveGoWorkspaces links the modules used by each go.work file: requirements
+// on another module of the workspace, and the workspace's own replace
+// directives, are resolved within the workspace
+func (c *Crawler) resolveGoWorkspaces() {
+	byDir := make(map[string]*Manifest)
+	for _, m := range c.Analysis.Dependencies.Manifests {
+		if m.Go != nil {
+			byDir[filepath.Dir(m.Path)] = m
+		}
+	}
+
+	var workFiles []string
+	for _, files := range c.Analysis.FilesByType {
+		for _, file := range files {
+			if file.Name == "go.work" {
+				workFiles = append(workFiles, file.Path)
+			}
+		}
+	}
+	sort.Strings(workFiles)
+
+	for _, path := range workFiles {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		root := filepath.Dir(path)
+		ws := &Workspace{Kind: "go", Root: path, Members: []string{}, Edges: []WorkspaceEdge{}}
+		var replaces []GoReplace
+		members := make(map[string]*Manifest) // module path -> go.mod
+		for _, line := range parseGoModLines(content) {
+			switch line.verb {
+			case "use":
+				if len(line.args) == 0 {
+					continue
+				}
+				if m := byDir[filepath.Join(root, filepath.FromSlash(line.args[0]))]; m != nil && m.Name != "" {
+					members[m.Name] = m
+				}
+			case "replace":
+				if r, ok := parseGoReplace(line.args); ok {
+					replaces = append(replaces, r)
+				}
+			}
+		}
+
+		for _, modPath := range sortedKeys(members) {
+			m := members[modPath]
+			ws.Members = append(ws.Members, m.Path)
+			applyGoReplaces(m, replaces, root)
+			for _, dep := range m.Dependencies {
+				target := members[dep.Name]
+				if dep.Source == "path" {
+					target = byDir[dep.URL]
+				} else if target != nil {
+					dep.Source = "workspace"
+				}
+				if target != nil && target != m && members[target.Name] == target {
+					ws.Edges = append(ws.Edges, WorkspaceEdge{From: m.Path, To: target.Path, Kind: dep.Group})
+				}
+			}
+		}
+		if len(ws.Members) > 0 {
+			c.Analysis.Dependencies.Workspaces = append(c.Analysis.Dependencies.Workspaces, ws)
+		}
+	}
+}
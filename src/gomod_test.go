@@ -0,0 +1,101 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGoModTokens(t *testing.T) {
+	tests := []struct {
+		line    string
+		tokens  []string
+		comment string
+	}{
+		{"require golang.org/x/mod v0.14.0 // indirect", []string{"require", "golang.org/x/mod", "v0.14.0"}, "indirect"},
+		{`module "example.com/quoted"`, []string{"module", "example.com/quoted"}, ""},
+		{"replace a v1.0.0=>../a", []string{"replace", "a", "v1.0.0", "=>", "../a"}, ""},
+		{"\t// only a comment", nil, "only a comment"},
+	}
+	for _, tt := range tests {
+		tokens, comment := goModTokens(tt.line)
+		if !reflect.DeepEqual(tokens, tt.tokens) || comment != tt.comment {
+			t.Errorf("goModTokens(%q) = %q, %q, want %q, %q", tt.line, tokens, comment, tt.tokens, tt.comment)
+		}
+	}
+}
+
+func TestParseGoReplace(t *testing.T) {
+	tests := []struct {
+		args []string
+		want GoReplace
+		ok   bool
+	}{
+		{[]string{"example.com/a", "=>", "../a"}, GoReplace{Old: "example.com/a", New: "../a", Local: true}, true},
+		{[]string{"example.com/a", "v1.2.0", "=>", "example.com/fork", "v1.2.1"}, GoReplace{Old: "example.com/a@v1.2.0", New: "example.com/fork@v1.2.1"}, true},
+		{[]string{"example.com/a", "=>"}, GoReplace{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseGoReplace(tt.args)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseGoReplace(%q) = %+v, %v, want %+v, %v", tt.args, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+const appGoMod = `module example.com/app
+
+go 1.22
+toolchain go1.22.3
+
+require (
+	example.com/lib v0.0.0
+	golang.org/x/text v0.14.0 // indirect
+)
+
+exclude golang.org/x/text v0.13.0
+retract [v0.1.0, v0.1.5]
+`
+
+func TestGoModules(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"go.work":        "go 1.22\n\nuse (\n\t./app\n\t./lib\n)\n",
+		"app/go.mod":     appGoMod,
+		"app/main.go":    "package main\n",
+		"lib/go.mod":     "module example.com/lib\n\ngo 1.22\n",
+		"lib/lib.go":     "package lib\n",
+		"tools/go.mod":   "module example.com/tools\n\nrequire example.com/lib v0.1.0\n\nreplace example.com/lib => ../lib\n",
+		"tools/tools.go": "package tools\n",
+	})
+
+	app := findManifest(c, root, "app/go.mod")
+	if app == nil || app.Go == nil {
+		t.Fatalf("app/go.mod not parsed: %+v", app)
+	}
+	wantMod := &GoModule{
+		GoVersion: "1.22",
+		Toolchain: "go1.22.3",
+		Exclude:   []string{"golang.org/x/text@v0.13.0"},
+		Retract:   []string{"[v0.1.0, v0.1.5]"},
+	}
+	if !reflect.DeepEqual(app.Go, wantMod) {
+		t.Errorf("app module = %+v, want %+v", app.Go, wantMod)
+	}
+	if len(app.Dependencies) != 2 || app.Dependencies[0].Source != "workspace" || !app.Dependencies[1].Indirect {
+		t.Errorf("app dependencies not linked: %+v", app.Dependencies)
+	}
+
+	tools := findManifest(c, root, "tools/go.mod")
+	if tools == nil || len(tools.Dependencies) != 1 {
+		t.Fatalf("tools/go.mod not parsed: %+v", tools)
+	}
+	if dep := tools.Dependencies[0]; dep.Source != "path" || dep.URL != filepath.Join(root, "lib") {
+		t.Errorf("tools replacement = %q %q, want path %q", dep.Source, dep.URL, filepath.Join(root, "lib"))
+	}
+
+	got := workspaceEdges(c, root, "go.work")
+	want := []string{"app/go.mod -> lib/go.mod (require)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("go.work edges = %q, want %q", got, want)
+	}
+}
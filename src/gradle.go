@@ -0,0 +1,615 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// gradleBuild is what parseGradleBuild extracts from one build script; the
+// declarations are resolved once settings and version catalogs are known
+type gradleBuild struct {
+	decls []gradleDecl
+	vars  map[string]string
+}
+
+// gradleDecl is a dependency declaration: a configuration and its notation
+type gradleDecl struct {
+	Config   string
+	Notation string
+}
+
+// gradleSettings is a parsed settings.gradle(.kts)
+type gradleSettings struct {
+	Path     string
+	Name     string            // rootProject.name
+	Projects map[string]string // project path -> project directory
+	Catalogs map[string]*versionCatalog
+}
+
+// versionCatalog is a parsed libs.versions.toml
+type versionCatalog struct {
+	versions  map[string]string
+	libraries map[string][2]string // normalized alias -> group:name, version
+	bundles   map[string][]string
+}
+
+var (
+	gradleStatementHead = regexp.MustCompile(`^"?([A-Za-z_]\w*)"?\s*(.*)$`)
+	gradleNamedArg      = regexp.MustCompile(`^(\w+)\s*[:=]\s*(.+)$`)
+	gradleWrapped       = regexp.MustCompile(`^(platform|enforcedPlatform|testFixtures|kotlin|project)\s*\((.*)\)$`)
+	gradleAccessor      = regexp.MustCompile(`^([A-Za-z_]\w*)((?:\.\w+)+?)(?:\.get\(\))?$`)
+	gradlePlaceholder   = regexp.MustCompile(`\$\{([^}]+)\}|\$([A-Za-z_][\w.]*)`)
+	gradleStringVar     = regexp.MustCompile(`(?m)(?:^|\{)\s*(?:def\s+|val\s+|var\s+|ext\.|extra\.|project\.ext\.)?(\w+)\s*=\s*["']([^"'$\n]*)["']\s*(?:}|$)`)
+	gradleExtraSet      = regexp.MustCompile(`(?:\bset\s*\(|\bextra\s*\[)\s*["'](\w+)["']\s*(?:,|\]\s*=)\s*["']([^"'$\n]*)["']`)
+	gradleInclude       = regexp.MustCompile(`(?m)^\s*include\b`)
+	gradleQuoted        = regexp.MustCompile(`["']([^"']*)["']`)
+	gradleProjectDir    = regexp.MustCompile(`project\s*\(\s*["']([^"']+)["']\s*\)\.projectDir\s*=\s*(?:file|new\s+File)\s*\(\s*(?:(?:settingsDir|rootDir)\s*,\s*)?["']([^"']+)["']`)
+	gradleRootName      = regexp.MustCompile(`rootProject\.name\s*=\s*["']([^"']+)["']`)
+	gradleCatalogFrom   = regexp.MustCompile(`(?s)create\s*\(\s*["'](\w+)["']\s*\)\s*\{[^}]*?from\s*\(\s*files\s*\(\s*["']([^"']+)["']`)
+	gradleAliasSep      = regexp.MustCompile(`[-_.]`)
+)
+
+// parseGradleBuild parses build.gradle or build.gradle.kts; notations are
+// resolved later by resolveGradleProjects
+func (c *Crawler) parseGradleBuild(content []byte, m *Manifest) {
+	src := stripCStyleComments(string(content))
+	build := &gradleBuild{vars: make(map[string]string)}
+
+	for _, match := range gradleStringVar.FindAllStringSubmatch(strings.ReplaceAll(src, ";", "\n"), -1) {
+		build.vars[match[1]] = match[2]
+	}
+	for _, match := range gradleExtraSet.FindAllStringSubmatch(src, -1) {
+		build.vars[match[1]] = match[2]
+	}
+
+	for _, body := range gradleBlocks(src, "dependencies") {
+		for _, stmt := range gradleStatements(body) {
+			match := gradleStatementHead.FindStringSubmatch(stmt)
+			if match == nil {
+				continue
+			}
+			rest := strings.TrimSpace(match[2])
+			if rest == "" || strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ".") {
+				continue
+			}
+			build.decls = append(build.decls, gradleDecl{Config: match[1], Notation: rest})
+		}
+	}
+	m.gradle = build
+}
+
+// gradleBlocks returns the bodies of the top-level `name { ... }` blocks of a
+// script; blocks nested in buildscript, subprojects, allprojects or any other
+// closure configure something other than the project itself
+func gradleBlocks(src, name string) []string {
+	var blocks []string
+	for i := 0; i < len(src); i++ {
+		switch ch := src[i]; {
+		case ch == '"' || ch == '\'':
+			for i++; i < len(src) && src[i] != ch; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+		case ch == '{':
+			end := matchingBracket(src, i)
+			if end < i {
+				return blocks
+			}
+			i = end
+		case strings.HasPrefix(src[i:], name) && (i == 0 || !isIdentByte(src[i-1])):
+			rest := strings.TrimLeft(src[i+len(name):], " \t\r\n")
+			if !strings.HasPrefix(rest, "{") {
+				i += len(name) - 1
+				continue
+			}
+			open := len(src) - len(rest)
+			end := matchingBracket(src, open)
+			if end < open {
+				return blocks
+			}
+			blocks = append(blocks, src[open+1:end])
+			i = end
+		}
+	}
+	return blocks
+}
+
+// gradleStatements splits a block body into top-level statements, dropping
+// nested closures such as exclude rules and constraints blocks
+func gradleStatements(body string) []string {
+	var stmts []string
+	var cur strings.Builder
+	var quote byte
+	parens, braces := 0, 0
+
+	flush := func() {
+		if stmt := strings.TrimSpace(cur.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		cur.Reset()
+	}
+
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+		if quote != 0 {
+			if braces == 0 {
+				cur.WriteByte(ch)
+			}
+			if ch == '\\' && i+1 < len(body) {
+				i++
+				if braces == 0 {
+					cur.WriteByte(body[i])
+				}
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch ch {
+		case '"', '\'':
+			quote = ch
+		case '{':
+			braces++
+			continue
+		case '}':
+			braces--
+			continue
+		case '(':
+			parens++
+		case ')':
+			parens--
+		case '\n', ';':
+			if braces == 0 && parens == 0 && !strings.HasSuffix(strings.TrimSpace(cur.String()), ",") {
+				flush()
+				continue
+			}
+		}
+		if braces == 0 {
+			cur.WriteByte(ch)
+		}
+	}
+	flush()
+	return stmts
+}
+
+// resolveGradleProjects resolves Gradle declarations against settings files,
+// version catalogs and gradle.properties and records multi-project builds
+func (c *Crawler) resolveGradleProjects() {
+	var builds []*Manifest
+	byDir := make(map[string]*Manifest)
+	for _, m := range c.Analysis.Dependencies.Manifests {
+		if m.gradle != nil {
+			builds = append(builds, m)
+			byDir[filepath.Dir(m.Path)] = m
+		}
+	}
+	if len(builds) == 0 {
+		return
+	}
+
+	props := make(map[string]map[string]string) // dir -> gradle.properties
+	catalogs := make(map[string]map[string]*versionCatalog)
+	var settings []*gradleSettings
+	for _, files := range c.Analysis.FilesByType {
+		for _, file := range files {
+			dir := filepath.Dir(file.Path)
+			switch {
+			case file.Name == "gradle.properties":
+				props[dir] = readProperties(file.Path)
+			case strings.HasSuffix(file.Name, ".versions.toml") && filepath.Base(dir) == "gradle":
+				if catalog := parseVersionCatalog(file.Path); catalog != nil {
+					root := filepath.Dir(dir)
+					if catalogs[root] == nil {
+						catalogs[root] = make(map[string]*versionCatalog)
+					}
+					catalogs[root][strings.TrimSuffix(file.Name, ".versions.toml")] = catalog
+				}
+			case file.Name == "settings.gradle" || file.Name == "settings.gradle.kts":
+				if s := parseGradleSettings(file.Path); s != nil {
+					settings = append(settings, s)
+				}
+			}
+		}
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Path < settings[j].Path })
+	for _, s := range settings {
+		for name, catalog := range catalogs[filepath.Dir(s.Path)] {
+			if s.Catalogs[name] == nil {
+				s.Catalogs[name] = catalog
+			}
+		}
+	}
+
+	// Each build belongs to the settings file that includes its directory
+	owner := make(map[*Manifest]*gradleSettings)
+	projectPath := make(map[*Manifest]string)
+	for _, s := range settings {
+		for path, dir := range s.Projects {
+			if m := byDir[dir]; m != nil {
+				if _, claimed := owner[m]; !claimed || len(filepath.Dir(s.Path)) > len(filepath.Dir(owner[m].Path)) {
+					owner[m] = s
+					projectPath[m] = path
+				}
+			}
+		}
+	}
+
+	for _, m := range builds {
+		root := filepath.Dir(m.Path)
+		cats := catalogs[root]
+		s := owner[m]
+		if s != nil {
+			root = filepath.Dir(s.Path)
+			cats = s.Catalogs
+		}
+
+		vars := make(map[string]string)
+		for _, layer := range []map[string]string{props[root], props[filepath.Dir(m.Path)]} {
+			for k, v := range layer {
+				vars[k] = v
+			}
+		}
+		if rootBuild := byDir[root]; rootBuild != nil && rootBuild != m {
+			for k, v := range rootBuild.gradle.vars {
+				vars[k] = v
+			}
+		}
+		for k, v := range m.gradle.vars {
+			vars[k] = v
+		}
+
+		switch {
+		case s != nil && projectPath[m] == ":" && s.Name != "":
+			m.Name = s.Name
+		case s != nil && projectPath[m] != ":":
+			m.Name = projectPath[m][strings.LastIndex(projectPath[m], ":")+1:]
+		default:
+			m.Name = filepath.Base(root)
+		}
+		if v := interpolateGradle(vars["version"], vars, cats); v != "unspecified" && !strings.Contains(v, "$") {
+			m.Version = v
+		}
+
+		for _, decl := range m.gradle.decls {
+			for _, ref := range gradleNotation(decl.Notation, vars, cats) {
+				dep := m.addDependency(decl.Config, ref[0], ref[1])
+				if strings.HasPrefix(ref[0], ":") {
+					dep.Source = "workspace"
+				}
+			}
+		}
+	}
+
+	for _, s := range settings {
+		c.recordGradleBuild(s, byDir)
+	}
+}
+
+// recordGradleBuild records a multi-project build as a workspace with its
+// project(...) dependencies as edges
+func (c *Crawler) recordGradleBuild(s *gradleSettings, byDir map[string]*Manifest) {
+	members := make(map[string]*Manifest) // project path -> build script
+	for path, dir := range s.Projects {
+		if m := byDir[dir]; m != nil {
+			members[path] = m
+		}
+	}
+	if len(members) < 2 {
+		return
+	}
+
+	ws := &Workspace{Kind: "gradle", Root: s.Path, Name: s.Name, Members: []string{}, Edges: []WorkspaceEdge{}}
+	for _, path := range sortedKeys(members) {
+		m := members[path]
+		ws.Members = append(ws.Members, m.Path)
+		for _, dep := range m.Dependencies {
+			if target := members[dep.Name]; target != nil && dep.Source == "workspace" {
+				ws.Edges = append(ws.Edges, WorkspaceEdge{From: m.Path, To: target.Path, Kind: dep.Group})
+			}
+		}
+	}
+	c.Analysis.Dependencies.Workspaces = append(c.Analysis.Dependencies.Workspaces, ws)
+}
+
+// gradleNotation resolves a dependency notation to name/version pairs. Project
+// dependencies are named by their project path.
+func gradleNotation(expr string, vars map[string]string, catalogs map[string]*versionCatalog) [][2]string {
+	expr = unwrapParens(strings.TrimSpace(expr))
+	parts := splitTopLevel(expr, ',')
+
+	// Map notation: group: 'g', name: 'n', version: 'v'
+	if named := gradleNamedArgs(parts, vars, catalogs); named != nil {
+		if path := named["path"]; path != "" {
+			return [][2]string{{path, ""}}
+		}
+		if named["name"] == "" {
+			return nil
+		}
+		return [][2]string{{named["group"] + ":" + named["name"], named["version"]}}
+	}
+
+	var refs [][2]string
+	for _, part := range parts {
+		refs = append(refs, gradleSingleNotation(strings.TrimSpace(part), vars, catalogs)...)
+	}
+	return refs
+}
+
+// gradleNamedArgs returns named arguments, or nil when the notation has none
+func gradleNamedArgs(parts []string, vars map[string]string, catalogs map[string]*versionCatalog) map[string]string {
+	named := make(map[string]string)
+	for _, part := range parts {
+		match := gradleNamedArg.FindStringSubmatch(strings.TrimSpace(part))
+		if match == nil {
+			continue
+		}
+		if value, ok := quotedString(match[2]); ok {
+			named[match[1]] = interpolateGradle(value, vars, catalogs)
+		}
+	}
+	if len(named) == 0 {
+		return nil
+	}
+	return named
+}
+
+// gradleSingleNotation resolves one string, catalog accessor or wrapped notation
+func gradleSingleNotation(expr string, vars map[string]string, catalogs map[string]*versionCatalog) [][2]string {
+	if value, ok := quotedString(expr); ok {
+		coords := strings.Split(interpolateGradle(value, vars, catalogs), ":")
+		if len(coords) < 2 {
+			return nil
+		}
+		version := ""
+		if len(coords) > 2 {
+			version = strings.SplitN(coords[2], "@", 2)[0]
+		}
+		return [][2]string{{coords[0] + ":" + strings.SplitN(coords[1], "@", 2)[0], version}}
+	}
+
+	if match := gradleWrapped.FindStringSubmatch(expr); match != nil {
+		switch match[1] {
+		case "project":
+			args := splitTopLevel(match[2], ',')
+			if named := gradleNamedArgs(args, vars, catalogs); named != nil {
+				return [][2]string{{named["path"], ""}}
+			}
+			path, _ := quotedString(args[0])
+			return [][2]string{{":" + strings.TrimPrefix(path, ":"), ""}}
+		case "kotlin":
+			args := splitTopLevel(match[2], ',')
+			module, _ := quotedString(args[0])
+			version := ""
+			if len(args) > 1 {
+				version, _ = quotedString(args[1])
+			}
+			return [][2]string{{"org.jetbrains.kotlin:kotlin-" + module, interpolateGradle(version, vars, catalogs)}}
+		default:
+			return gradleNotation(match[2], vars, catalogs)
+		}
+	}
+
+	if match := gradleAccessor.FindStringSubmatch(expr); match != nil {
+		catalog := catalogs[match[1]]
+		if catalog == nil {
+			return nil
+		}
+		alias := normalizeCatalogAlias(strings.TrimPrefix(match[2], "."))
+		if strings.HasPrefix(alias, "bundles.") {
+			var refs [][2]string
+			for _, lib := range catalog.bundles[strings.TrimPrefix(alias, "bundles.")] {
+				if entry, ok := catalog.libraries[lib]; ok {
+					refs = append(refs, entry)
+				}
+			}
+			return refs
+		}
+		if entry, ok := catalog.libraries[alias]; ok {
+			return [][2]string{entry}
+		}
+	}
+	return nil
+}
+
+// unwrapParens strips parentheses that enclose the whole expression
+func unwrapParens(expr string) string {
+	for strings.HasPrefix(expr, "(") {
+		depth := 0
+		for i := 0; i < len(expr); i++ {
+			if expr[i] == '(' {
+				depth++
+			} else if expr[i] == ')' {
+				depth--
+				if depth == 0 && i != len(expr)-1 {
+					return expr
+				}
+			}
+		}
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	return expr
+}
+
+// interpolateGradle substitutes $var and ${var} references, including
+// libs.versions.* catalog versions; unknown references are left intact
+func interpolateGradle(s string, vars map[string]string, catalogs map[string]*versionCatalog) string {
+	return gradlePlaceholder.ReplaceAllStringFunc(s, func(ref string) string {
+		match := gradlePlaceholder.FindStringSubmatch(ref)
+		name := strings.TrimSuffix(match[1]+match[2], ".get()")
+		for _, prefix := range []string{"rootProject.", "project.", "ext.", "extra."} {
+			name = strings.TrimPrefix(name, prefix)
+		}
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		if dot := strings.Index(name, ".versions."); dot > 0 {
+			if catalog := catalogs[name[:dot]]; catalog != nil {
+				if v, ok := catalog.versions[normalizeCatalogAlias(name[dot+len(".versions."):])]; ok {
+					return v
+				}
+			}
+		}
+		return ref
+	})
+}
+
+// normalizeCatalogAlias maps a catalog alias to the form of its accessor, so
+// spring-boot, spring_boot and spring.boot all match libs.spring.boot
+func normalizeCatalogAlias(alias string) string {
+	return strings.ToLower(gradleAliasSep.ReplaceAllString(alias, "."))
+}
+
+// parseVersionCatalog parses a Gradle version catalog TOML file
+func parseVersionCatalog(path string) *versionCatalog {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	doc, err := parseTOML(content)
+	if err != nil {
+		return nil
+	}
+
+	catalog := &versionCatalog{
+		versions:  make(map[string]string),
+		libraries: make(map[string][2]string),
+		bundles:   make(map[string][]string),
+	}
+	for alias, v := range tomlTable(doc, "versions") {
+		catalog.versions[normalizeCatalogAlias(alias)] = catalogVersion(v, nil)
+	}
+	for alias, v := range tomlTable(doc, "libraries") {
+		var module, version string
+		switch lib := v.(type) {
+		case string:
+			coords := strings.Split(lib, ":")
+			if len(coords) < 2 {
+				continue
+			}
+			module = coords[0] + ":" + coords[1]
+			if len(coords) > 2 {
+				version = coords[2]
+			}
+		case map[string]interface{}:
+			module = tomlString(lib, "module")
+			if module == "" {
+				module = tomlString(lib, "group") + ":" + tomlString(lib, "name")
+			}
+			version = catalogVersion(lib["version"], catalog.versions)
+		}
+		catalog.libraries[normalizeCatalogAlias(alias)] = [2]string{module, version}
+	}
+	for name, v := range tomlTable(doc, "bundles") {
+		list, _ := v.([]interface{})
+		for _, item := range list {
+			if alias, ok := item.(string); ok {
+				catalog.bundles[normalizeCatalogAlias(name)] = append(catalog.bundles[normalizeCatalogAlias(name)], normalizeCatalogAlias(alias))
+			}
+		}
+	}
+	return catalog
+}
+
+// catalogVersion resolves a catalog version: a plain string, a ref to the
+// [versions] table, or a rich version with strictly/require/prefer
+func catalogVersion(v interface{}, versions map[string]string) string {
+	switch version := v.(type) {
+	case string:
+		return version
+	case map[string]interface{}:
+		if ref := tomlString(version, "ref"); ref != "" {
+			return versions[normalizeCatalogAlias(ref)]
+		}
+		for _, key := range []string{"strictly", "require", "prefer"} {
+			if s := tomlString(version, key); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// parseGradleSettings reads the projects and version catalogs of a settings file
+func parseGradleSettings(path string) *gradleSettings {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	src := stripCStyleComments(string(content))
+	root := filepath.Dir(path)
+
+	s := &gradleSettings{
+		Path:     path,
+		Projects: map[string]string{":": root},
+		Catalogs: make(map[string]*versionCatalog),
+	}
+	if match := gradleRootName.FindStringSubmatch(src); match != nil {
+		s.Name = match[1]
+	}
+
+	for _, include := range gradleInclude.FindAllStringIndex(src, -1) {
+		for _, quoted := range gradleQuoted.FindAllStringSubmatch(gradleIncludeArgs(src, include[1]), -1) {
+			project := ":" + strings.TrimPrefix(quoted[1], ":")
+			s.Projects[project] = filepath.Join(root, filepath.FromSlash(strings.ReplaceAll(strings.TrimPrefix(project, ":"), ":", "/")))
+		}
+	}
+	for _, match := range gradleProjectDir.FindAllStringSubmatch(src, -1) {
+		project := ":" + strings.TrimPrefix(match[1], ":")
+		if _, ok := s.Projects[project]; ok {
+			s.Projects[project] = filepath.Join(root, filepath.FromSlash(match[2]))
+		}
+	}
+
+	for _, match := range gradleCatalogFrom.FindAllStringSubmatch(src, -1) {
+		if catalog := parseVersionCatalog(filepath.Join(root, filepath.FromSlash(match[2]))); catalog != nil {
+			s.Catalogs[match[1]] = catalog
+		}
+	}
+	return s
+}
+
+// gradleIncludeArgs returns the arguments of the include statement whose
+// keyword ends at offset: up to the matching ")" of include(...), or up to
+// the first line not ending in a comma for Groovy's `include ":a", ":b"`
+func gradleIncludeArgs(src string, offset int) string {
+	rest := strings.TrimLeft(src[offset:], " \t")
+	if strings.HasPrefix(rest, "(") {
+		open := len(src) - len(rest)
+		if end := matchingBracket(src, open); end > open {
+			return src[open+1 : end]
+		}
+		return rest[1:]
+	}
+	var args strings.Builder
+	for _, line := range strings.SplitAfter(rest, "\n") {
+		args.WriteString(line)
+		if !strings.HasSuffix(strings.TrimSpace(line), ",") {
+			break
+		}
+	}
+	return args.String()
+}
+
+// readProperties reads a Java .properties file into a map
+func readProperties(path string) map[string]string {
+	props := make(map[string]string)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return props
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		if idx := strings.IndexAny(line, "=:"); idx > 0 {
+			props[strings.TrimSpace(line[:idx])] = strings.TrimSpace(line[idx+1:])
+		}
+	}
+	return props
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGradleProjects(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"settings.gradle.kts": "rootProject.name = \"shop\"\ninclude(\":app\", \":lib\")\n",
+		"gradle/libs.versions.toml": `[versions]
+kotlin = "1.9.0"
+
+[libraries]
+kotlin-stdlib = { module = "org.jetbrains.kotlin:kotlin-stdlib", version.ref = "kotlin" }
+okhttp = "com.squareup.okhttp3:okhttp:4.12.0"
+
+[bundles]
+net = ["okhttp"]
+`,
+		"build.gradle.kts": "plugins { java }\n",
+		"app/build.gradle.kts": `dependencies {
+    implementation(project(":lib"))
+    implementation(libs.kotlin.stdlib)
+    implementation(libs.bundles.net)
+    testImplementation("junit:junit:4.13.2") // tests
+    /* runtimeOnly("x:y:1") */
+}
+`,
+		"lib/build.gradle": `def guavaVersion = '32.1.0-jre'
+dependencies {
+    api "com.google.guava:guava:$guavaVersion"
+    compileOnly group: 'org.projectlombok', name: 'lombok', version: '1.18.30'
+    implementation('org.slf4j:slf4j-api:2.0.9') {
+        exclude group: 'x'
+    }
+}
+`,
+	})
+
+	tests := []struct {
+		path string
+		name string
+		deps []string
+	}{
+		{"build.gradle.kts", "shop", nil},
+		{"app/build.gradle.kts", "app", []string{
+			"implementation :lib ",
+			"implementation org.jetbrains.kotlin:kotlin-stdlib 1.9.0",
+			"implementation com.squareup.okhttp3:okhttp 4.12.0",
+			"testImplementation junit:junit 4.13.2",
+		}},
+		{"lib/build.gradle", "lib", []string{
+			"api com.google.guava:guava 32.1.0-jre",
+			"compileOnly org.projectlombok:lombok 1.18.30",
+			"implementation org.slf4j:slf4j-api 2.0.9",
+		}},
+	}
+	for _, tt := range tests {
+		m := findManifest(c, root, tt.path)
+		if m == nil {
+			t.Errorf("%s: no manifest", tt.path)
+			continue
+		}
+		if m.Name != tt.name {
+			t.Errorf("%s: name %q, want %q", tt.path, m.Name, tt.name)
+		}
+		if got := manifestDeps(m); !reflect.DeepEqual(got, tt.deps) {
+			t.Errorf("%s: dependencies = %q, want %q", tt.path, got, tt.deps)
+		}
+	}
+
+	want := []string{"app/build.gradle.kts -> lib/build.gradle (implementation)"}
+	if got := workspaceEdges(c, root, "settings.gradle.kts"); !reflect.DeepEqual(got, want) {
+		t.Errorf("build edges = %v, want %v", got, want)
+	}
+}
+
+func TestGradleStatements(t *testing.T) {
+	body := `
+    implementation("a:b:1") { exclude(group = "x") }
+    constraints { implementation("c:d:2") }
+    api 'e:f:3'; runtimeOnly "g:h:4"
+    testImplementation(
+        "i:j:5"
+    )
+`
+	want := []string{
+		`implementation("a:b:1")`,
+		`constraints`,
+		`api 'e:f:3'`,
+		`runtimeOnly "g:h:4"`,
+		"testImplementation(\n        \"i:j:5\"\n    )",
+	}
+	if got := gradleStatements(body); !reflect.DeepEqual(got, want) {
+		t.Errorf("gradleStatements = %q, want %q", got, want)
+	}
+}
+
+func TestParseGradleSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"settings.gradle.kts", "include(\n    \":app\",\n    \":lib\"\n)\ninclude(\":tools\")\n"},
+		{"settings.gradle", "include ':app',\n        ':lib'\ninclude ':tools'\nincludeBuild 'plugins'\n"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		s := parseGradleSettings(path)
+		root := filepath.Dir(path)
+		want := map[string]string{
+			":":      root,
+			":app":   filepath.Join(root, "app"),
+			":lib":   filepath.Join(root, "lib"),
+			":tools": filepath.Join(root, "tools"),
+		}
+		if !reflect.DeepEqual(s.Projects, want) {
+			t.Errorf("%s: projects = %v, want %v", tt.name, s.Projects, want)
+		}
+	}
+}
+
+func TestGradleBlocks(t *testing.T) {
+	src := `buildscript {
+    dependencies { classpath "com.android.tools.build:gradle:8.2.0" }
+}
+allprojects {
+    dependencies { implementation "org.slf4j:slf4j-api:2.0.9" }
+}
+def label = "dependencies { }"
+dependencies {
+    implementation "com.google.guava:guava:32.1.0-jre"
+}
+subprojects { dependencies { testImplementation "junit:junit:4.13.2" } }
+mydependencies { api "x:y:1" }
+`
+	got := gradleBlocks(src, "dependencies")
+	want := []string{"\n    implementation \"com.google.guava:guava:32.1.0-jre\"\n"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("gradleBlocks = %q, want %q", got, want)
+	}
+}
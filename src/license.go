@@ -0,0 +1,760 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//go:embed licenses/*.txt
+var licenseTextsFS embed.FS
+
+// licenseMatchThreshold is the lowest similarity at which a license text is
+// taken to be the SPDX license it resembles most
+const licenseMatchThreshold = 0.8
+
+// LicenseFile is a LICENSE or COPYING file of the repository
+type LicenseFile struct {
+	Path    string  `json:"path"`
+	License string  `json:"license"`           // SPDX identifier, "" when no license text matched
+	Closest string  `json:"closest,omitempty"` // best match below the threshold
+	Score   float64 `json:"score"`             // similarity to the matched SPDX text, 0-1
+	Flag    string  `json:"flag,omitempty"`
+}
+
+// DependencyLicense is the license of a dependency, read from its locally
+// installed sources
+type DependencyLicense struct {
+	Ecosystem string   `json:"ecosystem"`
+	Package   string   `json:"package"`
+	Version   string   `json:"version,omitempty"`
+	License   string   `json:"license"`            // SPDX expression, "" when unknown
+	Declared  string   `json:"declared,omitempty"` // license metadata as written, when it is not an SPDX expression
+	Source    string   `json:"source,omitempty"`   // metadata or license file the license was read from
+	Score     float64  `json:"score,omitempty"`    // similarity of a license text to the SPDX text it matched
+	Flag      string   `json:"flag,omitempty"`     // "denied", "not-allowed", "unknown" or "copyleft"
+	Files     []string `json:"files"`              // manifests and lockfiles the package was found in
+}
+
+// licenseFlagRanks orders flags from acceptable to worst
+var licenseFlagRanks = map[string]int{"": 0, "copyleft": 1, "unknown": 2, "not-allowed": 3, "denied": 4}
+
+// copyleftPrefixes are the SPDX identifier prefixes of copyleft licenses,
+// weak (LGPL, MPL, EPL) as well as strong
+var copyleftPrefixes = []string{"GPL-", "LGPL-", "AGPL-", "MPL-", "EPL-", "EUPL-", "CDDL-", "OSL-", "CPL-", "CC-BY-SA-", "SSPL-", "MS-RL", "ODBL-"}
+
+// licenseAliases maps license names used in package metadata, including the
+// last part of Python "License ::" classifiers, to SPDX identifiers
+var licenseAliases = map[string]string{
+	"mit":                                  "MIT",
+	"mit license":                          "MIT",
+	"the mit license":                      "MIT",
+	"mit no attribution license (mit-0)":   "MIT-0",
+	"isc":                                  "ISC",
+	"isc license":                          "ISC",
+	"isc license (iscl)":                   "ISC",
+	"apache 2":                             "Apache-2.0",
+	"apache 2.0":                           "Apache-2.0",
+	"apache-2":                             "Apache-2.0",
+	"apache license 2.0":                   "Apache-2.0",
+	"apache license, version 2.0":          "Apache-2.0",
+	"apache license version 2.0":           "Apache-2.0",
+	"apache software license":              "Apache-2.0",
+	"apache software license 2.0":          "Apache-2.0",
+	"asl 2.0":                              "Apache-2.0",
+	"bsd 2-clause":                         "BSD-2-Clause",
+	"simplified bsd":                       "BSD-2-Clause",
+	"bsd 3-clause":                         "BSD-3-Clause",
+	"new bsd":                              "BSD-3-Clause",
+	"new bsd license":                      "BSD-3-Clause",
+	"modified bsd":                         "BSD-3-Clause",
+	"boost software license 1.0 (bsl-1.0)": "BSL-1.0",
+	"zlib/libpng license":                  "Zlib",
+	"the unlicense (unlicense)":            "Unlicense",
+	"cc0 1.0 universal (cc0 1.0) public domain dedication": "CC0-1.0",
+	"python software foundation license":                   "PSF-2.0",
+	"psf":                                                  "PSF-2.0",
+	"mozilla public license 2.0 (mpl 2.0)":                 "MPL-2.0",
+	"mpl 2.0":                                              "MPL-2.0",
+	"eclipse public license 1.0 (epl-1.0)":                 "EPL-1.0",
+	"eclipse public license 2.0 (epl-2.0)":                 "EPL-2.0",
+	"gnu general public license v2 (gplv2)":                "GPL-2.0",
+	"gnu general public license v2 or later (gplv2+)":      "GPL-2.0-or-later",
+	"gnu general public license v3 (gplv3)":                "GPL-3.0",
+	"gnu general public license v3 or later (gplv3+)":      "GPL-3.0-or-later",
+	"gplv2": "GPL-2.0",
+	"gplv3": "GPL-3.0",
+	"gnu lesser general public license v2 (lgplv2)":           "LGPL-2.0",
+	"gnu lesser general public license v2 or later (lgplv2+)": "LGPL-2.0-or-later",
+	"gnu lesser general public license v3 (lgplv3)":           "LGPL-3.0",
+	"gnu lesser general public license v3 or later (lgplv3+)": "LGPL-3.0-or-later",
+	"lgplv3":                               "LGPL-3.0",
+	"gnu affero general public license v3": "AGPL-3.0",
+	"gnu affero general public license v3 or later (agplv3+)": "AGPL-3.0-or-later",
+}
+
+var (
+	spdxHeader      = regexp.MustCompile(`(?m)SPDX-License-Identifier:\s*([^\n*]+?)\s*(?:\*/|-->|$)`)
+	spdxExpression  = regexp.MustCompile(`^[A-Za-z0-9.+\-() ]+$`)
+	licenseTokens   = regexp.MustCompile(`\(|\)|[^\s()]+`)
+	licenseFileName = regexp.MustCompile(`(?i)^((un)?licen[cs]e|copying)([-_.].*)?$`)
+)
+
+// licenseTemplate is an embedded SPDX license text, as a set of word bigrams
+type licenseTemplate struct {
+	id      string
+	bigrams map[string]bool
+}
+
+// licenseClassifier matches license texts against the embedded SPDX texts
+type licenseClassifier struct {
+	templates []licenseTemplate
+	cache     map[string]LicenseFile // by path
+}
+
+// newLicenseClassifier loads the embedded license texts
+func newLicenseClassifier() *licenseClassifier {
+	lc := &licenseClassifier{cache: make(map[string]LicenseFile)}
+	entries, _ := licenseTextsFS.ReadDir("licenses")
+	for _, entry := range entries {
+		content, err := licenseTextsFS.ReadFile("licenses/" + entry.Name())
+		if err != nil {
+			continue
+		}
+		lc.templates = append(lc.templates, licenseTemplate{
+			id:      strings.TrimSuffix(entry.Name(), ".txt"),
+			bigrams: licenseBigrams(string(content)),
+		})
+	}
+	return lc
+}
+
+// licenseBigrams normalizes a license text to its set of word bigrams,
+// ignoring case, punctuation and copyright lines
+func licenseBigrams(text string) map[string]bool {
+	var words []string
+	for _, line := range strings.Split(strings.ToLower(text), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "copyright") || strings.HasPrefix(line, "(c)") || strings.HasPrefix(line, "©") {
+			continue
+		}
+		words = append(words, strings.FieldsFunc(line, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+	bigrams := make(map[string]bool)
+	for i := 1; i < len(words); i++ {
+		bigrams[words[i-1]+" "+words[i]] = true
+	}
+	return bigrams
+}
+
+// classify matches a license text: an SPDX-License-Identifier line wins,
+// otherwise the embedded text with the highest Dice coefficient over word
+// bigrams
+func (lc *licenseClassifier) classify(text string) (license, closest string, score float64) {
+	if match := spdxHeader.FindStringSubmatch(text); match != nil {
+		return match[1], "", 1
+	}
+	bigrams := licenseBigrams(text)
+	if len(bigrams) == 0 {
+		return "", "", 0
+	}
+	for _, t := range lc.templates {
+		common := 0
+		for bigram := range bigrams {
+			if t.bigrams[bigram] {
+				common++
+			}
+		}
+		if s := 2 * float64(common) / float64(len(bigrams)+len(t.bigrams)); s > score {
+			closest, score = t.id, s
+		}
+	}
+	// The AGPL is the GPL v3 plus a network clause, so only its title tells
+	// them apart; the GPL's own text mentions the AGPL further down
+	title := strings.ToLower(text)
+	if len(title) > 500 {
+		title = title[:500]
+	}
+	if closest == "GPL-3.0" && strings.Contains(title, "gnu affero general public license") {
+		closest = "AGPL-3.0"
+	}
+	score = float64(int(score*1000)) / 1000
+	if score < licenseMatchThreshold {
+		return "", closest, score
+	}
+	return closest, "", score
+}
+
+// classifyFile classifies a license file, caching the result by path
+func (lc *licenseClassifier) classifyFile(path string) LicenseFile {
+	if cached, ok := lc.cache[path]; ok {
+		return cached
+	}
+	result := LicenseFile{Path: path}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Size() < 256*1024 {
+		if content, err := os.ReadFile(path); err == nil {
+			result.License, result.Closest, result.Score = lc.classify(string(content))
+		}
+	}
+	lc.cache[path] = result
+	return result
+}
+
+// classifyDir classifies the license files directly inside a directory;
+// several licenses (LICENSE-MIT and LICENSE-APACHE) are joined with AND and
+// the score is that of the weakest match
+func (lc *licenseClassifier) classifyDir(dir string) (license, source string, score float64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", 0
+	}
+	ids := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !isLicenseFileName(entry.Name()) {
+			continue
+		}
+		match := lc.classifyFile(filepath.Join(dir, entry.Name()))
+		switch {
+		case match.License == "":
+			if source == "" {
+				source, score = match.Path, match.Score
+			}
+		case len(ids) == 0:
+			source, score = match.Path, match.Score
+		case match.Score < score:
+			score = match.Score
+		}
+		if match.License != "" {
+			ids[match.License] = true
+		}
+	}
+	return strings.Join(sortedKeys(ids), " AND "), source, score
+}
+
+// isLicenseFileName reports whether a file name is a LICENSE, LICENCE,
+// UNLICENSE or COPYING file, possibly with a suffix such as -MIT or .md, and
+// not source code such as license.go
+func isLicenseFileName(name string) bool {
+	if !licenseFileName.MatchString(name) {
+		return false
+	}
+	switch languageMap[strings.ToLower(filepath.Ext(name))] {
+	case "", "Text", "Markdown", "reStructuredText":
+		return true
+	}
+	return false
+}
+
+// normalizeLicense turns license metadata into an SPDX expression: known
+// license names are mapped to their identifier and anything that reads as an
+// expression is kept; other text yields ""
+func normalizeLicense(declared string) string {
+	declared = strings.TrimSpace(declared)
+	if id, ok := licenseAliases[strings.ToLower(strings.TrimSuffix(declared, "."))]; ok {
+		return id
+	}
+	if declared == "" || !spdxExpression.MatchString(declared) {
+		return ""
+	}
+	// Identifiers alternate with operators; "BSD License" is a description
+	ids, operators := 0, 0
+	for _, word := range strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(declared)) {
+		switch strings.ToUpper(word) {
+		case "AND", "OR", "WITH":
+			operators++
+		default:
+			ids++
+		}
+	}
+	if ids != operators+1 {
+		return ""
+	}
+	return strings.TrimSpace(declared)
+}
+
+// licensePolicy is the configured allow and deny lists, by lower-cased SPDX identifier
+type licensePolicy struct {
+	allow, deny map[string]bool
+}
+
+// newLicensePolicy builds a policy from allow and deny lists of SPDX identifiers
+func newLicensePolicy(allow, deny []string) licensePolicy {
+	p := licensePolicy{allow: make(map[string]bool), deny: make(map[string]bool)}
+	for _, id := range allow {
+		p.allow[strings.ToLower(id)] = true
+	}
+	for _, id := range deny {
+		p.deny[strings.ToLower(id)] = true
+	}
+	return p
+}
+
+// flag evaluates an SPDX expression: an OR is as good as its best choice, an
+// AND as bad as its worst part
+func (p licensePolicy) flag(expression string) string {
+	tokens := licenseTokens.FindAllString(expression, -1)
+	if len(tokens) == 0 {
+		return "unknown"
+	}
+	flag, _ := p.flagOr(tokens)
+	return flag
+}
+
+// flagOr evaluates `and-expr { OR and-expr }` and returns the unread tokens
+func (p licensePolicy) flagOr(tokens []string) (string, []string) {
+	flag, tokens := p.flagAnd(tokens)
+	for len(tokens) > 0 && strings.EqualFold(tokens[0], "OR") {
+		var next string
+		next, tokens = p.flagAnd(tokens[1:])
+		if licenseFlagRanks[next] < licenseFlagRanks[flag] {
+			flag = next
+		}
+	}
+	return flag, tokens
+}
+
+// flagAnd evaluates `term { AND term }` and returns the unread tokens
+func (p licensePolicy) flagAnd(tokens []string) (string, []string) {
+	flag, tokens := p.flagTerm(tokens)
+	for len(tokens) > 0 && strings.EqualFold(tokens[0], "AND") {
+		var next string
+		next, tokens = p.flagTerm(tokens[1:])
+		if licenseFlagRanks[next] > licenseFlagRanks[flag] {
+			flag = next
+		}
+	}
+	return flag, tokens
+}
+
+// flagTerm evaluates a parenthesized expression or `id [WITH exception]`
+func (p licensePolicy) flagTerm(tokens []string) (string, []string) {
+	if len(tokens) == 0 {
+		return "unknown", nil
+	}
+	if tokens[0] == "(" {
+		flag, rest := p.flagOr(tokens[1:])
+		if len(rest) > 0 && rest[0] == ")" {
+			rest = rest[1:]
+		}
+		return flag, rest
+	}
+	id, rest := tokens[0], tokens[1:]
+	if len(rest) >= 2 && strings.EqualFold(rest[0], "WITH") {
+		rest = rest[2:]
+	}
+	return p.flagID(id), rest
+}
+
+// flagID flags a single license: the deny list, then the allow list, then
+// whether it is copyleft
+func (p licensePolicy) flagID(id string) string {
+	key := strings.ToLower(id)
+	switch {
+	case p.deny[key]:
+		return "denied"
+	case p.allow[key]:
+		return ""
+	case len(p.allow) > 0:
+		return "not-allowed"
+	}
+	upper := strings.ToUpper(id)
+	for _, prefix := range copyleftPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return "copyleft"
+		}
+	}
+	return ""
+}
+
+// DetectLicenses classifies the repository's license files and looks up the
+// license of every dependency whose sources are installed locally, flagging
+// them against Config.LicenseAllow and Config.LicenseDeny
+func (c *Crawler) DetectLicenses() {
+	lc := newLicenseClassifier()
+	policy := newLicensePolicy(c.Config.LicenseAllow, c.Config.LicenseDeny)
+	deps := c.Analysis.Dependencies
+
+	files := []*LicenseFile{}
+	for _, infos := range c.Analysis.FilesByType {
+		for _, info := range infos {
+			if !isLicenseFileName(info.Name) {
+				continue
+			}
+			match := lc.classifyFile(info.Path)
+			match.Flag = "unknown"
+			if match.License != "" {
+				match.Flag = policy.flag(match.License)
+			}
+			files = append(files, &match)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	deps.LicenseFiles = files
+
+	found := make(map[string]*DependencyLicense) // ecosystem|package|version
+	add := func(manager, name, version, file string) {
+		ecosystem := osvEcosystems[manager]
+		if ecosystem == "" {
+			ecosystem = manager
+		}
+		dl := c.lookupLicense(lc, ecosystem, name, version, filepath.Dir(file))
+		key := strings.Join([]string{ecosystem, name, dl.Version}, "|")
+		if existing := found[key]; existing != nil {
+			dl = existing
+		} else {
+			found[key] = dl
+		}
+		if !containsString(dl.Files, file) {
+			dl.Files = append(dl.Files, file)
+		}
+	}
+	for _, m := range deps.Manifests {
+		for _, dep := range m.Dependencies {
+			switch dep.Source {
+			case "workspace", "path", "sdk":
+				continue
+			}
+			version := dep.Resolved
+			if version == "" {
+				version = dep.Version
+			}
+			add(m.Manager, dep.Name, version, m.Path)
+		}
+	}
+	for _, lock := range deps.Lockfiles {
+		if lock.Error != "" {
+			continue
+		}
+		for _, pkg := range lock.Packages {
+			// go.sum also lists modules of which only the go.mod was downloaded
+			if lock.Manager == "go modules" && pkg.Integrity == "" {
+				continue
+			}
+			add(lock.Manager, pkg.Name, pkg.Version, lock.Path)
+		}
+	}
+
+	licenses := []*DependencyLicense{}
+	for _, key := range sortedKeys(found) {
+		dl := found[key]
+		dl.Flag = "unknown"
+		if dl.License != "" {
+			dl.Flag = policy.flag(dl.License)
+		}
+		licenses = append(licenses, dl)
+	}
+	deps.Licenses = licenses
+}
+
+// lookupLicense finds the installed sources of a package in dir or one of its
+// parents within the repository and reads their license. version is the
+// resolved version, or the declared constraint when there is none; the
+// installed version replaces a constraint.
+func (c *Crawler) lookupLicense(lc *licenseClassifier, ecosystem, name, version, dir string) *DependencyLicense {
+	dl := &DependencyLicense{Ecosystem: ecosystem, Package: name, Version: version, Files: []string{}}
+	var dirs []string
+	for {
+		dirs = append(dirs, dir)
+		parent := filepath.Dir(dir)
+		if rel, err := filepath.Rel(c.Config.TargetPath, dir); err != nil || rel == "." || strings.HasPrefix(rel, "..") || parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	for _, dir := range dirs {
+		var ok bool
+		switch ecosystem {
+		case "npm":
+			ok = lc.nodeLicense(dl, filepath.Join(dir, "node_modules", filepath.FromSlash(name)))
+		case "Packagist":
+			ok = lc.composerLicense(dl, filepath.Join(dir, "vendor", filepath.FromSlash(name)))
+		case "Go":
+			ok = lc.dirLicense(dl, filepath.Join(dir, "vendor", filepath.FromSlash(name)))
+		case "crates.io":
+			ok = lc.cargoLicense(dl, filepath.Join(dir, "vendor", name))
+		case "PyPI":
+			ok = lc.pythonLicense(dl, dir)
+		}
+		if ok {
+			return dl
+		}
+	}
+
+	// Module and crate caches outside the repository
+	switch ecosystem {
+	case "Go":
+		if cache := goModCache(); cache != "" && version != "" {
+			lc.dirLicense(dl, filepath.Join(cache, escapeGoModulePath(name)+"@"+escapeGoModulePath(version)))
+		}
+	case "crates.io":
+		if home := cargoHome(); home != "" {
+			matches, _ := filepath.Glob(filepath.Join(home, "registry", "src", "*", name+"-"+version))
+			for _, match := range matches {
+				if lc.cargoLicense(dl, match) {
+					break
+				}
+			}
+		}
+	}
+	return dl
+}
+
+// dirLicense classifies the license files of a package directory
+func (lc *licenseClassifier) dirLicense(dl *DependencyLicense, dir string) bool {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return false
+	}
+	dl.License, dl.Source, dl.Score = lc.classifyDir(dir)
+	return true
+}
+
+// fromMetadata records declared license metadata, falling back to the
+// package's license files when it is not an SPDX expression
+func (lc *licenseClassifier) fromMetadata(dl *DependencyLicense, declared, source, dir string) {
+	dl.License, dl.Source = normalizeLicense(declared), source
+	if dl.License == declared {
+		return
+	}
+	dl.Declared = declared
+	if dl.License == "" {
+		if license, file, score := lc.classifyDir(dir); license != "" {
+			dl.License, dl.Source, dl.Score = license, file, score
+		}
+	}
+}
+
+// nodeLicense reads the "license" (or legacy "licenses") field of an
+// installed package.json
+func (lc *licenseClassifier) nodeLicense(dl *DependencyLicense, dir string) bool {
+	content, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return false
+	}
+	var pkg struct {
+		Version  string          `json:"version"`
+		License  json.RawMessage `json:"license"`
+		Licenses []struct {
+			Type string `json:"type"`
+		} `json:"licenses"`
+	}
+	if json.Unmarshal(content, &pkg) != nil {
+		return false
+	}
+	if pkg.Version != "" {
+		dl.Version = pkg.Version
+	}
+
+	var declared string
+	if json.Unmarshal(pkg.License, &declared) != nil {
+		var obj struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(pkg.License, &obj)
+		declared = obj.Type
+	}
+	if declared == "" && len(pkg.Licenses) > 0 {
+		var types []string
+		for _, l := range pkg.Licenses {
+			types = append(types, l.Type)
+		}
+		declared = strings.Join(types, " OR ")
+	}
+
+	if file := strings.TrimPrefix(declared, "SEE LICENSE IN "); file != declared {
+		match := lc.classifyFile(filepath.Join(dir, filepath.FromSlash(file)))
+		dl.Declared, dl.License, dl.Source, dl.Score = declared, match.License, match.Path, match.Score
+		return true
+	}
+	if declared == "" {
+		dl.License, dl.Source, dl.Score = lc.classifyDir(dir)
+		return true
+	}
+	lc.fromMetadata(dl, declared, filepath.Join(dir, "package.json"), dir)
+	return true
+}
+
+// composerLicense reads the "license" field of an installed composer.json;
+// several licenses are a choice
+func (lc *licenseClassifier) composerLicense(dl *DependencyLicense, dir string) bool {
+	content, err := os.ReadFile(filepath.Join(dir, "composer.json"))
+	if err != nil {
+		return false
+	}
+	var pkg map[string]interface{}
+	if json.Unmarshal(content, &pkg) != nil {
+		return false
+	}
+	if v, ok := pkg["version"].(string); ok {
+		dl.Version = v
+	}
+	declared, _ := pkg["license"].(string)
+	if list := stringList(pkg["license"]); len(list) > 0 {
+		declared = strings.Join(list, " OR ")
+		if len(list) > 1 {
+			declared = "(" + declared + ")"
+		}
+	}
+	if declared == "" {
+		dl.License, dl.Source, dl.Score = lc.classifyDir(dir)
+		return true
+	}
+	lc.fromMetadata(dl, declared, filepath.Join(dir, "composer.json"), dir)
+	return true
+}
+
+// cargoLicense reads the license or license-file of an unpacked crate's Cargo.toml
+func (lc *licenseClassifier) cargoLicense(dl *DependencyLicense, dir string) bool {
+	content, err := os.ReadFile(filepath.Join(dir, "Cargo.toml"))
+	if err != nil {
+		return false
+	}
+	doc, err := parseTOML(content)
+	if err != nil {
+		return false
+	}
+	pkg := asTable(doc["package"])
+	if v := tomlString(pkg, "version"); v != "" {
+		dl.Version = v
+	}
+	switch {
+	case tomlString(pkg, "license") != "":
+		// Cargo accepts the deprecated "/" separator for OR
+		declared := strings.ReplaceAll(tomlString(pkg, "license"), "/", " OR ")
+		lc.fromMetadata(dl, declared, filepath.Join(dir, "Cargo.toml"), dir)
+	case tomlString(pkg, "license-file") != "":
+		match := lc.classifyFile(filepath.Join(dir, filepath.FromSlash(tomlString(pkg, "license-file"))))
+		dl.License, dl.Source, dl.Score = match.License, match.Path, match.Score
+	default:
+		dl.License, dl.Source, dl.Score = lc.classifyDir(dir)
+	}
+	return true
+}
+
+// pythonVirtualenvs are the directory names searched for virtual environments
+var pythonVirtualenvs = []string{".venv", "venv", "env"}
+
+// pythonLicense reads the METADATA of a distribution installed in a virtual
+// environment of dir: License-Expression, then license classifiers, then
+// the License field, then the license files
+func (lc *licenseClassifier) pythonLicense(dl *DependencyLicense, dir string) bool {
+	var distInfo string
+	for _, venv := range pythonVirtualenvs {
+		pattern := filepath.Join(dir, venv, "lib", "python*", "site-packages", "*.dist-info")
+		windows := filepath.Join(dir, venv, "Lib", "site-packages", "*.dist-info")
+		matches, _ := filepath.Glob(pattern)
+		more, _ := filepath.Glob(windows)
+		for _, match := range append(matches, more...) {
+			base := strings.TrimSuffix(filepath.Base(match), ".dist-info")
+			distName, distVersion, _ := strings.Cut(base, "-")
+			if normalizePythonName(distName) == normalizePythonName(dl.Package) {
+				distInfo = match
+				dl.Version = distVersion
+				break
+			}
+		}
+		if distInfo != "" {
+			break
+		}
+	}
+	if distInfo == "" {
+		return false
+	}
+
+	metadata := filepath.Join(distInfo, "METADATA")
+	content, err := os.ReadFile(metadata)
+	if err != nil {
+		dl.License, dl.Source, dl.Score = lc.classifyDir(distInfo)
+		return true
+	}
+	var expression, field string
+	var classifiers []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break // the description follows the headers
+		}
+		key, value, _ := strings.Cut(line, ":")
+		value = strings.TrimSpace(value)
+		switch key {
+		case "License-Expression":
+			expression = value
+		case "License":
+			field = value
+		case "Classifier":
+			if parts := strings.Split(value, " :: "); len(parts) > 2 && parts[0] == "License" {
+				if id := licenseAliases[strings.ToLower(parts[len(parts)-1])]; id != "" {
+					classifiers = append(classifiers, id)
+				}
+			}
+		}
+	}
+
+	switch {
+	case expression != "":
+		lc.fromMetadata(dl, expression, metadata, distInfo)
+	case len(classifiers) > 0:
+		dl.License, dl.Source = strings.Join(classifiers, " OR "), metadata
+		if len(classifiers) > 1 {
+			dl.License = "(" + dl.License + ")"
+		}
+	case field != "" && field != "UNKNOWN":
+		lc.fromMetadata(dl, field, metadata, distInfo)
+	default:
+		dl.License, dl.Source, dl.Score = lc.classifyDir(distInfo)
+		if dl.License == "" {
+			dl.License, dl.Source, dl.Score = lc.classifyDir(filepath.Join(distInfo, "licenses"))
+		}
+	}
+	return true
+}
+
+// goModCache returns the Go module cache directory
+func goModCache() string {
+	if cache := os.Getenv("GOMODCACHE"); cache != "" {
+		return cache
+	}
+	if gopath := filepath.SplitList(os.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "go", "pkg", "mod")
+	}
+	return ""
+}
+
+// escapeGoModulePath escapes a module path or version for the module cache,
+// which writes upper-case letters as "!" and the lower-case letter
+func escapeGoModulePath(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return filepath.FromSlash(path.Clean(b.String()))
+}
+
+// cargoHome returns the Cargo home directory holding the registry sources
+func cargoHome() string {
+	if home := os.Getenv("CARGO_HOME"); home != "" {
+		return home
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".cargo")
+	}
+	return ""
+}
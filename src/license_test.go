@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLicense(t *testing.T) {
+	tests := map[string]string{
+		"MIT":                                  "MIT",
+		"The MIT License":                      "MIT",
+		"Apache Software License":              "Apache-2.0",
+		"(MIT OR Apache-2.0)":                  "(MIT OR Apache-2.0)",
+		"GPL-2.0 WITH Classpath-exception-2.0": "GPL-2.0 WITH Classpath-exception-2.0",
+		"BSD License":                          "",
+		"see LICENSE.txt":                      "",
+	}
+	for declared, want := range tests {
+		if got := normalizeLicense(declared); got != want {
+			t.Errorf("normalizeLicense(%q) = %q, want %q", declared, got, want)
+		}
+	}
+}
+
+func TestLicensePolicyFlag(t *testing.T) {
+	open := newLicensePolicy(nil, []string{"AGPL-3.0"})
+	strict := newLicensePolicy([]string{"MIT", "Apache-2.0"}, nil)
+	tests := []struct {
+		policy     licensePolicy
+		expression string
+		want       string
+	}{
+		{open, "MIT", ""},
+		{open, "GPL-3.0-or-later", "copyleft"},
+		{open, "MIT OR GPL-3.0", ""},
+		{open, "MIT AND GPL-3.0", "copyleft"},
+		{open, "(MIT OR AGPL-3.0) AND AGPL-3.0", "denied"},
+		{open, "", "unknown"},
+		{strict, "Apache-2.0 WITH LLVM-exception", ""},
+		{strict, "ISC", "not-allowed"},
+		{strict, "ISC OR mit", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.flag(tt.expression); got != tt.want {
+			t.Errorf("flag(%q) = %q, want %q", tt.expression, got, tt.want)
+		}
+	}
+}
+
+func TestDetectLicenses(t *testing.T) {
+	mit, err := licenseTextsFS.ReadFile("licenses/MIT.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, root := scanFixture(t, map[string]string{
+		"LICENSE":                            strings.Replace(string(mit), "<year> <copyright holders>", "2024 Example Corp", 1),
+		"package.json":                       `{"name": "app", "dependencies": {"left-pad": "^1.3.0", "gpl-lib": "^2.0.0", "missing": "^1.0.0"}}`,
+		"node_modules/left-pad/package.json": `{"name": "left-pad", "version": "1.3.0", "license": "WTFPL OR MIT"}`,
+		"node_modules/gpl-lib/package.json":  `{"name": "gpl-lib", "version": "2.1.0", "licenses": [{"type": "GPL-3.0"}]}`,
+	})
+	c.DetectLicenses()
+
+	files := c.Analysis.Dependencies.LicenseFiles
+	if len(files) != 1 || relativePath(root, files[0].Path) != "LICENSE" || files[0].License != "MIT" || files[0].Flag != "" {
+		t.Fatalf("license files = %+v, want one MIT file", files)
+	}
+
+	got := make(map[string]string)
+	for _, dl := range c.Analysis.Dependencies.Licenses {
+		got[dl.Package] = dl.Version + " " + dl.License + " " + dl.Flag
+	}
+	want := map[string]string{
+		"left-pad": "1.3.0 WTFPL OR MIT ",
+		"gpl-lib":  "2.1.0 GPL-3.0 copyleft",
+		"missing":  "^1.0.0  unknown",
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %q, want %q", name, got[name], w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("licenses = %v, want %v", got, want)
+	}
+}
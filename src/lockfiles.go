@@ -0,0 +1,946 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lockfile is a parsed lockfile and the package versions it pins
+type Lockfile struct {
+	Path     string           `json:"path"`
+	Manager  string           `json:"manager"`
+	Version  string           `json:"lockfile_version,omitempty"`
+	Packages []*LockedPackage `json:"packages"`
+	Error    string           `json:"error,omitempty"`
+
+	// importers holds, per project directory relative to the lockfile ("."
+	// for the root), the dependency specs the lockfile was generated from
+	importers map[string]map[string]string
+	// importerVersions holds the version each importer dependency resolved to (pnpm)
+	importerVersions map[string]map[string]string
+	// specs maps "name@range" entries to packages (yarn)
+	specs map[string]*LockedPackage
+	// paths maps install locations such as "node_modules/a" to packages (npm)
+	paths map[string]*LockedPackage
+}
+
+// LockedPackage is one resolved package in a lockfile
+type LockedPackage struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Integrity string `json:"integrity,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Dev       bool   `json:"dev,omitempty"`
+
+	// IDs ("name@version") of the packages this one depends on
+	Requires []string `json:"requires,omitempty"`
+}
+
+// ID identifies a locked package as name@version
+func (p *LockedPackage) ID() string {
+	return p.Name + "@" + p.Version
+}
+
+// require records a dependency edge, ignoring duplicates
+func (p *LockedPackage) require(dep *LockedPackage) {
+	if dep == nil || dep == p {
+		return
+	}
+	id := dep.ID()
+	for _, existing := range p.Requires {
+		if existing == id {
+			return
+		}
+	}
+	p.Requires = append(p.Requires, id)
+}
+
+// Lock statuses reported on manifests
+const (
+	lockStatusLocked    = "locked"
+	lockStatusMissing   = "missing"
+	lockStatusOutOfSync = "out-of-sync"
+)
+
+// lockFiles maps lockfile names to the tool that writes them
+var lockFiles = map[string]string{
+	"package-lock.json":   "npm",
+	"npm-shrinkwrap.json": "npm",
+	"yarn.lock":           "yarn",
+	"pnpm-lock.yaml":      "pnpm",
+	"go.sum":              "go modules",
+	"Cargo.lock":          "cargo",
+	"poetry.lock":         "poetry",
+	"Pipfile.lock":        "pipenv",
+	"Gemfile.lock":        "bundler",
+}
+
+// manifestLockfiles lists the lockfiles that can pin each manager's manifests.
+// Workspace-aware tools keep a single lockfile at the workspace root, so for
+// those parent directories are searched as well.
+var manifestLockfiles = map[string]struct {
+	names   []string
+	inherit bool
+}{
+	"npm":        {[]string{"npm-shrinkwrap.json", "package-lock.json", "yarn.lock", "pnpm-lock.yaml"}, true},
+	"go modules": {[]string{"go.sum"}, false},
+	"cargo":      {[]string{"Cargo.lock"}, true},
+	"pipenv":     {[]string{"Pipfile.lock"}, false},
+	"poetry":     {[]string{"poetry.lock"}, false},
+	"bundler":    {[]string{"Gemfile.lock"}, false},
+}
+
+// analyzeLockfiles parses every lockfile and attaches resolved versions to the
+// dependencies of the manifests they belong to
+func (c *Crawler) analyzeLockfiles() {
+	var lockPaths []string
+	for _, files := range c.Analysis.FilesByType {
+		for _, file := range files {
+			if _, exists := lockFiles[file.Name]; exists {
+				lockPaths = append(lockPaths, file.Path)
+			}
+		}
+	}
+	sort.Strings(lockPaths)
+
+	byPath := make(map[string]*Lockfile)
+	for _, path := range lockPaths {
+		lock := parseLockfile(path, lockFiles[filepath.Base(path)])
+		c.Analysis.Dependencies.Lockfiles = append(c.Analysis.Dependencies.Lockfiles, lock)
+		byPath[path] = lock
+	}
+
+	for _, m := range c.Analysis.Dependencies.Manifests {
+		lock, importer := c.linkLockfile(m, byPath)
+		if lock != nil && lock.Error == "" {
+			tree := buildDependencyTree(m, lock, importer)
+			c.Analysis.Dependencies.DependencyTrees = append(c.Analysis.Dependencies.DependencyTrees, tree)
+		}
+	}
+}
+
+// parseLockfile reads a lockfile; parse failures are recorded on the result
+func parseLockfile(path, manager string) *Lockfile {
+	lock := &Lockfile{Path: path, Manager: manager, Packages: []*LockedPackage{}}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		lock.Error = err.Error()
+		return lock
+	}
+
+	switch filepath.Base(path) {
+	case "package-lock.json", "npm-shrinkwrap.json":
+		err = parsePackageLock(content, lock)
+	case "yarn.lock":
+		err = parseYarnLock(content, lock)
+	case "pnpm-lock.yaml":
+		err = parsePnpmLock(content, lock)
+	case "go.sum":
+		err = parseGoSum(content, lock)
+	case "Cargo.lock":
+		err = parseCargoLock(content, lock)
+	case "poetry.lock":
+		err = parsePoetryLock(content, lock)
+	case "Pipfile.lock":
+		err = parsePipfileLock(content, lock)
+	case "Gemfile.lock":
+		err = parseGemfileLock(content, lock)
+	}
+	if err != nil {
+		lock.Error = err.Error()
+	}
+	return lock
+}
+
+// linkLockfile finds the lockfile for a manifest, attaches resolved versions and
+// records whether the lockfile is missing or out of sync with the manifest. It
+// returns the lockfile and the manifest's importer path within it.
+func (c *Crawler) linkLockfile(m *Manifest, byPath map[string]*Lockfile) (*Lockfile, string) {
+	rule, ok := manifestLockfiles[m.Manager]
+	if !ok || len(m.Dependencies) == 0 {
+		return nil, ""
+	}
+
+	manifestDir := filepath.Dir(m.Path)
+	var lock *Lockfile
+	var importer string
+
+search:
+	for dir := manifestDir; ; dir = filepath.Dir(dir) {
+		for _, name := range rule.names {
+			candidate, exists := byPath[filepath.Join(dir, name)]
+			if !exists {
+				continue
+			}
+			rel := filepath.ToSlash(relativePath(dir, manifestDir))
+			// A lockfile further up only counts if it covers this project
+			if dir != manifestDir && candidate.importers != nil && candidate.importers[rel] == nil {
+				continue
+			}
+			lock, importer = candidate, rel
+			break search
+		}
+		if !rule.inherit || dir == c.Config.TargetPath || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	if lock == nil {
+		m.LockStatus = lockStatusMissing
+		return nil, ""
+	}
+
+	m.Lockfile = lock.Path
+	m.LockStatus = lockStatusLocked
+	if lock.Error != "" {
+		m.LockStatus = lockStatusOutOfSync
+		m.LockIssues = append(m.LockIssues, "lockfile could not be parsed: "+lock.Error)
+		return lock, importer
+	}
+
+	declared := lock.importers[importer]
+	seen := make(map[string]bool)
+
+	for _, dep := range m.Dependencies {
+		name := lock.normalize(dep.Name)
+		seen[name] = true
+
+		if pkg := lock.lookup(importer, dep); pkg != nil {
+			dep.Resolved = pkg.Version
+			dep.Integrity = pkg.Integrity
+		} else if dep.Group == "peerDependencies" || dep.Source == "workspace" {
+			// Peers are provided by the consumer and workspace packages are
+			// linked, so neither needs a lockfile entry
+			continue
+		} else {
+			m.LockIssues = append(m.LockIssues, fmt.Sprintf("%s is not in the lockfile", dep.Name))
+			continue
+		}
+
+		if declared == nil {
+			continue
+		}
+		if spec, exists := declared[name]; !exists {
+			if dep.Group == "peerDependencies" {
+				continue
+			}
+			m.LockIssues = append(m.LockIssues, fmt.Sprintf("%s is not recorded as a dependency in the lockfile", dep.Name))
+		} else if spec != "" && strings.TrimSpace(spec) != strings.TrimSpace(dep.Version) {
+			m.LockIssues = append(m.LockIssues, fmt.Sprintf("%s is declared as %q but the lockfile was generated from %q", dep.Name, dep.Version, spec))
+		}
+	}
+
+	for _, name := range sortedKeys(declared) {
+		if !seen[name] {
+			m.LockIssues = append(m.LockIssues, fmt.Sprintf("%s is in the lockfile but no longer declared", name))
+		}
+	}
+
+	if len(m.LockIssues) > 0 {
+		m.LockStatus = lockStatusOutOfSync
+	}
+	return lock, importer
+}
+
+// normalize canonicalises a package name the way the lockfile's tool compares them
+func (l *Lockfile) normalize(name string) string {
+	switch l.Manager {
+	case "poetry", "pipenv":
+		return normalizePythonName(name)
+	}
+	return name
+}
+
+// lookup finds the locked package a declared dependency resolved to
+func (l *Lockfile) lookup(importer string, dep *Dependency) *LockedPackage {
+	name := l.normalize(dep.Name)
+
+	switch {
+	case l.paths != nil:
+		// Node resolution: the importer's own node_modules first, then the root
+		if importer != "." {
+			if pkg := l.paths[importer+"/node_modules/"+name]; pkg != nil {
+				return pkg
+			}
+		}
+		return l.paths["node_modules/"+name]
+
+	case l.specs != nil:
+		for _, key := range []string{name + "@" + dep.Version, name + "@npm:" + dep.Version} {
+			if pkg := l.specs[key]; pkg != nil {
+				return pkg
+			}
+		}
+		return nil
+
+	case l.importerVersions != nil:
+		version := l.importerVersions[importer][name]
+		if version == "" {
+			return nil
+		}
+		for _, pkg := range l.Packages {
+			if pkg.Name == name && pkg.Version == version {
+				return pkg
+			}
+		}
+		// Linked workspace packages have no package entry
+		return &LockedPackage{Name: name, Version: version}
+	}
+
+	var candidates []*LockedPackage
+	for _, pkg := range l.Packages {
+		if l.normalize(pkg.Name) == name {
+			candidates = append(candidates, pkg)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// Several versions may be locked (Cargo, go.sum); prefer the declared one
+	declared := strings.TrimLeft(dep.Version, "=^~v ")
+	for _, pkg := range candidates {
+		if strings.TrimPrefix(pkg.Version, "v") == declared {
+			return pkg
+		}
+	}
+	// A partial version such as Cargo's "1" or "1.0" covers whole components:
+	// 1.0.2 but not 10.0.1
+	for _, pkg := range candidates {
+		if declared != "" && strings.HasPrefix(strings.TrimPrefix(pkg.Version, "v"), declared+".") {
+			return pkg
+		}
+	}
+	return candidates[0]
+}
+
+// parsePackageLock parses package-lock.json and npm-shrinkwrap.json (lockfile versions 1-3)
+func parsePackageLock(content []byte, lock *Lockfile) error {
+	type v1Dep struct {
+		Version      string            `json:"version"`
+		Resolved     string            `json:"resolved"`
+		Integrity    string            `json:"integrity"`
+		Dev          bool              `json:"dev"`
+		Requires     map[string]string `json:"requires"`
+		Dependencies json.RawMessage   `json:"dependencies"`
+	}
+	var data struct {
+		LockfileVersion int `json:"lockfileVersion"`
+		Packages        map[string]struct {
+			Name                 string            `json:"name"`
+			Version              string            `json:"version"`
+			Resolved             string            `json:"resolved"`
+			Integrity            string            `json:"integrity"`
+			Dev                  bool              `json:"dev"`
+			Link                 bool              `json:"link"`
+			Dependencies         map[string]string `json:"dependencies"`
+			DevDependencies      map[string]string `json:"devDependencies"`
+			OptionalDependencies map[string]string `json:"optionalDependencies"`
+			PeerDependencies     map[string]string `json:"peerDependencies"`
+		} `json:"packages"`
+		Dependencies json.RawMessage `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return err
+	}
+
+	lock.Version = fmt.Sprint(data.LockfileVersion)
+	lock.paths = make(map[string]*LockedPackage)
+	requires := make(map[string][]string) // install path -> required package names
+
+	if len(data.Packages) > 0 {
+		lock.importers = make(map[string]map[string]string)
+		for _, path := range sortedKeys(data.Packages) {
+			entry := data.Packages[path]
+			idx := strings.LastIndex(path, "node_modules/")
+			if idx < 0 {
+				// The root project or a workspace package
+				importer := path
+				if importer == "" {
+					importer = "."
+				}
+				specs := make(map[string]string)
+				for _, group := range []map[string]string{entry.Dependencies, entry.DevDependencies, entry.OptionalDependencies, entry.PeerDependencies} {
+					for name, spec := range group {
+						specs[name] = spec
+					}
+				}
+				lock.importers[importer] = specs
+				continue
+			}
+
+			name := entry.Name
+			if name == "" {
+				name = path[idx+len("node_modules/"):]
+			}
+			pkg := &LockedPackage{
+				Name:      name,
+				Version:   entry.Version,
+				Integrity: entry.Integrity,
+				Source:    entry.Resolved,
+				Dev:       entry.Dev,
+			}
+			if entry.Link {
+				// Symlink to a workspace package; its version lives in the target entry
+				if target, ok := data.Packages[entry.Resolved]; ok {
+					pkg.Version = target.Version
+				}
+			}
+			lock.Packages = append(lock.Packages, pkg)
+			lock.paths[path] = pkg
+			for _, group := range []map[string]string{entry.Dependencies, entry.OptionalDependencies, entry.PeerDependencies} {
+				requires[path] = append(requires[path], sortedKeys(group)...)
+			}
+		}
+		lock.linkNodeModules(requires)
+		return nil
+	}
+
+	// Lockfile v1 nests dependencies the way they're laid out in node_modules
+	var walk func(raw json.RawMessage, prefix string) error
+	walk = func(raw json.RawMessage, prefix string) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		var deps map[string]v1Dep
+		if err := json.Unmarshal(raw, &deps); err != nil {
+			return err
+		}
+		for _, name := range sortedKeys(deps) {
+			dep := deps[name]
+			path := prefix + "node_modules/" + name
+			pkg := &LockedPackage{
+				Name:      name,
+				Version:   dep.Version,
+				Integrity: dep.Integrity,
+				Source:    dep.Resolved,
+				Dev:       dep.Dev,
+			}
+			lock.Packages = append(lock.Packages, pkg)
+			lock.paths[path] = pkg
+			requires[path] = sortedKeys(dep.Requires)
+			if err := walk(dep.Dependencies, path+"/"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(data.Dependencies, ""); err != nil {
+		return err
+	}
+	lock.linkNodeModules(requires)
+	return nil
+}
+
+// linkNodeModules resolves required package names the way Node does, looking in
+// the requiring package's own node_modules and then in each enclosing one
+func (l *Lockfile) linkNodeModules(requires map[string][]string) {
+	for _, path := range sortedKeys(requires) {
+		pkg := l.paths[path]
+		for _, name := range requires[path] {
+			for dir := path; ; {
+				key := "node_modules/" + name
+				if dir != "" {
+					key = dir + "/" + key
+				}
+				if target := l.paths[key]; target != nil {
+					pkg.require(target)
+					break
+				}
+				if dir == "" {
+					break
+				}
+				if idx := strings.LastIndex(dir, "/node_modules/"); idx >= 0 {
+					dir = dir[:idx]
+				} else {
+					dir = ""
+				}
+			}
+		}
+	}
+}
+
+var yarnKeySplit = regexp.MustCompile(`\s*,\s*`)
+
+// parseYarnLock parses yarn.lock in both the classic (v1) and the YAML-based berry format
+func parseYarnLock(content []byte, lock *Lockfile) error {
+	lock.specs = make(map[string]*LockedPackage)
+	requires := make(map[*LockedPackage][]string) // package -> "name@range" keys
+
+	if bytes.Contains(content, []byte("\n__metadata:")) || bytes.HasPrefix(content, []byte("__metadata:")) {
+		doc, err := parseYAML(content)
+		if err != nil {
+			return err
+		}
+		entries, _ := doc.(map[string]interface{})
+		lock.Version = yamlString(entries["__metadata"], "version")
+		for _, key := range sortedKeys(entries) {
+			if key == "__metadata" {
+				continue
+			}
+			entry := entries[key]
+			pkg := &LockedPackage{
+				Version:   yamlString(entry, "version"),
+				Integrity: yamlString(entry, "checksum"),
+				Source:    yamlString(entry, "resolution"),
+			}
+			lock.addYarnEntry(key, pkg)
+			for _, group := range []string{"dependencies", "optionalDependencies", "peerDependencies"} {
+				deps := yamlMap(entry, group)
+				for _, name := range sortedKeys(deps) {
+					spec, _ := deps[name].(string)
+					requires[pkg] = append(requires[pkg], name+"@"+spec)
+				}
+			}
+		}
+		lock.linkYarnSpecs(requires)
+		return nil
+	}
+
+	lock.Version = "1"
+	var pkg *LockedPackage
+	inDeps := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case indent == 0 && strings.HasSuffix(trimmed, ":"):
+			pkg = &LockedPackage{}
+			lock.addYarnEntry(strings.TrimSuffix(trimmed, ":"), pkg)
+		case indent == 2 && pkg != nil && strings.HasSuffix(trimmed, ":"):
+			inDeps = trimmed == "dependencies:" || trimmed == "optionalDependencies:"
+		case indent == 4 && pkg != nil && inDeps:
+			name, spec, _ := strings.Cut(trimmed, " ")
+			requires[pkg] = append(requires[pkg], strings.Trim(name, `"`)+"@"+strings.Trim(strings.TrimSpace(spec), `"`))
+		case indent == 2 && pkg != nil:
+			inDeps = false
+			field, value, _ := strings.Cut(trimmed, " ")
+			value = strings.Trim(strings.TrimSpace(value), `"`)
+			switch field {
+			case "version":
+				pkg.Version = value
+			case "resolved":
+				pkg.Source = value
+			case "integrity":
+				pkg.Integrity = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	lock.linkYarnSpecs(requires)
+	return nil
+}
+
+// linkYarnSpecs resolves "name@range" requirements through the lockfile's entry keys
+func (l *Lockfile) linkYarnSpecs(requires map[*LockedPackage][]string) {
+	for pkg, keys := range requires {
+		for _, key := range keys {
+			target := l.specs[key]
+			if target == nil {
+				// Berry keys carry the protocol that classic ranges omit
+				name := yarnPackageName(key)
+				target = l.specs[name+"@npm:"+strings.TrimPrefix(key, name+"@")]
+			}
+			pkg.require(target)
+		}
+	}
+}
+
+// addYarnEntry registers a yarn.lock entry under each of its "name@range" keys
+func (l *Lockfile) addYarnEntry(header string, pkg *LockedPackage) {
+	for _, key := range yarnKeySplit.Split(header, -1) {
+		key = strings.Trim(key, `"`)
+		if key == "" {
+			continue
+		}
+		if pkg.Name == "" {
+			pkg.Name = yarnPackageName(key)
+		}
+		l.specs[key] = pkg
+	}
+	if pkg.Name != "" {
+		l.Packages = append(l.Packages, pkg)
+	}
+}
+
+// yarnPackageName strips the range from a "name@range" key, keeping scopes intact
+func yarnPackageName(key string) string {
+	if idx := strings.LastIndex(key, "@"); idx > 0 {
+		return key[:idx]
+	}
+	return key
+}
+
+// parsePnpmLock parses pnpm-lock.yaml (lockfile versions 5.x, 6.x and 9.x)
+func parsePnpmLock(content []byte, lock *Lockfile) error {
+	doc, err := parseYAML(content)
+	if err != nil {
+		return err
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("pnpm-lock.yaml: unexpected document")
+	}
+
+	lock.Version = fmt.Sprint(root["lockfileVersion"])
+	lock.importers = make(map[string]map[string]string)
+	lock.importerVersions = make(map[string]map[string]string)
+
+	importers, _ := root["importers"].(map[string]interface{})
+	if importers == nil {
+		// Single-project lockfiles keep the root importer at the top level
+		importers = map[string]interface{}{".": root}
+	}
+	for _, path := range sortedKeys(importers) {
+		specs := make(map[string]string)
+		versions := make(map[string]string)
+		importer, _ := importers[path].(map[string]interface{})
+		specifiers, _ := importer["specifiers"].(map[string]interface{})
+
+		for _, group := range []string{"dependencies", "devDependencies", "optionalDependencies"} {
+			deps, _ := importer[group].(map[string]interface{})
+			for name, value := range deps {
+				switch v := value.(type) {
+				case string: // lockfile v5
+					versions[name] = pnpmVersion(v)
+					specs[name], _ = specifiers[name].(string)
+				case map[string]interface{}:
+					versions[name] = pnpmVersion(yamlString(v, "version"))
+					specs[name] = yamlString(v, "specifier")
+				}
+			}
+		}
+		lock.importers[path] = specs
+		lock.importerVersions[path] = versions
+	}
+
+	byID := make(map[string]*LockedPackage)
+	packages, _ := root["packages"].(map[string]interface{})
+	for _, key := range sortedKeys(packages) {
+		entry := packages[key]
+		name, version := pnpmPackageKey(key)
+		if v := yamlString(entry, "version"); v != "" {
+			version = v
+		}
+		if n := yamlString(entry, "name"); n != "" {
+			name = n
+		}
+		pkg := &LockedPackage{
+			Name:      name,
+			Version:   version,
+			Integrity: yamlString(yamlMap(entry, "resolution"), "integrity"),
+			Source:    yamlString(yamlMap(entry, "resolution"), "tarball"),
+			Dev:       yamlString(entry, "dev") == "true",
+		}
+		lock.Packages = append(lock.Packages, pkg)
+		byID[pkg.ID()] = pkg
+	}
+
+	// Lockfile v9 moved dependency edges from packages into snapshots
+	snapshots, _ := root["snapshots"].(map[string]interface{})
+	for _, section := range []map[string]interface{}{packages, snapshots} {
+		for _, key := range sortedKeys(section) {
+			name, version := pnpmPackageKey(key)
+			pkg := byID[name+"@"+version]
+			if pkg == nil {
+				continue
+			}
+			for _, group := range []string{"dependencies", "optionalDependencies"} {
+				deps := yamlMap(section[key], group)
+				for _, depName := range sortedKeys(deps) {
+					depVersion, _ := deps[depName].(string)
+					pkg.require(byID[depName+"@"+pnpmVersion(depVersion)])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// pnpmVersion strips peer-dependency suffixes such as "1.0.0(react@18.2.0)" or "1.0.0_react@18.2.0"
+func pnpmVersion(version string) string {
+	if idx := strings.IndexAny(version, "(_"); idx > 0 {
+		return version[:idx]
+	}
+	return version
+}
+
+// pnpmPackageKey splits "/name/1.0.0_peer@2.0.0" (v5), "/name@1.0.0" (v6) or "name@1.0.0" (v9)
+func pnpmPackageKey(key string) (string, string) {
+	key = strings.TrimPrefix(key, "/")
+	if idx := strings.Index(key, "("); idx > 0 {
+		key = key[:idx]
+	}
+	if key == "" {
+		return "", ""
+	}
+	slashes := 0 // in the package name
+	if strings.HasPrefix(key, "@") {
+		slashes = 1
+	}
+
+	// v6 and v9: the first "@" after the scope ends the name
+	if idx := strings.Index(key[1:], "@") + 1; idx > 0 && strings.Count(key[:idx], "/") == slashes {
+		return key[:idx], pnpmVersion(key[idx+1:])
+	}
+	// v5: the segment after the name is the version, with any peer suffix
+	if parts := strings.SplitN(key, "/", slashes+2); len(parts) == slashes+2 {
+		return strings.Join(parts[:slashes+1], "/"), pnpmVersion(parts[slashes+1])
+	}
+	return key, ""
+}
+
+// parseGoSum parses go.sum; each module version gets the h1 hash of its zip
+func parseGoSum(content []byte, lock *Lockfile) error {
+	byID := make(map[string]*LockedPackage)
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		module, version, hash := fields[0], fields[1], fields[2]
+		goModOnly := strings.HasSuffix(version, "/go.mod")
+		version = strings.TrimSuffix(version, "/go.mod")
+
+		pkg, exists := byID[module+"@"+version]
+		if !exists {
+			pkg = &LockedPackage{Name: module, Version: version}
+			byID[module+"@"+version] = pkg
+			lock.Packages = append(lock.Packages, pkg)
+		}
+		if !goModOnly {
+			pkg.Integrity = hash
+		}
+	}
+	return nil
+}
+
+// parseCargoLock parses Cargo.lock, including the v1 [metadata] checksum table
+func parseCargoLock(content []byte, lock *Lockfile) error {
+	doc, err := parseTOML(content)
+	if err != nil {
+		return err
+	}
+	if v, ok := doc["version"].(int64); ok {
+		lock.Version = fmt.Sprint(v)
+	}
+
+	checksums := tomlTable(doc, "metadata")
+	requires := make(map[*LockedPackage][]string)
+	for _, entry := range tomlTables(doc, "package") {
+		pkg := &LockedPackage{
+			Name:      tomlString(entry, "name"),
+			Version:   tomlString(entry, "version"),
+			Integrity: tomlString(entry, "checksum"),
+			Source:    tomlString(entry, "source"),
+		}
+		if pkg.Integrity == "" && checksums != nil {
+			key := fmt.Sprintf("checksum %s %s (%s)", pkg.Name, pkg.Version, pkg.Source)
+			pkg.Integrity = tomlString(checksums, key)
+		}
+		lock.Packages = append(lock.Packages, pkg)
+		requires[pkg] = tomlStrings(entry, "dependencies")
+	}
+
+	// Entries are "name", "name version" or "name version (source)"; the
+	// version is only spelled out when several versions are locked
+	for pkg, deps := range requires {
+		for _, dep := range deps {
+			fields := strings.Fields(dep)
+			if len(fields) >= 2 {
+				pkg.require(lock.find(fields[0], fields[1]))
+			} else if len(fields) == 1 {
+				pkg.require(lock.find(fields[0], ""))
+			}
+		}
+	}
+	return nil
+}
+
+// find returns the locked package with the given name and, if set, version
+func (l *Lockfile) find(name, version string) *LockedPackage {
+	name = l.normalize(name)
+	for _, pkg := range l.Packages {
+		if l.normalize(pkg.Name) == name && (version == "" || pkg.Version == version) {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// parsePoetryLock parses poetry.lock (lock versions 1.x and 2.x)
+func parsePoetryLock(content []byte, lock *Lockfile) error {
+	doc, err := parseTOML(content)
+	if err != nil {
+		return err
+	}
+	lock.Version = tomlString(tomlTable(doc, "metadata"), "lock-version")
+	legacyFiles := tomlTable(doc, "metadata", "files")
+	requires := make(map[*LockedPackage][]string)
+
+	for _, entry := range tomlTables(doc, "package") {
+		pkg := &LockedPackage{
+			Name:    tomlString(entry, "name"),
+			Version: tomlString(entry, "version"),
+			Dev:     tomlString(entry, "category") == "dev",
+		}
+		if source := tomlTable(entry, "source"); source != nil {
+			pkg.Source = tomlString(source, "url")
+		}
+
+		files := tomlTables(entry, "files")
+		if len(files) == 0 && legacyFiles != nil {
+			files = tomlTables(legacyFiles, pkg.Name)
+		}
+		if len(files) > 0 {
+			pkg.Integrity = tomlString(files[0], "hash")
+		}
+		lock.Packages = append(lock.Packages, pkg)
+		requires[pkg] = sortedKeys(tomlTable(entry, "dependencies"))
+	}
+
+	// Poetry locks a single version per package, so names are enough
+	for pkg, deps := range requires {
+		for _, dep := range deps {
+			pkg.require(lock.find(dep, ""))
+		}
+	}
+	return nil
+}
+
+// parsePipfileLock parses Pipfile.lock
+func parsePipfileLock(content []byte, lock *Lockfile) error {
+	type entry struct {
+		Version string   `json:"version"`
+		Hashes  []string `json:"hashes"`
+		Git     string   `json:"git"`
+		Ref     string   `json:"ref"`
+		Path    string   `json:"path"`
+	}
+	var data struct {
+		Meta struct {
+			PipfileSpec int `json:"pipfile-spec"`
+		} `json:"_meta"`
+		Default map[string]entry `json:"default"`
+		Develop map[string]entry `json:"develop"`
+	}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return err
+	}
+	lock.Version = fmt.Sprint(data.Meta.PipfileSpec)
+
+	for _, section := range []struct {
+		deps map[string]entry
+		dev  bool
+	}{{data.Default, false}, {data.Develop, true}} {
+		for _, name := range sortedKeys(section.deps) {
+			e := section.deps[name]
+			pkg := &LockedPackage{
+				Name:    name,
+				Version: strings.TrimPrefix(e.Version, "=="),
+				Dev:     section.dev,
+			}
+			if len(e.Hashes) > 0 {
+				pkg.Integrity = e.Hashes[0]
+			}
+			switch {
+			case e.Git != "":
+				pkg.Source = e.Git
+				if pkg.Version == "" {
+					pkg.Version = e.Ref
+				}
+			case e.Path != "":
+				pkg.Source = e.Path
+			}
+			lock.Packages = append(lock.Packages, pkg)
+		}
+	}
+	return nil
+}
+
+var gemSpecLine = regexp.MustCompile(`^([^\s(]+)(?: \(([^)]*)\))?(!)?(?: (sha256=\S+))?$`)
+
+// parseGemfileLock parses Gemfile.lock: GEM/GIT/PATH specs, DEPENDENCIES and CHECKSUMS
+func parseGemfileLock(content []byte, lock *Lockfile) error {
+	declared := make(map[string]string)
+	lock.importers = map[string]map[string]string{".": declared}
+	checksums := make(map[string]string)
+	requires := make(map[*LockedPackage][]string)
+	var current *LockedPackage
+
+	section, remote := "", ""
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		trimmed := strings.TrimSpace(line)
+
+		if indent == 0 {
+			section, remote = trimmed, ""
+			continue
+		}
+
+		switch section {
+		case "GEM", "GIT", "PATH":
+			if indent == 2 && strings.HasPrefix(trimmed, "remote:") {
+				remote = strings.TrimSpace(strings.TrimPrefix(trimmed, "remote:"))
+			}
+			m := gemSpecLine.FindStringSubmatch(trimmed)
+			if m == nil {
+				continue
+			}
+			if indent == 6 && current != nil {
+				requires[current] = append(requires[current], m[1])
+				continue
+			}
+			if indent != 4 {
+				continue
+			}
+			// Platform-specific gems are listed as "nokogiri (1.15.0-x86_64-linux)"
+			version := m[2]
+			pkg := &LockedPackage{Name: m[1], Version: version, Source: remote}
+			lock.Packages = append(lock.Packages, pkg)
+			current = pkg
+
+		case "DEPENDENCIES":
+			if m := gemSpecLine.FindStringSubmatch(trimmed); m != nil {
+				declared[m[1]] = m[2]
+			}
+
+		case "CHECKSUMS":
+			if m := gemSpecLine.FindStringSubmatch(trimmed); m != nil && m[4] != "" {
+				checksums[m[1]+" "+m[2]] = m[4]
+			}
+
+		case "BUNDLED WITH":
+			lock.Version = trimmed
+		}
+	}
+
+	for _, pkg := range lock.Packages {
+		pkg.Integrity = checksums[pkg.Name+" "+pkg.Version]
+		for _, dep := range requires[pkg] {
+			pkg.require(lock.find(dep, ""))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// lockedGraph flattens a lockfile into "name@version -> dep, dep" lines
+func lockedGraph(lock *Lockfile) []string {
+	var lines []string
+	for _, pkg := range lock.Packages {
+		line := pkg.ID()
+		if len(pkg.Requires) > 0 {
+			deps := append([]string(nil), pkg.Requires...)
+			sort.Strings(deps)
+			line += " -> " + strings.Join(deps, ", ")
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func TestParseLockfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func([]byte, *Lockfile) error
+		content string
+		version string
+		graph   []string
+	}{
+		{
+			"package-lock v1", parsePackageLock, `{
+  "lockfileVersion": 1,
+  "dependencies": {
+    "a": {"version": "1.0.0", "requires": {"b": "^2.0.0"},
+      "dependencies": {"b": {"version": "2.1.0"}}},
+    "b": {"version": "1.0.0", "dev": true}
+  }
+}`,
+			"1", []string{"a@1.0.0 -> b@2.1.0", "b@1.0.0", "b@2.1.0"},
+		},
+		{
+			"package-lock v2", parsePackageLock, `{
+  "lockfileVersion": 2,
+  "packages": {
+    "": {"dependencies": {"a": "^1.0.0"}},
+    "node_modules/a": {"version": "1.0.0", "dependencies": {"b": "^1.0.0"}},
+    "node_modules/b": {"version": "1.0.0"}
+  },
+  "dependencies": {"a": {"version": "1.0.0"}}
+}`,
+			"2", []string{"a@1.0.0 -> b@1.0.0", "b@1.0.0"},
+		},
+		{
+			"package-lock v3 with workspaces", parsePackageLock, `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"workspaces": ["pkgs/*"]},
+    "pkgs/app": {"name": "app", "version": "0.1.0", "dependencies": {"lib": "*"}},
+    "node_modules/app": {"resolved": "pkgs/app", "link": true},
+    "node_modules/lib": {"version": "3.0.0"},
+    "pkgs/app/node_modules/lib": {"version": "2.0.0"}
+  }
+}`,
+			"3", []string{"app@0.1.0", "lib@2.0.0", "lib@3.0.0"},
+		},
+		{
+			"yarn v1", parseYarnLock, `# yarn lockfile v1
+
+"a@^1.0.0", a@~1.0.0:
+  version "1.0.2"
+  resolved "https://registry.yarnpkg.com/a/-/a-1.0.2.tgz"
+  dependencies:
+    "@scope/b" "^2.0.0"
+
+"@scope/b@^2.0.0":
+  version "2.3.0"
+`,
+			"1", []string{"@scope/b@2.3.0", "a@1.0.2 -> @scope/b@2.3.0"},
+		},
+		{
+			"yarn berry", parseYarnLock, `__metadata:
+  version: 8
+  cacheKey: 10
+
+"a@npm:^1.0.0":
+  version: 1.0.2
+  resolution: "a@npm:1.0.2"
+  dependencies:
+    b: "npm:^2.0.0"
+  checksum: 10/abc
+
+"b@npm:^2.0.0, b@npm:^2.1.0":
+  version: 2.3.0
+  resolution: "b@npm:2.3.0"
+`,
+			"8", []string{"a@1.0.2 -> b@2.3.0", "b@2.3.0"},
+		},
+		{
+			"pnpm v5", parsePnpmLock, `lockfileVersion: 5.4
+
+specifiers:
+  react-dom: ^18.2.0
+
+dependencies:
+  react-dom: 18.2.0_react@18.2.0
+
+packages:
+
+  /react-dom/18.2.0_react@18.2.0:
+    resolution: {integrity: sha512-dom}
+    peerDependencies:
+      react: ^18.2.0
+    dependencies:
+      loose-envify: 1.4.0
+      react: 18.2.0
+
+  /react/18.2.0:
+    resolution: {integrity: sha512-react}
+    dependencies:
+      loose-envify: 1.4.0
+
+  /loose-envify/1.4.0:
+    resolution: {integrity: sha512-le}
+
+  /@babel/core/7.0.0:
+    resolution: {integrity: sha512-babel}
+    dev: true
+`,
+			"5.4", []string{"@babel/core@7.0.0", "loose-envify@1.4.0", "react-dom@18.2.0 -> loose-envify@1.4.0, react@18.2.0", "react@18.2.0 -> loose-envify@1.4.0"},
+		},
+		{
+			"pnpm v6", parsePnpmLock, `lockfileVersion: '6.0'
+
+dependencies:
+  react-dom:
+    specifier: ^18.2.0
+    version: 18.2.0(react@18.2.0)
+
+packages:
+
+  /react-dom@18.2.0(react@18.2.0):
+    resolution: {integrity: sha512-dom}
+    dependencies:
+      react: 18.2.0
+
+  /react@18.2.0:
+    resolution: {integrity: sha512-react}
+
+  /@babel/core@7.0.0:
+    resolution: {integrity: sha512-babel}
+`,
+			"6.0", []string{"@babel/core@7.0.0", "react-dom@18.2.0 -> react@18.2.0", "react@18.2.0"},
+		},
+		{
+			"pnpm v9", parsePnpmLock, `lockfileVersion: '9.0'
+
+importers:
+
+  .:
+    dependencies:
+      react-dom:
+        specifier: ^18.2.0
+        version: 18.2.0(react@18.2.0)
+
+packages:
+
+  '@babel/core@7.0.0':
+    resolution: {integrity: sha512-babel}
+
+  react-dom@18.2.0:
+    resolution: {integrity: sha512-dom}
+
+  react@18.2.0:
+    resolution: {integrity: sha512-react}
+
+snapshots:
+
+  '@babel/core@7.0.0': {}
+
+  react-dom@18.2.0(react@18.2.0):
+    dependencies:
+      react: 18.2.0
+
+  react@18.2.0: {}
+`,
+			"9.0", []string{"@babel/core@7.0.0", "react-dom@18.2.0 -> react@18.2.0", "react@18.2.0"},
+		},
+		{
+			"go.sum", parseGoSum, `golang.org/x/mod v0.14.0 h1:mod=
+golang.org/x/mod v0.14.0/go.mod h1:gomod=
+golang.org/x/text v0.3.0/go.mod h1:only=
+`,
+			"", []string{"golang.org/x/mod@v0.14.0", "golang.org/x/text@v0.3.0"},
+		},
+		{
+			"Cargo.lock v1", parseCargoLock, `[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = [
+ "serde 1.0.0 (registry+https://github.com/rust-lang/crates.io-index)",
+]
+
+[[package]]
+name = "serde"
+version = "1.0.0"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[metadata]
+"checksum serde 1.0.0 (registry+https://github.com/rust-lang/crates.io-index)" = "abc"
+`,
+			"", []string{"app@0.1.0 -> serde@1.0.0", "serde@1.0.0"},
+		},
+		{
+			"Cargo.lock v3", parseCargoLock, `version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = ["rand 0.8.5", "serde"]
+
+[[package]]
+name = "rand"
+version = "0.7.3"
+
+[[package]]
+name = "rand"
+version = "0.8.5"
+
+[[package]]
+name = "serde"
+version = "1.0.0"
+checksum = "abc"
+`,
+			"3", []string{"app@0.1.0 -> rand@0.8.5, serde@1.0.0", "rand@0.7.3", "rand@0.8.5", "serde@1.0.0"},
+		},
+		{
+			"poetry.lock 1.1", parsePoetryLock, `[[package]]
+name = "requests"
+version = "2.31.0"
+category = "main"
+
+[package.dependencies]
+urllib3 = ">=1.21.1,<3"
+
+[[package]]
+name = "urllib3"
+version = "2.0.0"
+category = "dev"
+
+[metadata]
+lock-version = "1.1"
+
+[metadata.files]
+requests = [
+    {file = "requests-2.31.0.tar.gz", hash = "sha256:abc"},
+]
+`,
+			"1.1", []string{"requests@2.31.0 -> urllib3@2.0.0", "urllib3@2.0.0"},
+		},
+		{
+			"poetry.lock 2.0", parsePoetryLock, `[[package]]
+name = "requests"
+version = "2.31.0"
+files = [
+    {file = "requests-2.31.0.tar.gz", hash = "sha256:abc"},
+]
+
+[metadata]
+lock-version = "2.0"
+`,
+			"2.0", []string{"requests@2.31.0"},
+		},
+		{
+			"Pipfile.lock", parsePipfileLock, `{
+  "_meta": {"pipfile-spec": 6},
+  "default": {"requests": {"version": "==2.31.0", "hashes": ["sha256:abc"]}},
+  "develop": {"tool": {"git": "https://github.com/org/tool.git", "ref": "abc123"}}
+}`,
+			"6", []string{"requests@2.31.0", "tool@abc123"},
+		},
+		{
+			"Gemfile.lock", parseGemfileLock, `GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (3.0.8)
+    rails (7.1.0)
+      rack (>= 2.2.4)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  rails (~> 7.1)
+
+CHECKSUMS
+  rack (3.0.8) sha256=abc
+
+BUNDLED WITH
+   2.5.3
+`,
+			"2.5.3", []string{"rack@3.0.8", "rails@7.1.0 -> rack@3.0.8"},
+		},
+	}
+	for _, tt := range tests {
+		lock := &Lockfile{Packages: []*LockedPackage{}}
+		if err := tt.parse([]byte(tt.content), lock); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if lock.Version != tt.version {
+			t.Errorf("%s: version %q, want %q", tt.name, lock.Version, tt.version)
+		}
+		if got := lockedGraph(lock); !reflect.DeepEqual(got, tt.graph) {
+			t.Errorf("%s: packages\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.graph, "\n"))
+		}
+	}
+}
+
+func TestPnpmPackageKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		name    string
+		version string
+	}{
+		{"/lodash/4.17.21", "lodash", "4.17.21"},
+		{"/react-dom/18.2.0_react@18.2.0", "react-dom", "18.2.0"},
+		{"/@babel/core/7.0.0", "@babel/core", "7.0.0"},
+		{"/@types/node/20.1.0_typescript@5.0.0", "@types/node", "20.1.0"},
+		{"/react-dom@18.2.0(react@18.2.0)", "react-dom", "18.2.0"},
+		{"/@babel/core@7.0.0", "@babel/core", "7.0.0"},
+		{"@babel/core@7.0.0", "@babel/core", "7.0.0"},
+		{"lodash@4.17.21", "lodash", "4.17.21"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		name, version := pnpmPackageKey(tt.key)
+		if name != tt.name || version != tt.version {
+			t.Errorf("pnpmPackageKey(%q) = %q, %q, want %q, %q", tt.key, name, version, tt.name, tt.version)
+		}
+	}
+}
+
+func TestLockfileIntegrity(t *testing.T) {
+	lock := &Lockfile{Packages: []*LockedPackage{}}
+	content := "golang.org/x/mod v0.14.0/go.mod h1:gomod=\ngolang.org/x/mod v0.14.0 h1:mod=\n"
+	if err := parseGoSum([]byte(content), lock); err != nil {
+		t.Fatal(err)
+	}
+	if got := lock.Packages[0].Integrity; got != "h1:mod=" {
+		t.Errorf("go.sum integrity %q, want the module zip hash", got)
+	}
+}
+
+func TestLinkLockfile(t *testing.T) {
+	lock := `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"dependencies": {"a": "^1.0.0", "gone": "^1.0.0"}},
+    "node_modules/a": {"version": "1.2.0", "integrity": "sha512-a"},
+    "node_modules/gone": {"version": "1.0.0"}
+  }
+}`
+	c, root := scanFixture(t, map[string]string{
+		"web/package.json":      `{"dependencies": {"a": "^1.1.0", "b": "^2.0.0"}}`,
+		"web/package-lock.json": lock,
+		"ok/package.json":       `{"dependencies": {"a": "^1.0.0", "gone": "^1.0.0"}}`,
+		"ok/package-lock.json":  lock,
+		"bare/package.json":     `{"dependencies": {"a": "^1.0.0"}}`,
+	})
+
+	if m := findManifest(c, root, "bare/package.json"); m.LockStatus != lockStatusMissing {
+		t.Errorf("bare: lock status %q, want %q", m.LockStatus, lockStatusMissing)
+	}
+
+	m := findManifest(c, root, "ok/package.json")
+	if m.LockStatus != lockStatusLocked || len(m.LockIssues) > 0 {
+		t.Errorf("ok: lock status %q %v, want %q", m.LockStatus, m.LockIssues, lockStatusLocked)
+	}
+	if dep := m.Dependencies[0]; dep.Resolved != "1.2.0" || dep.Integrity != "sha512-a" {
+		t.Errorf("ok: a resolved to %q (%q), want 1.2.0 (sha512-a)", dep.Resolved, dep.Integrity)
+	}
+
+	m = findManifest(c, root, "web/package.json")
+	want := []string{
+		`a is declared as "^1.1.0" but the lockfile was generated from "^1.0.0"`,
+		"b is not in the lockfile",
+		"gone is in the lockfile but no longer declared",
+	}
+	if m.LockStatus != lockStatusOutOfSync || !reflect.DeepEqual(m.LockIssues, want) {
+		t.Errorf("web: lock status %q, issues\n%s\nwant %q with\n%s", m.LockStatus,
+			strings.Join(m.LockIssues, "\n"), lockStatusOutOfSync, strings.Join(want, "\n"))
+	}
+}
+
+func TestLockfileLookup(t *testing.T) {
+	lock := &Lockfile{Manager: "cargo", Packages: []*LockedPackage{
+		{Name: "serde", Version: "10.0.1"},
+		{Name: "serde", Version: "1.0.2"},
+		{Name: "serde", Version: "1.10.0"},
+	}}
+	tests := map[string]string{
+		"1":      "1.0.2",
+		"1.10":   "1.10.0",
+		"=1.0.2": "1.0.2",
+		"10":     "10.0.1",
+	}
+	for declared, want := range tests {
+		pkg := lock.lookup(".", &Dependency{Name: "serde", Version: declared})
+		if pkg == nil || pkg.Version != want {
+			t.Errorf("lookup(serde %q) = %+v, want %s", declared, pkg, want)
+		}
+	}
+}
+
+func FuzzParseLockfiles(f *testing.F) {
+	parsers := []func([]byte, *Lockfile) error{
+		parsePackageLock, parseYarnLock, parsePnpmLock, parseGoSum,
+		parseCargoLock, parsePoetryLock, parsePipfileLock, parseGemfileLock,
+	}
+	for _, seed := range []string{
+		`{"lockfileVersion": 3, "packages": {"node_modules/a": {"version": "1.0.0"}}}`,
+		"a@^1.0.0:\n  version \"1.0.0\"\n  dependencies:\n    b \"^1\"\n",
+		"__metadata:\n  version: 8\n\"a@npm:^1\":\n  version: 1.0.0\n",
+		"lockfileVersion: 5.4\npackages:\n  /a/1.0.0_b@2.0.0:\n    dependencies: {b: 2.0.0}\n",
+		"[[package]]\nname = \"a\"\nversion = \"1\"\ndependencies = [\"b\"]\n",
+		"GEM\n  specs:\n    a (1.0)\n      b\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, content []byte) {
+		for _, parse := range parsers {
+			parse(content, &Lockfile{Packages: []*LockedPackage{}})
+		}
+	})
+}
@@ -19,8 +19,18 @@ func main() {
 	excludeDirs := flag.String("exclude", ".git,node_modules,vendor,.dist,build,target,.venv,__pycache__", "Comma-separated list of directories to exclude")
 	generateViz := flag.Bool("viz", true, "Generate HTML visualization")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging")
+	topN := flag.Int("top", 10, "Number of files to list per metric (largest, longest)")
 	showVersion := flag.Bool("version", false, "Show version information")
-
+	osvDB := flag.String("osv-db", "", "OSV database export (directory or zip) to match dependencies against")
+	licenseAllow := flag.String("license-allow", "", "Comma-separated SPDX licenses dependencies may use; others are flagged")
+	licenseDeny := flag.String("license-deny", "", "Comma-separated SPDX licenses to flag as denied")
+	sbomFormat := flag.String("sbom", "", "Also write an SBOM: cyclonedx-json or spdx-json")
+	rulesFile := flag.String("rules", "", "Architecture rules file (YAML); exit with status 1 if any import violates it")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [why <package>]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	if *showVersion {
@@ -38,11 +48,9 @@ func main() {
 		log.Fatalf("Path does not exist: %s", absPath)
 	}
 
-	fmt.Printf("🔍 Code Crawler v%s\n", version)
-	fmt.Printf("📂 Analyzing: %s\n", absPath)
-	fmt.Println(strings.Repeat("=", 60))
-
-	startTime := time.Now()
+	if _, ok := sbomFormats[*sbomFormat]; *sbomFormat != "" && !ok {
+		log.Fatalf("Unknown SBOM format %q (want cyclonedx-json or spdx-json)", *sbomFormat)
+	}
 
 	// Initialize crawler
 	config := &CrawlerConfig{
@@ -50,10 +58,38 @@ func main() {
 		OutputPath:  *outputPath,
 		ExcludeDirs: parseExcludeDirs(*excludeDirs),
 		Verbose:     *verbose,
+		TopN:        *topN,
+		OSVDatabase: *osvDB,
+
+		LicenseAllow: parseList(*licenseAllow),
+		LicenseDeny:  parseList(*licenseDeny),
+
+		SBOMFormat: *sbomFormat,
+		RulesFile:  *rulesFile,
 	}
 
 	crawler := NewCrawler(config)
 
+	// "why <package>" explains how a package ends up in the dependency trees
+	if flag.Arg(0) == "why" {
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		if err := crawler.Scan(); err != nil {
+			log.Fatalf("Scan failed: %v", err)
+		}
+		crawler.AnalyzeDependencies()
+		printWhy(flag.Arg(1), crawler.Why(flag.Arg(1)))
+		os.Exit(0)
+	}
+
+	fmt.Printf("🔍 Code Crawler v%s\n", version)
+	fmt.Printf("📂 Analyzing: %s\n", absPath)
+	fmt.Println(strings.Repeat("=", 60))
+
+	startTime := time.Now()
+
 	// Scan the repository
 	fmt.Println("\n📊 Scanning repository structure...")
 	if err := crawler.Scan(); err != nil {
@@ -64,12 +100,56 @@ func main() {
 	fmt.Println("\n🔗 Analyzing dependencies...")
 	crawler.AnalyzeDependencies()
 
+	// Match dependencies against known vulnerabilities
+	if config.OSVDatabase != "" {
+		fmt.Println("\n🛡️  Matching vulnerabilities...")
+		if err := crawler.MatchVulnerabilities(); err != nil {
+			log.Fatalf("Failed to load OSV database: %v", err)
+		}
+		fmt.Printf("   %d vulnerable dependency versions found\n", len(crawler.Analysis.Dependencies.Vulnerabilities))
+	}
+
+	// Check imports against the architecture rules
+	if config.RulesFile != "" {
+		fmt.Println("\n🏛️  Checking architecture rules...")
+		if err := crawler.CheckArchitecture(); err != nil {
+			log.Fatalf("Failed to load architecture rules: %v", err)
+		}
+		violations := crawler.Analysis.Dependencies.Architecture.Violations
+		for _, v := range violations {
+			fmt.Printf("   %s:%d: %s → %s (%s)\n", relativePath(absPath, v.File), v.Line, v.From, v.To, v.Import)
+		}
+		fmt.Printf("   %d architecture violations\n", len(violations))
+	}
+
+	// Detect repository and dependency licenses
+	fmt.Println("\n📜 Detecting licenses...")
+	crawler.DetectLicenses()
+	flagged := 0
+	for _, l := range crawler.Analysis.Dependencies.Licenses {
+		if l.Flag != "" {
+			flagged++
+		}
+	}
+	fmt.Printf("   %d license files, %d dependencies (%d flagged)\n",
+		len(crawler.Analysis.Dependencies.LicenseFiles), len(crawler.Analysis.Dependencies.Licenses), flagged)
+
 	// Save analysis data
 	fmt.Println("\n💾 Saving analysis data...")
 	if err := crawler.SaveData(); err != nil {
 		log.Fatalf("Failed to save data: %v", err)
 	}
 
+	// Write the software bill of materials
+	if config.SBOMFormat != "" {
+		fmt.Println("\n📋 Writing SBOM...")
+		sbomPath, err := crawler.WriteSBOM()
+		if err != nil {
+			log.Fatalf("Failed to write SBOM: %v", err)
+		}
+		fmt.Printf("   SBOM saved to: %s\n", sbomPath)
+	}
+
 	// Generate visualizations
 	if *generateViz {
 		fmt.Println("\n🎨 Generating visualizations...")
@@ -86,6 +166,22 @@ func main() {
 		vizPath := filepath.Join(*outputPath, "visualization.html")
 		fmt.Printf("🌐 Open visualization: file://%s\n", vizPath)
 	}
+
+	// Fail the run so the rules can gate merges
+	if arch := crawler.Analysis.Dependencies.Architecture; arch != nil && len(arch.Violations) > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseList splits a comma-separated flag value, dropping empty entries
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 func parseExcludeDirs(excludeStr string) []string {
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// pomProject is the subset of a Maven POM the crawler understands
+type pomProject struct {
+	GroupID    string          `xml:"groupId"`
+	ArtifactID string          `xml:"artifactId"`
+	Version    string          `xml:"version"`
+	Packaging  string          `xml:"packaging"`
+	Parent     *pomParent      `xml:"parent"`
+	Properties pomProperties   `xml:"properties"`
+	Managed    []pomDependency `xml:"dependencyManagement>dependencies>dependency"`
+	Deps       []pomDependency `xml:"dependencies>dependency"`
+	Modules    []string        `xml:"modules>module"`
+
+	parent *Manifest         // parent POM when it is part of the repo
+	props  map[string]string // effective properties, filled in by mavenProperties
+}
+
+// pomParent is the <parent> reference of a POM
+type pomParent struct {
+	GroupID      string  `xml:"groupId"`
+	ArtifactID   string  `xml:"artifactId"`
+	Version      string  `xml:"version"`
+	RelativePath *string `xml:"relativePath"` // nil means the default ../pom.xml
+}
+
+// pomDependency is a <dependency> element
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+	Type       string `xml:"type"`
+	Optional   string `xml:"optional"`
+}
+
+// pomProperties collects the free-form children of <properties>
+type pomProperties struct {
+	Entries []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+// pomPlaceholder matches ${property} references
+var pomPlaceholder = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// parsePomXML parses pom.xml; versions are resolved later by resolveMavenProjects
+func (c *Crawler) parsePomXML(content []byte, m *Manifest) {
+	var pom pomProject
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.CharsetReader = pomCharsetReader
+	if err := decoder.Decode(&pom); err != nil {
+		m.Error = err.Error()
+		return
+	}
+	m.pom = &pom
+}
+
+// pomCharsetReader decodes the single-byte encodings POMs declare besides
+// UTF-8; windows-1252 is read as ISO-8859-1, which differs only in punctuation
+func pomCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1", "windows-1252", "cp1252":
+		content, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(content))
+		for i, b := range content {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", charset)
+}
+
+// resolveMavenProjects resolves parents, properties and managed versions of
+// every pom.xml against the other POMs in the repo and records the reactor graph
+func (c *Crawler) resolveMavenProjects() {
+	byPath := make(map[string]*Manifest)
+	byCoords := make(map[string]*Manifest)
+	var poms []*Manifest
+	for _, m := range c.Analysis.Dependencies.Manifests {
+		if m.pom == nil {
+			continue
+		}
+		poms = append(poms, m)
+		byPath[m.Path] = m
+		byCoords[pomGroupID(m.pom)+":"+strings.TrimSpace(m.pom.ArtifactID)] = m
+	}
+
+	for _, m := range poms {
+		m.pom.parent = findParentPom(m, byPath, byCoords)
+	}
+
+	for _, m := range poms {
+		props := mavenProperties(m)
+		m.Name = interpolatePom(pomGroupID(m.pom)+":"+strings.TrimSpace(m.pom.ArtifactID), props)
+		m.Version = props["project.version"]
+
+		managed := mavenManaged(m, byCoords, make(map[*Manifest]bool))
+		for _, d := range inheritedPomDependencies(m, props) {
+			name := interpolatePom(strings.TrimSpace(d.GroupID)+":"+strings.TrimSpace(d.ArtifactID), props)
+			version := interpolatePom(strings.TrimSpace(d.Version), props)
+			scope := interpolatePom(strings.TrimSpace(d.Scope), props)
+			if md, ok := managed[name]; ok {
+				if version == "" {
+					version = md.Version
+				}
+				if scope == "" {
+					scope = md.Scope
+				}
+			}
+			if scope == "" {
+				scope = "compile"
+			}
+
+			dep := m.addDependency(scope, name, version)
+			dep.Optional = interpolatePom(strings.TrimSpace(d.Optional), props) == "true"
+			if byCoords[name] != nil {
+				dep.Source = "workspace"
+			}
+		}
+	}
+
+	c.recordMavenReactors(poms, byPath, byCoords)
+}
+
+// inheritedPomDependencies returns the dependencies of a POM followed by those
+// of its parents that it does not declare itself, as Maven merges them into
+// the effective model
+func inheritedPomDependencies(m *Manifest, props map[string]string) []pomDependency {
+	var deps []pomDependency
+	declared := make(map[string]bool)
+	seen := make(map[*Manifest]bool)
+	for p := m; p != nil && !seen[p]; p = p.pom.parent {
+		seen[p] = true
+		// A POM may list an artifact more than once (a jar and its test-jar);
+		// only a nearer POM's declaration hides a parent's
+		own := make(map[string]bool)
+		for _, d := range p.pom.Deps {
+			key := interpolatePom(strings.TrimSpace(d.GroupID)+":"+strings.TrimSpace(d.ArtifactID), props)
+			if !declared[key] {
+				own[key] = true
+				deps = append(deps, d)
+			}
+		}
+		for key := range own {
+			declared[key] = true
+		}
+	}
+	return deps
+}
+
+// pomGroupID returns the groupId of a POM, inherited from <parent> when omitted
+func pomGroupID(pom *pomProject) string {
+	if g := strings.TrimSpace(pom.GroupID); g != "" || pom.Parent == nil {
+		return g
+	}
+	return strings.TrimSpace(pom.Parent.GroupID)
+}
+
+// findParentPom locates the parent POM in the repo, first by relativePath and
+// then by coordinates
+func findParentPom(m *Manifest, byPath, byCoords map[string]*Manifest) *Manifest {
+	ref := m.pom.Parent
+	if ref == nil {
+		return nil
+	}
+	artifact := strings.TrimSpace(ref.ArtifactID)
+
+	rel := "../pom.xml"
+	if ref.RelativePath != nil {
+		rel = strings.TrimSpace(*ref.RelativePath)
+	}
+	if rel != "" {
+		path := filepath.Join(filepath.Dir(m.Path), filepath.FromSlash(rel))
+		if !strings.HasSuffix(path, ".xml") {
+			path = filepath.Join(path, "pom.xml")
+		}
+		if p := byPath[path]; p != nil && p != m && strings.TrimSpace(p.pom.ArtifactID) == artifact {
+			return p
+		}
+	}
+
+	if p := byCoords[strings.TrimSpace(ref.GroupID)+":"+artifact]; p != m {
+		return p
+	}
+	return nil
+}
+
+// mavenProperties returns the effective properties of a POM: its parent's,
+// overridden by its own, plus the project.* built-ins. As in Maven, inherited
+// values are interpolated against the child.
+func mavenProperties(m *Manifest) map[string]string {
+	if m.pom.props != nil {
+		return m.pom.props
+	}
+	props := rawPomProperties(m, make(map[*Manifest]bool))
+	for k, v := range props {
+		props[k] = interpolatePom(v, props)
+	}
+	m.pom.props = props
+	return props
+}
+
+// rawPomProperties merges the uninterpolated properties along the parent chain
+func rawPomProperties(m *Manifest, seen map[*Manifest]bool) map[string]string {
+	props := make(map[string]string)
+	seen[m] = true
+	pom := m.pom
+	if pom.parent != nil && !seen[pom.parent] {
+		for k, v := range rawPomProperties(pom.parent, seen) {
+			props[k] = v
+		}
+	}
+	for _, entry := range pom.Properties.Entries {
+		props[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+
+	version := strings.TrimSpace(pom.Version)
+	if pom.Parent != nil {
+		props["project.parent.groupId"] = strings.TrimSpace(pom.Parent.GroupID)
+		props["project.parent.artifactId"] = strings.TrimSpace(pom.Parent.ArtifactID)
+		props["project.parent.version"] = strings.TrimSpace(pom.Parent.Version)
+		if version == "" {
+			version = props["project.parent.version"]
+		}
+	}
+	props["project.groupId"] = pomGroupID(pom)
+	props["project.artifactId"] = strings.TrimSpace(pom.ArtifactID)
+	props["project.version"] = version
+	for k, v := range props {
+		if strings.HasPrefix(k, "project.") {
+			props["pom."+strings.TrimPrefix(k, "project.")] = v
+		}
+	}
+	return props
+}
+
+// interpolatePom substitutes ${property} placeholders, leaving unknown ones intact
+func interpolatePom(s string, props map[string]string) string {
+	for i := 0; i < 10 && strings.Contains(s, "${"); i++ {
+		next := pomPlaceholder.ReplaceAllStringFunc(s, func(ref string) string {
+			if v, ok := props[ref[2:len(ref)-1]]; ok {
+				return v
+			}
+			return ref
+		})
+		if next == s {
+			break
+		}
+		s = next
+	}
+	return s
+}
+
+// mavenManaged returns the dependencyManagement entries that apply to a POM,
+// keyed by groupId:artifactId. The POM's own entries win over its parent's, and
+// imported BOMs found in the repo are merged in.
+func mavenManaged(m *Manifest, byCoords map[string]*Manifest, visiting map[*Manifest]bool) map[string]pomDependency {
+	managed := make(map[string]pomDependency)
+	if visiting[m] {
+		return managed
+	}
+	visiting[m] = true
+
+	props := mavenProperties(m)
+	var boms []*Manifest
+	chain := make(map[*Manifest]bool)
+	for p := m; p != nil && !chain[p]; p = p.pom.parent {
+		chain[p] = true
+		for _, d := range p.pom.Managed {
+			key := interpolatePom(strings.TrimSpace(d.GroupID)+":"+strings.TrimSpace(d.ArtifactID), props)
+			resolved := pomDependency{
+				Version: interpolatePom(strings.TrimSpace(d.Version), props),
+				Scope:   interpolatePom(strings.TrimSpace(d.Scope), props),
+			}
+			if resolved.Scope == "import" {
+				if bom := byCoords[key]; bom != nil {
+					boms = append(boms, bom)
+				}
+				continue
+			}
+			if _, seen := managed[key]; !seen {
+				managed[key] = resolved
+			}
+		}
+	}
+
+	for _, bom := range boms {
+		for key, d := range mavenManaged(bom, byCoords, visiting) {
+			if _, seen := managed[key]; !seen {
+				managed[key] = d
+			}
+		}
+	}
+	return managed
+}
+
+// recordMavenReactors records each multi-module build as a workspace whose
+// edges are the dependencies and parent links between its modules
+func (c *Crawler) recordMavenReactors(poms []*Manifest, byPath, byCoords map[string]*Manifest) {
+	modules := make(map[*Manifest][]*Manifest)
+	isModule := make(map[*Manifest]bool)
+	for _, m := range poms {
+		for _, module := range m.pom.Modules {
+			path := filepath.Join(filepath.Dir(m.Path), filepath.FromSlash(strings.TrimSpace(module)))
+			if !strings.HasSuffix(path, ".xml") {
+				path = filepath.Join(path, "pom.xml")
+			}
+			if child := byPath[path]; child != nil && child != m {
+				modules[m] = append(modules[m], child)
+				isModule[child] = true
+			}
+		}
+	}
+
+	for _, root := range poms {
+		if len(modules[root]) == 0 || isModule[root] {
+			continue
+		}
+
+		members := []*Manifest{root}
+		inReactor := map[*Manifest]bool{root: true}
+		for i := 0; i < len(members); i++ {
+			for _, child := range modules[members[i]] {
+				if !inReactor[child] {
+					inReactor[child] = true
+					members = append(members, child)
+				}
+			}
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].Path < members[j].Path })
+
+		ws := &Workspace{Kind: "maven", Root: root.Path, Name: root.Name, Members: []string{}, Edges: []WorkspaceEdge{}}
+		// Dependencies inherited from the parent can repeat a module's own
+		seen := make(map[WorkspaceEdge]bool)
+		addEdge := func(from, to *Manifest, kind string) {
+			edge := WorkspaceEdge{From: from.Path, To: to.Path, Kind: kind}
+			if to != from && inReactor[to] && !seen[edge] {
+				seen[edge] = true
+				ws.Edges = append(ws.Edges, edge)
+			}
+		}
+		for _, m := range members {
+			ws.Members = append(ws.Members, m.Path)
+			if p := m.pom.parent; p != nil {
+				addEdge(m, p, "parent")
+			}
+			for _, dep := range m.Dependencies {
+				if target := byCoords[dep.Name]; target != nil {
+					addEdge(m, target, dep.Group)
+				}
+			}
+			for _, d := range m.pom.Managed {
+				key := interpolatePom(strings.TrimSpace(d.GroupID)+":"+strings.TrimSpace(d.ArtifactID), m.pom.props)
+				if target := byCoords[key]; target != nil && interpolatePom(strings.TrimSpace(d.Scope), m.pom.props) == "import" {
+					addEdge(m, target, "import")
+				}
+			}
+		}
+		c.Analysis.Dependencies.Workspaces = append(c.Analysis.Dependencies.Workspaces, ws)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// workspaceEdges lists the edges of the workspace rooted at root-relative path
+// as "from -> to (kind)"
+func workspaceEdges(c *Crawler, root, path string) []string {
+	for _, ws := range c.Analysis.Dependencies.Workspaces {
+		if relativePath(root, ws.Root) != path {
+			continue
+		}
+		edges := []string{}
+		for _, e := range ws.Edges {
+			edges = append(edges, relativePath(root, e.From)+" -> "+relativePath(root, e.To)+" ("+e.Kind+")")
+		}
+		return edges
+	}
+	return nil
+}
+
+const parentPom = `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1.0.0</version>
+  <packaging>pom</packaging>
+  <modules>
+    <module>core</module>
+    <module>app</module>
+  </modules>
+  <properties>
+    <guava.version>32.1.0-jre</guava.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.google.guava</groupId>
+        <artifactId>guava</artifactId>
+        <version>${guava.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>junit</groupId>
+        <artifactId>junit</artifactId>
+        <version>4.13.2</version>
+        <scope>test</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>org.slf4j</groupId>
+      <artifactId>slf4j-api</artifactId>
+      <version>2.0.9</version>
+    </dependency>
+  </dependencies>
+</project>`
+
+func TestMavenProjects(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"pom.xml": parentPom,
+		"core/pom.xml": `<project>
+  <parent><groupId>com.example</groupId><artifactId>parent</artifactId><version>1.0.0</version></parent>
+  <artifactId>core</artifactId>
+  <dependencies>
+    <dependency><groupId>com.google.guava</groupId><artifactId>guava</artifactId></dependency>
+    <dependency><groupId>junit</groupId><artifactId>junit</artifactId></dependency>
+  </dependencies>
+</project>`,
+		"app/pom.xml": `<project>
+  <parent><groupId>com.example</groupId><artifactId>parent</artifactId><version>1.0.0</version></parent>
+  <artifactId>app</artifactId>
+  <dependencies>
+    <dependency><groupId>${project.groupId}</groupId><artifactId>core</artifactId><version>${project.version}</version></dependency>
+    <dependency><groupId>org.slf4j</groupId><artifactId>slf4j-api</artifactId><version>2.0.10</version></dependency>
+  </dependencies>
+</project>`,
+	})
+
+	core := findManifest(c, root, "core/pom.xml")
+	if core.Name != "com.example:core" || core.Version != "1.0.0" {
+		t.Errorf("core = %s@%s, want com.example:core@1.0.0 from the parent", core.Name, core.Version)
+	}
+	// Managed versions and scopes fill in, and the parent's dependencies are inherited
+	want := []string{
+		"compile com.google.guava:guava 32.1.0-jre",
+		"test junit:junit 4.13.2",
+		"compile org.slf4j:slf4j-api 2.0.9",
+	}
+	if got := manifestDeps(core); !reflect.DeepEqual(got, want) {
+		t.Errorf("core dependencies = %v, want %v", got, want)
+	}
+
+	// A child's own declaration wins over the inherited one
+	app := findManifest(c, root, "app/pom.xml")
+	want = []string{"compile com.example:core 1.0.0", "compile org.slf4j:slf4j-api 2.0.10"}
+	if got := manifestDeps(app); !reflect.DeepEqual(got, want) {
+		t.Errorf("app dependencies = %v, want %v", got, want)
+	}
+
+	want = []string{
+		"app/pom.xml -> pom.xml (parent)",
+		"app/pom.xml -> core/pom.xml (compile)",
+		"core/pom.xml -> pom.xml (parent)",
+	}
+	if got := workspaceEdges(c, root, "pom.xml"); !reflect.DeepEqual(got, want) {
+		t.Errorf("reactor edges:\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestMavenReactorEdges(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"pom.xml": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1.0.0</version>
+  <packaging>pom</packaging>
+  <modules><module>bom</module><module>core</module><module>app</module></modules>
+  <properties><bom.scope>import</bom.scope></properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency><groupId>com.example</groupId><artifactId>bom</artifactId><version>1.0.0</version><type>pom</type><scope>${bom.scope}</scope></dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+		"bom/pom.xml": `<project>
+  <groupId>com.example</groupId><artifactId>bom</artifactId><version>1.0.0</version><packaging>pom</packaging>
+</project>`,
+		"core/pom.xml": `<project>
+  <parent><groupId>com.example</groupId><artifactId>parent</artifactId><version>1.0.0</version></parent>
+  <artifactId>core</artifactId>
+</project>`,
+		"app/pom.xml": `<project>
+  <parent><groupId>com.example</groupId><artifactId>parent</artifactId><version>1.0.0</version></parent>
+  <artifactId>app</artifactId>
+  <dependencies>
+    <dependency><groupId>com.example</groupId><artifactId>core</artifactId><version>1.0.0</version></dependency>
+    <dependency><groupId>com.example</groupId><artifactId>core</artifactId><version>1.0.0</version><type>test-jar</type></dependency>
+  </dependencies>
+</project>`,
+	})
+
+	app := findManifest(c, root, "app/pom.xml")
+	if got := manifestDeps(app); len(got) != 2 {
+		t.Errorf("app dependencies = %v, want the jar and the test-jar", got)
+	}
+
+	// One edge per kind, and the import scope is read through its property
+	want := []string{
+		"app/pom.xml -> pom.xml (parent)",
+		"app/pom.xml -> core/pom.xml (compile)",
+		"core/pom.xml -> pom.xml (parent)",
+		"pom.xml -> bom/pom.xml (import)",
+	}
+	if got := workspaceEdges(c, root, "pom.xml"); !reflect.DeepEqual(got, want) {
+		t.Errorf("reactor edges:\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParsePomXMLEncoding(t *testing.T) {
+	latin1 := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<project><groupId>com.example</groupId>" +
+		"<artifactId>caf\xe9</artifactId><version>1.0</version></project>"
+	m := &Manifest{}
+	(&Crawler{}).parsePomXML([]byte(latin1), m)
+	if m.Error != "" || m.pom == nil || m.pom.ArtifactID != "café" {
+		t.Errorf("latin-1 POM: error %q, pom %+v", m.Error, m.pom)
+	}
+
+	m = &Manifest{}
+	(&Crawler{}).parsePomXML([]byte("<?xml version=\"1.0\" encoding=\"Shift_JIS\"?>\n<project/>"), m)
+	if m.pom != nil || !strings.Contains(m.Error, "Shift_JIS") {
+		t.Errorf("Shift_JIS POM: error %q, want an unsupported encoding", m.Error)
+	}
+}
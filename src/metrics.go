@@ -0,0 +1,158 @@
+package main
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PackageMetrics are Robert C. Martin's coupling metrics of one package,
+// computed from the internal import graph
+type PackageMetrics struct {
+	Package       string   `json:"package"` // directory, or "<language>:<namespace>" for Java and C#
+	Language      string   `json:"language"`
+	Files         int      `json:"files"`
+	Afferent      int      `json:"afferent"`               // Ca: packages that import this one
+	Efferent      int      `json:"efferent"`               // Ce: packages this one imports
+	Instability   float64  `json:"instability"`            // I = Ce / (Ca + Ce)
+	AbstractTypes int      `json:"abstract_types"`         // interfaces, traits, abstract classes
+	ConcreteTypes int      `json:"concrete_types"`         // every other type declaration
+	Abstractness  *float64 `json:"abstractness,omitempty"` // A = abstract / all types, when the language has abstract types
+	Distance      *float64 `json:"distance,omitempty"`     // D = |A + I - 1|, distance from the main sequence
+}
+
+var (
+	// typeDeclaration matches class-like declarations of Java, C#, TypeScript
+	// and Rust with their modifiers
+	typeDeclaration       = regexp.MustCompile(`(?m)^[ \t]*((?:(?:public|private|protected|internal|static|final|abstract|sealed|non-sealed|partial|readonly|unsafe|new|export|default|declare|strictfp|pub(?:\([\w\s:]*\))?)\s+)*)(class|interface|enum|record|struct|trait|@interface)\s+[A-Za-z_]\w*`)
+	goTypeDeclaration     = regexp.MustCompile(`(?m)^type\s+[A-Za-z_]\w*(?:\[[^\]\n]*\])?\s+(=\s*)?(interface\b)?`)
+	pythonTypeDeclaration = regexp.MustCompile(`(?m)^[ \t]*class\s+\w+\s*(?:\(([^)]*)\))?\s*:`)
+	pythonAbstractBases   = regexp.MustCompile(`\b(ABC|ABCMeta|Protocol)\b`)
+)
+
+// abstractionLanguages are the languages whose type declarations can be abstract
+var abstractionLanguages = setOf("Go", "Java", "C#", "TypeScript", "Rust", "Python")
+
+// countTypes counts the abstract and concrete type declarations of a source file
+func countTypes(lang, content string) (abstract, concrete int) {
+	switch lang {
+	case "Go":
+		for _, m := range goTypeDeclaration.FindAllStringSubmatch(content, -1) {
+			if m[2] != "" {
+				abstract++
+			} else {
+				concrete++
+			}
+		}
+	case "Python":
+		for _, m := range pythonTypeDeclaration.FindAllStringSubmatch(content, -1) {
+			if pythonAbstractBases.MatchString(m[1]) {
+				abstract++
+			} else {
+				concrete++
+			}
+		}
+	default:
+		for _, m := range typeDeclaration.FindAllStringSubmatch(content, -1) {
+			switch {
+			case m[2] == "interface", m[2] == "trait", m[2] == "@interface",
+				strings.Contains(" "+m[1], " abstract "):
+				abstract++
+			default:
+				concrete++
+			}
+		}
+	}
+	return abstract, concrete
+}
+
+// analyzePackageMetrics computes afferent and efferent coupling, instability,
+// abstractness and distance from the main sequence for every package that
+// takes part in the internal import graph
+func (c *Crawler) analyzePackageMetrics() {
+	deps := c.Analysis.Dependencies
+	fileLang := make(map[string]string)
+	for lang, files := range c.Analysis.FilesByType {
+		for _, file := range files {
+			fileLang[file.Path] = lang
+		}
+	}
+	packageOf := func(file string) string {
+		return sourcePackage(file, fileLang[file], deps.FileNamespaces[file])
+	}
+
+	efferent := make(map[string]map[string]bool)
+	afferent := make(map[string]map[string]bool)
+	for _, edge := range deps.ImportEdges {
+		if edge.Class != importInternal || fileLang[edge.To] == "" {
+			continue
+		}
+		from, to := packageOf(edge.From), packageOf(edge.To)
+		if from == to {
+			continue
+		}
+		if efferent[from] == nil {
+			efferent[from] = make(map[string]bool)
+		}
+		if afferent[to] == nil {
+			afferent[to] = make(map[string]bool)
+		}
+		efferent[from][to] = true
+		afferent[to][from] = true
+	}
+
+	metrics := make(map[string]*PackageMetrics)
+	for pkg := range efferent {
+		metrics[pkg] = &PackageMetrics{Package: pkg}
+	}
+	for pkg := range afferent {
+		metrics[pkg] = &PackageMetrics{Package: pkg}
+	}
+
+	languages := make(map[string]map[string]int)
+	for file, lang := range fileLang {
+		m := metrics[packageOf(file)]
+		if m == nil || languageEcosystems[lang] == "" {
+			continue
+		}
+		m.Files++
+		if languages[m.Package] == nil {
+			languages[m.Package] = make(map[string]int)
+		}
+		languages[m.Package][lang]++
+		if !abstractionLanguages[lang] {
+			continue
+		}
+		if content, err := os.ReadFile(file); err == nil {
+			abstract, concrete := countTypes(lang, string(content))
+			m.AbstractTypes += abstract
+			m.ConcreteTypes += concrete
+		}
+	}
+
+	deps.PackageMetrics = []*PackageMetrics{}
+	for _, pkg := range sortedKeys(metrics) {
+		m := metrics[pkg]
+		for _, lang := range sortedKeys(languages[pkg]) {
+			if languages[pkg][lang] > languages[pkg][m.Language] {
+				m.Language = lang
+			}
+		}
+		m.Afferent, m.Efferent = len(afferent[pkg]), len(efferent[pkg])
+		m.Instability = float64(m.Efferent) / float64(m.Afferent+m.Efferent)
+		if types := m.AbstractTypes + m.ConcreteTypes; types > 0 {
+			a := float64(m.AbstractTypes) / float64(types)
+			d := math.Abs(a + m.Instability - 1)
+			m.Abstractness, m.Distance = &a, &d
+		}
+		deps.PackageMetrics = append(deps.PackageMetrics, m)
+	}
+
+	// Furthest from the main sequence first; packages without types last
+	sort.SliceStable(deps.PackageMetrics, func(i, j int) bool {
+		a, b := deps.PackageMetrics[i].Distance, deps.PackageMetrics[j].Distance
+		return a != nil && (b == nil || *a > *b)
+	})
+}
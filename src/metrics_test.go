@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCountTypes(t *testing.T) {
+	tests := []struct {
+		lang, content      string
+		abstract, concrete int
+	}{
+		{"Go", "type Reader interface {\n}\n\ntype File struct{}\ntype ID = string\ntype List[T any] []T\n", 1, 3},
+		{"Java", "public abstract class Shape {}\nfinal class Circle extends Shape {}\npublic interface Drawable {}\n@interface Marker {}\n", 3, 1},
+		{"C#", "public sealed partial class Order {}\ninternal abstract class Base {}\npublic record Money(decimal Amount);\n", 1, 2},
+		{"Rust", "pub trait Store {}\npub(crate) struct Memory;\nenum Kind { A }\n", 1, 2},
+		{"Python", "class Base(ABC):\n    pass\n\nclass Port(typing.Protocol):\n    pass\n\nclass Impl(Base):\n    pass\n", 2, 1},
+		{"TypeScript", "export interface Props {}\nexport default class App {}\nexport abstract class Model {}\n", 2, 1},
+	}
+	for _, tt := range tests {
+		abstract, concrete := countTypes(tt.lang, tt.content)
+		if abstract != tt.abstract || concrete != tt.concrete {
+			t.Errorf("countTypes(%s) = %d abstract, %d concrete, want %d, %d", tt.lang, abstract, concrete, tt.abstract, tt.concrete)
+		}
+	}
+}
+
+func TestPackageMetrics(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"go.mod":         "module example.com/app\n",
+		"main.go":        "package main\n\nimport (\n\t\"example.com/app/core\"\n\t\"example.com/app/store\"\n)\n",
+		"store/store.go": "package store\n\nimport \"example.com/app/core\"\n\ntype Store struct{}\n",
+		"core/core.go":   "package core\n\ntype Repo interface{}\n\ntype User struct{}\n",
+	})
+
+	var got []string
+	for _, m := range c.Analysis.Dependencies.PackageMetrics {
+		desc := fmt.Sprintf("%s %s files=%d ca=%d ce=%d i=%.2f", relativePath(root, m.Package), m.Language, m.Files, m.Afferent, m.Efferent, m.Instability)
+		if m.Distance != nil {
+			desc += fmt.Sprintf(" a=%.2f d=%.2f", *m.Abstractness, *m.Distance)
+		}
+		got = append(got, desc)
+	}
+	want := []string{
+		"core Go files=1 ca=2 ce=0 i=0.00 a=0.50 d=0.50",
+		"store Go files=1 ca=1 ce=1 i=0.50 a=0.00 d=0.50",
+		". Go files=1 ca=0 ce=2 i=1.00",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("metrics = %q, want %q", got, want)
+	}
+}
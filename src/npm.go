@@ -0,0 +1,422 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NodePackage is the package metadata of a package.json beyond its dependencies
+type NodePackage struct {
+	Private     bool              `json:"private,omitempty"`
+	Scripts     map[string]string `json:"scripts,omitempty"`
+	Bin         map[string]string `json:"bin,omitempty"` // command -> file
+	Engines     map[string]string `json:"engines,omitempty"`
+	EntryPoints []string          `json:"entry_points,omitempty"` // main, module, types, browser and exports targets
+	Workspaces  []string          `json:"workspaces,omitempty"`   // workspace package globs
+}
+
+// nodeWorkspaceConfig is a pnpm-workspace.yaml or lerna.json package list
+type nodeWorkspaceConfig struct {
+	kind     string
+	patterns []string
+}
+
+// nodeDependencyGroups are the dependency fields of package.json
+var nodeDependencyGroups = []string{"dependencies", "devDependencies", "peerDependencies", "optionalDependencies"}
+
+// parsePackageJSON parses package.json: dependencies of every kind, scripts,
+// bin, engines, entry points and workspace globs
+func (c *Crawler) parsePackageJSON(content []byte, m *Manifest) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return
+	}
+
+	if name, ok := data["name"].(string); ok {
+		m.Name = name
+	}
+	if version, ok := data["version"].(string); ok {
+		m.Version = version
+	}
+
+	peerMeta, _ := data["peerDependenciesMeta"].(map[string]interface{})
+	for _, group := range nodeDependencyGroups {
+		deps, ok := data[group].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, name := range sortedKeys(deps) {
+			v, ok := deps[name].(string)
+			if !ok {
+				continue
+			}
+			dep := m.addDependency(group, name, v)
+			locateNodeDependency(dep, filepath.Dir(m.Path))
+			switch group {
+			case "optionalDependencies":
+				dep.Optional = true
+			case "peerDependencies":
+				dep.Optional, _ = asTable(peerMeta[name])["optional"].(bool)
+			}
+		}
+	}
+
+	pkg := &NodePackage{
+		Scripts: stringMap(data["scripts"]),
+		Engines: stringMap(data["engines"]),
+	}
+	pkg.Private, _ = data["private"].(bool)
+	switch bin := data["bin"].(type) {
+	case string:
+		pkg.Bin = map[string]string{path.Base(m.Name): bin}
+	case map[string]interface{}:
+		pkg.Bin = stringMap(bin)
+	}
+
+	entries := make(map[string]bool)
+	for _, field := range []string{"main", "module", "types", "typings", "browser"} {
+		if s, ok := data[field].(string); ok && s != "" {
+			entries[path.Clean(s)] = true
+		}
+	}
+	collectExportTargets(data["exports"], entries)
+	pkg.EntryPoints = sortedKeys(entries)
+
+	switch workspaces := data["workspaces"].(type) {
+	case []interface{}:
+		pkg.Workspaces = stringList(workspaces)
+	case map[string]interface{}:
+		// Yarn's { "packages": [...], "nohoist": [...] } form
+		pkg.Workspaces = stringList(workspaces["packages"])
+	}
+	m.Node = pkg
+}
+
+// locateNodeDependency sets the source of workspace:, file:, link:, git and
+// URL specifiers; dir is the directory of the package.json
+func locateNodeDependency(dep *Dependency, dir string) {
+	spec := dep.Version
+	switch {
+	case strings.HasPrefix(spec, "workspace:"):
+		dep.Source = "workspace"
+	case strings.HasPrefix(spec, "file:") || strings.HasPrefix(spec, "link:") ||
+		strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || strings.HasPrefix(spec, "/"):
+		target := strings.TrimPrefix(strings.TrimPrefix(spec, "file:"), "link:")
+		dep.Source = "path"
+		dep.URL = filepath.Join(dir, filepath.FromSlash(target))
+	case strings.HasPrefix(spec, "git") || strings.HasPrefix(spec, "github:") ||
+		strings.HasPrefix(spec, "gitlab:") || strings.HasPrefix(spec, "bitbucket:"):
+		dep.Source = "git"
+		dep.URL = spec
+	case strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://"):
+		dep.Source = "url"
+		dep.URL = spec
+	case !strings.HasPrefix(spec, "npm:") && strings.Count(spec, "/") == 1 && !strings.ContainsAny(spec, " <>=^~*|@"):
+		// GitHub shorthand, user/repo#ref
+		dep.Source = "git"
+		dep.URL = "github:" + spec
+	}
+}
+
+// collectExportTargets adds the file targets of a package.json "exports"
+// value, walking subpath and condition maps
+func collectExportTargets(exports interface{}, entries map[string]bool) {
+	switch v := exports.(type) {
+	case string:
+		entries[path.Clean(v)] = true
+	case []interface{}:
+		for _, item := range v {
+			collectExportTargets(item, entries)
+		}
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			collectExportTargets(v[key], entries)
+		}
+	}
+}
+
+// stringMap returns the string values of a JSON object
+func stringMap(v interface{}) map[string]string {
+	obj, ok := v.(map[string]interface{})
+	if !ok || len(obj) == 0 {
+		return nil
+	}
+	out := make(map[string]string)
+	for key, value := range obj {
+		if s, ok := value.(string); ok {
+			out[key] = s
+		}
+	}
+	return out
+}
+
+// stringList returns the strings of a JSON array
+func stringList(v interface{}) []string {
+	var out []string
+	items, _ := v.([]interface{})
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// resolveNodeWorkspaces discovers the packages of every npm/yarn
+// "workspaces", pnpm-workspace.yaml and lerna.json root and records the
+// dependencies between them
+func (c *Crawler) resolveNodeWorkspaces() {
+	byDir := make(map[string]*Manifest)
+	for _, m := range c.Analysis.Dependencies.Manifests {
+		if m.Node != nil {
+			byDir[filepath.Dir(m.Path)] = m
+		}
+	}
+	if len(byDir) == 0 {
+		return
+	}
+
+	// pnpm and lerna list their packages next to the root package.json
+	configs := make(map[string]nodeWorkspaceConfig)
+	for _, files := range c.Analysis.FilesByType {
+		for _, file := range files {
+			dir := filepath.Dir(file.Path)
+			switch file.Name {
+			case "pnpm-workspace.yaml":
+				content, err := os.ReadFile(file.Path)
+				if err != nil {
+					continue
+				}
+				doc, err := parseYAML(content)
+				if err != nil {
+					continue
+				}
+				m, _ := doc.(map[string]interface{})
+				configs[dir] = nodeWorkspaceConfig{"pnpm", stringList(m["packages"])}
+			case "lerna.json":
+				if configs[dir].kind == "pnpm" {
+					continue
+				}
+				content, err := os.ReadFile(file.Path)
+				if err != nil {
+					continue
+				}
+				var lerna struct {
+					Packages      []string `json:"packages"`
+					UseWorkspaces bool     `json:"useWorkspaces"`
+				}
+				if json.Unmarshal(content, &lerna) != nil || lerna.UseWorkspaces {
+					continue
+				}
+				if len(lerna.Packages) == 0 {
+					lerna.Packages = []string{"packages/*"}
+				}
+				configs[dir] = nodeWorkspaceConfig{"lerna", lerna.Packages}
+			}
+		}
+	}
+
+	for _, dir := range sortedKeys(byDir) {
+		root := byDir[dir]
+		kind, patterns := "npm", root.Node.Workspaces
+		if config, ok := configs[dir]; ok {
+			kind, patterns = config.kind, config.patterns
+		} else if _, err := os.Stat(filepath.Join(dir, "yarn.lock")); err == nil {
+			kind = "yarn"
+		}
+		if len(patterns) == 0 {
+			continue
+		}
+		c.recordNodeWorkspace(kind, root, patterns, byDir)
+	}
+}
+
+// recordNodeWorkspace records the packages matched by a root's workspace
+// globs ("!" patterns exclude) and the dependencies between them
+func (c *Crawler) recordNodeWorkspace(kind string, root *Manifest, patterns []string, byDir map[string]*Manifest) {
+	rootDir := filepath.Dir(root.Path)
+	ws := &Workspace{Kind: kind, Root: root.Path, Name: root.Name, Members: []string{}, Edges: []WorkspaceEdge{}}
+	members := make(map[string]*Manifest) // package name -> package.json
+	packages := []*Manifest{root}
+	for _, dir := range sortedKeys(byDir) {
+		m := byDir[dir]
+		rel, err := filepath.Rel(rootDir, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") || m.Name == "" {
+			continue
+		}
+		if !matchWorkspaceGlobs(patterns, filepath.ToSlash(rel)) {
+			continue
+		}
+		// The package manager refuses two members with one name; link the first
+		if other := members[m.Name]; other != nil {
+			ws.Issues = append(ws.Issues, fmt.Sprintf("%s is the name of both %s and %s",
+				m.Name, relativePath(rootDir, other.Path), relativePath(rootDir, m.Path)))
+			continue
+		}
+		members[m.Name] = m
+		packages = append(packages, m)
+	}
+	sort.Slice(packages[1:], func(i, j int) bool { return packages[i+1].Path < packages[j+1].Path })
+
+	// A dependency on a workspace package is linked when it uses the
+	// workspace: protocol or a range the package's version satisfies;
+	// otherwise the registry version is installed
+	for _, m := range packages {
+		if m != root {
+			ws.Members = append(ws.Members, m.Path)
+		}
+		for _, dep := range m.Dependencies {
+			target := members[dep.Name]
+			switch dep.Source {
+			case "path":
+				target = byDir[dep.URL]
+			case "workspace":
+			case "":
+				if target == nil || !satisfiesNodeRange(target.Version, dep.Version) {
+					continue
+				}
+			default:
+				continue
+			}
+			if target == nil || target == m {
+				continue
+			}
+			if dep.Source == "" {
+				dep.Source = "workspace"
+			}
+			if m != root && members[target.Name] == target {
+				ws.Edges = append(ws.Edges, WorkspaceEdge{From: m.Path, To: target.Path, Kind: dep.Group})
+			}
+		}
+	}
+	c.Analysis.Dependencies.Workspaces = append(c.Analysis.Dependencies.Workspaces, ws)
+}
+
+// satisfiesNodeRange reports whether a version satisfies an npm semver range:
+// "||" alternatives of space-separated comparators, hyphen ranges, and
+// partial, x-, caret and tilde versions
+func satisfiesNodeRange(version, spec string) bool {
+	for _, alternative := range strings.Split(spec, "||") {
+		alternative = constraintOperator.ReplaceAllString(strings.TrimSpace(alternative), "$1")
+		if low, high, ok := strings.Cut(alternative, " - "); ok {
+			alternative = ">=" + strings.TrimSpace(low) + " <=" + strings.TrimSpace(high)
+		}
+		satisfied := true
+		for _, comparator := range strings.Fields(alternative) {
+			if !satisfiesNodeComparator(version, comparator) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+// satisfiesNodeComparator checks a version against one comparator of an npm range
+func satisfiesNodeComparator(version, comparator string) bool {
+	rest := strings.TrimLeft(comparator, "<>=^~")
+	op := comparator[:len(comparator)-len(rest)]
+	rest = strings.TrimPrefix(rest, "v")
+	parts, n := nodeVersionParts(rest)
+	if n == 0 {
+		// "*" and "x" allow anything; tags and aliases name no version
+		return strings.IndexAny(rest, "*xX") == 0 && op != "<" && op != ">"
+	}
+	if version == "" {
+		return false
+	}
+
+	// floor is the partial version filled with zeros; bump increments the
+	// last given part, or the one a caret or tilde keeps fixed
+	floor := fmt.Sprintf("%d.%d.%d", parts[0], parts[1], parts[2])
+	if n == 3 && parts[3] != 0 {
+		floor = rest
+	}
+	bump := func(i int) string {
+		next := [3]int{parts[0], parts[1], parts[2]}
+		next[i]++
+		for j := i + 1; j < 3; j++ {
+			next[j] = 0
+		}
+		return fmt.Sprintf("%d.%d.%d-0", next[0], next[1], next[2])
+	}
+	atLeast := func(v string) bool { return compareSemver(version, v) >= 0 }
+	below := func(v string) bool { return compareSemver(version, v) < 0 }
+
+	switch op {
+	case ">=":
+		return atLeast(floor)
+	case ">":
+		if n < 3 {
+			return atLeast(bump(n - 1))
+		}
+		return compareSemver(version, floor) > 0
+	case "<":
+		return below(floor)
+	case "<=":
+		if n < 3 {
+			return below(bump(n - 1))
+		}
+		return compareSemver(version, floor) <= 0
+	case "^":
+		fixed := 0
+		for fixed < n-1 && parts[fixed] == 0 {
+			fixed++
+		}
+		return atLeast(floor) && below(bump(fixed))
+	case "~":
+		if n == 1 {
+			return atLeast(floor) && below(bump(0))
+		}
+		return atLeast(floor) && below(bump(1))
+	}
+	if n < 3 {
+		return atLeast(floor) && below(bump(n-1))
+	}
+	return compareSemver(version, floor) == 0
+}
+
+// nodeVersionParts parses the major, minor and patch of a possibly partial
+// version such as 1.2 or 1.x, returning how many leading parts are given; the
+// fourth part is 1 when a pre-release or build suffix follows
+func nodeVersionParts(v string) ([4]int, int) {
+	var parts [4]int
+	core, suffix, _ := strings.Cut(v, "-")
+	core, _, build := strings.Cut(core, "+")
+	if suffix != "" || build {
+		parts[3] = 1
+	}
+	n := 0
+	for _, s := range strings.SplitN(core, ".", 3) {
+		num, err := strconv.Atoi(s)
+		if err != nil {
+			break // x, X, * or the end of the version
+		}
+		parts[n] = num
+		n++
+	}
+	return parts, n
+}
+
+// matchWorkspaceGlobs reports whether a directory relative to the workspace
+// root is matched by the globs and not excluded by a later "!" glob
+func matchWorkspaceGlobs(patterns []string, rel string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./")
+		if matchPathGlob(pattern, rel) {
+			matched = !exclude
+		}
+	}
+	return matched
+}
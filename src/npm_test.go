@@ -0,0 +1,136 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchWorkspaceGlobs(t *testing.T) {
+	patterns := []string{"packages/*", "./apps/**", "!packages/legacy"}
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{"packages/ui", true},
+		{"packages/legacy", false},
+		{"apps/web/admin", true},
+		{"tools/cli", false},
+	}
+	for _, tt := range tests {
+		if got := matchWorkspaceGlobs(patterns, tt.rel); got != tt.want {
+			t.Errorf("matchWorkspaceGlobs(%q) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestParsePackageJSONMetadata(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"package.json": `{
+  "name": "@acme/cli",
+  "private": true,
+  "bin": "./bin/cli.js",
+  "main": "./dist/index.js",
+  "exports": {".": {"import": "./dist/index.mjs", "require": "./dist/index.js"}},
+  "engines": {"node": ">=18"},
+  "dependencies": {"utils": "file:../utils", "left-pad": "github:stevemao/left-pad"},
+  "peerDependencies": {"react": "^18.0.0"},
+  "peerDependenciesMeta": {"react": {"optional": true}}
+}`,
+	})
+
+	m := findManifest(c, root, "package.json")
+	if m == nil || m.Node == nil {
+		t.Fatalf("package.json not parsed: %+v", m)
+	}
+	want := &NodePackage{
+		Private:     true,
+		Bin:         map[string]string{"cli": "./bin/cli.js"},
+		Engines:     map[string]string{"node": ">=18"},
+		EntryPoints: []string{"dist/index.js", "dist/index.mjs"},
+	}
+	if !reflect.DeepEqual(m.Node, want) {
+		t.Errorf("package metadata = %+v, want %+v", m.Node, want)
+	}
+
+	sources := make(map[string]string)
+	for _, dep := range m.Dependencies {
+		sources[dep.Name] = dep.Source
+		if dep.Name == "react" && !dep.Optional {
+			t.Errorf("optional peer react not marked optional")
+		}
+	}
+	wantSources := map[string]string{"left-pad": "git", "utils": "path", "react": ""}
+	if !reflect.DeepEqual(sources, wantSources) {
+		t.Errorf("sources = %v, want %v", sources, wantSources)
+	}
+}
+
+func TestSatisfiesNodeRange(t *testing.T) {
+	tests := []struct {
+		version, spec string
+		want          bool
+	}{
+		{"1.2.3", "", true},
+		{"1.2.3", "*", true},
+		{"1.2.3", "1.x", true},
+		{"1.2.3", "1.2", true},
+		{"1.2.3", "1.3", false},
+		{"1.2.3", "=1.2.3", true},
+		{"1.2.3", "1.2.4", false},
+		{"1.9.0", "^1.2.0", true},
+		{"2.0.0", "^1.2.0", false},
+		{"0.3.0", "^0.2.1", false},
+		{"0.0.4", "^0.0.3", false},
+		{"1.2.9", "~1.2.3", true},
+		{"1.3.0", "~1.2.3", false},
+		{"1.5.0", ">=1.2.0 <2", true},
+		{"2.0.0", ">= 1.2.0 < 2", false},
+		{"1.3.0", ">1.2", true},
+		{"1.2.5", ">1.2", false},
+		{"1.2.5", "<=1.2", true},
+		{"2.0.0", "1.0.0 - 2.0.0", true},
+		{"3.1.0", "^1.0.0 || ^3.0.0", true},
+		{"1.0.0-beta.2", ">=1.0.0-beta.1", true},
+		{"1.2.3", "latest", false},
+		{"1.2.3", "npm:other@^1.0.0", false},
+		{"", "^1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := satisfiesNodeRange(tt.version, tt.spec); got != tt.want {
+			t.Errorf("satisfiesNodeRange(%q, %q) = %v, want %v", tt.version, tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestNodeWorkspaces(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"package.json":                  `{"name": "monorepo", "private": true, "workspaces": ["packages/*"]}`,
+		"packages/ui/package.json":      `{"name": "@acme/ui", "version": "1.0.0", "dependencies": {"react": "^18.2.0"}}`,
+		"packages/ui-fork/package.json": `{"name": "@acme/ui", "version": "2.0.0"}`,
+		"packages/web/package.json":     `{"name": "@acme/web", "dependencies": {"@acme/ui": "workspace:*"}, "devDependencies": {"@acme/ui": "^1.0.0"}}`,
+		"packages/legacy/package.json":  `{"name": "@acme/legacy", "dependencies": {"@acme/ui": "^0.9.0"}}`,
+		"tools/package.json":            `{"name": "tools", "dependencies": {"@acme/ui": "^1.0.0"}}`,
+	})
+
+	got := workspaceEdges(c, root, "package.json")
+	want := []string{
+		"packages/web/package.json -> packages/ui/package.json (dependencies)",
+		"packages/web/package.json -> packages/ui/package.json (devDependencies)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("workspace edges = %q, want %q", got, want)
+	}
+	// A range the workspace package does not satisfy installs from the registry
+	if legacy := findManifest(c, root, "packages/legacy/package.json"); legacy.Dependencies[0].Source != "" {
+		t.Errorf("legacy dependency on @acme/ui ^0.9.0 was linked")
+	}
+	if tools := findManifest(c, root, "tools/package.json"); tools == nil || tools.Dependencies[0].Source != "" {
+		t.Errorf("dependency outside the workspace was linked: %+v", tools)
+	}
+
+	ws := c.Analysis.Dependencies.Workspaces[0]
+	wantIssues := []string{"@acme/ui is the name of both packages/ui/package.json and packages/ui-fork/package.json"}
+	if !reflect.DeepEqual(ws.Issues, wantIssues) || len(ws.Members) != 3 {
+		t.Errorf("workspace members %q, issues %q, want 3 members and %q", ws.Members, ws.Issues, wantIssues)
+	}
+}
@@ -0,0 +1,533 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Vulnerability is an OSV advisory that affects a dependency version found in
+// the repository
+type Vulnerability struct {
+	ID        string   `json:"id"`
+	Aliases   []string `json:"aliases,omitempty"`
+	Summary   string   `json:"summary,omitempty"`
+	Severity  string   `json:"severity"`        // "CRITICAL", "HIGH", "MEDIUM", "LOW" or "UNKNOWN"
+	Score     float64  `json:"score,omitempty"` // CVSS v3 base score, when the advisory has a vector
+	Ecosystem string   `json:"ecosystem"`
+	Package   string   `json:"package"`
+	Version   string   `json:"version"`
+	Basis     string   `json:"basis"`  // "locked" for resolved versions, "declared" for the lowest version a constraint allows
+	Direct    bool     `json:"direct"` // declared by a manifest rather than only pulled in by a lockfile
+	Fixed     []string `json:"fixed,omitempty"`
+	Files     []string `json:"files"` // manifests and lockfiles the version was found in
+	URL       string   `json:"url,omitempty"`
+}
+
+// osvEntry is the subset of an OSV advisory the crawler matches against
+type osvEntry struct {
+	ID               string                 `json:"id"`
+	Aliases          []string               `json:"aliases"`
+	Summary          string                 `json:"summary"`
+	Withdrawn        string                 `json:"withdrawn"`
+	Severity         []osvSeverity          `json:"severity"`
+	Affected         []osvAffected          `json:"affected"`
+	DatabaseSpecific map[string]interface{} `json:"database_specific"`
+	References       []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"references"`
+}
+
+// osvSeverity is a severity score such as a CVSS vector
+type osvSeverity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// osvAffected is one affected package of an advisory
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Severity []osvSeverity `json:"severity"`
+	Ranges   []struct {
+		Type   string              `json:"type"`
+		Events []map[string]string `json:"events"`
+	} `json:"ranges"`
+	Versions          []string               `json:"versions"`
+	EcosystemSpecific map[string]interface{} `json:"ecosystem_specific"`
+	DatabaseSpecific  map[string]interface{} `json:"database_specific"`
+}
+
+// osvDatabase indexes advisories by ecosystem and package name
+type osvDatabase map[string][]*osvEntry
+
+// osvEcosystems maps package managers to OSV ecosystem names
+var osvEcosystems = map[string]string{
+	"npm":        "npm",
+	"yarn":       "npm",
+	"pnpm":       "npm",
+	"pip":        "PyPI",
+	"pipenv":     "PyPI",
+	"poetry":     "PyPI",
+	"pdm":        "PyPI",
+	"hatch":      "PyPI",
+	"go modules": "Go",
+	"cargo":      "crates.io",
+	"maven":      "Maven",
+	"gradle":     "Maven",
+	"composer":   "Packagist",
+	"bundler":    "RubyGems",
+	"pub":        "Pub",
+	"nuget":      "NuGet",
+	"swift pm":   "SwiftURL",
+}
+
+// severityRanks orders severities for sorting findings
+var severityRanks = map[string]int{"CRITICAL": 4, "HIGH": 3, "MEDIUM": 2, "LOW": 1}
+
+// osvKey builds the index key of a package, normalizing names the way the
+// ecosystem compares them
+func osvKey(ecosystem, name string) string {
+	switch ecosystem {
+	case "PyPI":
+		name = normalizePythonName(name)
+	case "NuGet", "Packagist":
+		name = strings.ToLower(name)
+	}
+	return ecosystem + "|" + name
+}
+
+// loadOSVDatabase reads OSV advisories from a directory of JSON files (which
+// may contain zip exports) or from a single zip or JSON file
+func loadOSVDatabase(path string) (osvDatabase, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	db := make(osvDatabase)
+	if !info.IsDir() {
+		return db, db.addFile(path)
+	}
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		return db.addFile(p)
+	})
+	return db, err
+}
+
+// addFile adds the advisories of a JSON or zip file
+func (db osvDatabase) addFile(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		db.addJSON(content)
+	case ".zip":
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		defer archive.Close()
+		for _, file := range archive.File {
+			if !strings.HasSuffix(strings.ToLower(file.Name), ".json") {
+				continue
+			}
+			r, err := file.Open()
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			content, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			db.addJSON(content)
+		}
+	}
+	return nil
+}
+
+// addJSON adds one advisory, or an array of them; other JSON is ignored
+func (db osvDatabase) addJSON(content []byte) {
+	var entries []*osvEntry
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		if json.Unmarshal(trimmed, &entries) != nil {
+			return
+		}
+	} else {
+		var entry osvEntry
+		if json.Unmarshal(trimmed, &entry) != nil {
+			return
+		}
+		entries = append(entries, &entry)
+	}
+
+	for _, entry := range entries {
+		if entry.ID == "" || entry.Withdrawn != "" {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, affected := range entry.Affected {
+			key := osvKey(affected.Package.Ecosystem, affected.Package.Name)
+			if !seen[key] {
+				seen[key] = true
+				db[key] = append(db[key], entry)
+			}
+		}
+	}
+}
+
+// osvPackage is a package version to look up, with where it was found
+type osvPackage struct {
+	ecosystem, name, version, basis, file string
+	direct                                bool
+}
+
+// MatchVulnerabilities loads the OSV database given by Config.OSVDatabase and
+// records the advisories affecting the declared and locked dependencies
+func (c *Crawler) MatchVulnerabilities() error {
+	db, err := loadOSVDatabase(c.Config.OSVDatabase)
+	if err != nil {
+		return err
+	}
+
+	var packages []osvPackage
+	for _, m := range c.Analysis.Dependencies.Manifests {
+		ecosystem, ok := osvEcosystems[m.Manager]
+		if !ok {
+			continue
+		}
+		for _, dep := range m.Dependencies {
+			switch dep.Source {
+			case "workspace", "path", "sdk", "url", "vcs":
+				continue
+			case "git":
+				if ecosystem != "SwiftURL" {
+					continue
+				}
+			}
+			name := dep.Name
+			if ecosystem == "SwiftURL" {
+				name = swiftPackageURL(dep.URL)
+			}
+			version, basis := dep.Resolved, "locked"
+			if version == "" {
+				version, basis = versionFloor(dep.Version), "declared"
+			}
+			if version != "" {
+				packages = append(packages, osvPackage{ecosystem, name, version, basis, m.Path, true})
+			}
+		}
+	}
+	for _, lock := range c.Analysis.Dependencies.Lockfiles {
+		ecosystem, ok := osvEcosystems[lock.Manager]
+		if !ok || lock.Error != "" {
+			continue
+		}
+		for _, pkg := range lock.Packages {
+			if pkg.Version != "" {
+				packages = append(packages, osvPackage{ecosystem, pkg.Name, pkg.Version, "locked", lock.Path, false})
+			}
+		}
+	}
+
+	findings := make(map[string]*Vulnerability) // advisory|ecosystem|package|version
+	for _, pkg := range packages {
+		for _, entry := range db[osvKey(pkg.ecosystem, pkg.name)] {
+			affected, fixed := entry.affects(pkg)
+			if !affected {
+				continue
+			}
+			key := strings.Join([]string{entry.ID, pkg.ecosystem, pkg.name, pkg.version}, "|")
+			v := findings[key]
+			if v == nil {
+				v = entry.finding(pkg)
+				v.Fixed = fixed
+				findings[key] = v
+			}
+			if !containsString(v.Files, pkg.file) {
+				v.Files = append(v.Files, pkg.file)
+			}
+			if pkg.basis == "locked" {
+				v.Basis = "locked"
+			}
+			v.Direct = v.Direct || pkg.direct
+		}
+	}
+
+	vulns := []*Vulnerability{}
+	for _, key := range sortedKeys(findings) {
+		vulns = append(vulns, findings[key])
+	}
+	sort.SliceStable(vulns, func(i, j int) bool {
+		if a, b := severityRanks[vulns[i].Severity], severityRanks[vulns[j].Severity]; a != b {
+			return a > b
+		}
+		return vulns[i].Package < vulns[j].Package
+	})
+	c.Analysis.Dependencies.Vulnerabilities = vulns
+	return nil
+}
+
+// affects reports whether an advisory affects a package version, and the
+// versions that fix it
+func (e *osvEntry) affects(pkg osvPackage) (bool, []string) {
+	key := osvKey(pkg.ecosystem, pkg.name)
+	for _, affected := range e.Affected {
+		if osvKey(affected.Package.Ecosystem, affected.Package.Name) != key {
+			continue
+		}
+		var fixed []string
+		hit := false
+		for _, v := range affected.Versions {
+			if strings.TrimPrefix(v, "v") == strings.TrimPrefix(pkg.version, "v") {
+				hit = true
+			}
+		}
+		for _, r := range affected.Ranges {
+			cmp := func(a, b string) int { return compareVersions(pkg.ecosystem, a, b) }
+			switch r.Type {
+			case "SEMVER":
+				cmp = compareSemver
+			case "ECOSYSTEM":
+			default:
+				continue // GIT ranges name commits
+			}
+			if rangeAffects(r.Events, pkg.version, cmp) {
+				hit = true
+			}
+			for _, event := range r.Events {
+				if v := event["fixed"]; v != "" && !containsString(fixed, v) {
+					fixed = append(fixed, v)
+				}
+			}
+		}
+		if hit {
+			return true, fixed
+		}
+	}
+	return false, nil
+}
+
+// rangeAffects evaluates OSV range events against a version: it is affected
+// between an introduced event and the next fixed or last_affected event
+func rangeAffects(events []map[string]string, version string, cmp func(a, b string) int) bool {
+	type event struct{ kind, version string }
+	var list []event
+	for _, e := range events {
+		for _, kind := range sortedKeys(e) {
+			list = append(list, event{kind, e[kind]})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].version == "0" || list[j].version == "0" {
+			return list[i].version == "0" && list[j].version != "0"
+		}
+		return cmp(list[i].version, list[j].version) < 0
+	})
+
+	affected := false
+	for _, e := range list {
+		switch e.kind {
+		case "introduced":
+			if e.version == "0" || cmp(version, e.version) >= 0 {
+				affected = true
+			}
+		case "fixed":
+			if cmp(version, e.version) >= 0 {
+				affected = false
+			}
+		case "last_affected":
+			if cmp(version, e.version) > 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+// finding builds the report entry of an advisory for a package version
+func (e *osvEntry) finding(pkg osvPackage) *Vulnerability {
+	v := &Vulnerability{
+		ID:        e.ID,
+		Aliases:   e.Aliases,
+		Summary:   e.Summary,
+		Severity:  "UNKNOWN",
+		Ecosystem: pkg.ecosystem,
+		Package:   pkg.name,
+		Version:   pkg.version,
+		Basis:     pkg.basis,
+		Files:     []string{},
+	}
+
+	severities := e.Severity
+	labels := []interface{}{e.DatabaseSpecific["severity"]}
+	for _, affected := range e.Affected {
+		if osvKey(affected.Package.Ecosystem, affected.Package.Name) == osvKey(pkg.ecosystem, pkg.name) {
+			severities = append(severities, affected.Severity...)
+			labels = append(labels, affected.EcosystemSpecific["severity"], affected.DatabaseSpecific["severity"])
+		}
+	}
+	for _, s := range severities {
+		if score, ok := cvss3BaseScore(s.Score); ok && score > v.Score {
+			v.Score = score
+			v.Severity = cvssRating(score)
+		}
+	}
+	if v.Score == 0 {
+		for _, label := range labels {
+			if s, ok := label.(string); ok && s != "" {
+				v.Severity = strings.ToUpper(s)
+				if v.Severity == "MODERATE" {
+					v.Severity = "MEDIUM"
+				}
+				break
+			}
+		}
+	}
+
+	for _, ref := range e.References {
+		if v.URL == "" || ref.Type == "ADVISORY" {
+			v.URL = ref.URL
+			if ref.Type == "ADVISORY" {
+				break
+			}
+		}
+	}
+	return v
+}
+
+// cvss3Weights are the CVSS v3 base metric weights; PR has separate weights
+// when the scope changes
+var cvss3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// cvss3BaseScore computes the base score of a CVSS v3.x vector
+func cvss3BaseScore(vector string) (float64, bool) {
+	if !strings.HasPrefix(vector, "CVSS:3.") {
+		return 0, false
+	}
+	metrics := make(map[string]string)
+	for _, part := range strings.Split(vector, "/")[1:] {
+		if kv := strings.SplitN(part, ":", 2); len(kv) == 2 {
+			metrics[kv[0]] = kv[1]
+		}
+	}
+	w := make(map[string]float64)
+	for metric, weights := range cvss3Weights {
+		weight, ok := weights[metrics[metric]]
+		if !ok {
+			return 0, false
+		}
+		w[metric] = weight
+	}
+	changed := metrics["S"] == "C"
+	if changed && metrics["PR"] == "L" {
+		w["PR"] = 0.68
+	} else if changed && metrics["PR"] == "H" {
+		w["PR"] = 0.5
+	}
+
+	iss := 1 - (1-w["C"])*(1-w["I"])*(1-w["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	exploitability := 8.22 * w["AV"] * w["AC"] * w["PR"] * w["UI"]
+	if changed {
+		return cvssRoundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return cvssRoundUp(math.Min(impact+exploitability, 10)), true
+}
+
+// cvssRoundUp rounds up to one decimal the way CVSS v3.1 specifies
+func cvssRoundUp(x float64) float64 {
+	n := int(math.Round(x * 100000))
+	if n%10000 == 0 {
+		return float64(n) / 100000
+	}
+	return float64(n/10000+1) / 10
+}
+
+// cvssRating is the qualitative rating of a CVSS score
+func cvssRating(score float64) string {
+	switch {
+	case score >= 9:
+		return "CRITICAL"
+	case score >= 7:
+		return "HIGH"
+	case score >= 4:
+		return "MEDIUM"
+	case score > 0:
+		return "LOW"
+	}
+	return "UNKNOWN"
+}
+
+// constraintOperator matches a comparison operator followed by spaces
+var constraintOperator = regexp.MustCompile(`([<>=!~^]+)\s+`)
+
+// versionFloor returns the lowest version a declared constraint allows, such
+// as 1.2.0 for ^1.2.0, >=1.2,<2 or [1.2,2.0), or "" when it has no lower bound
+func versionFloor(constraint string) string {
+	if strings.Contains(constraint, "${") || strings.Contains(constraint, "$(") {
+		return ""
+	}
+	alternative := constraintOperator.ReplaceAllString(strings.SplitN(constraint, "||", 2)[0], "$1")
+	for _, part := range strings.FieldsFunc(alternative, func(r rune) bool { return r == ',' || r == ' ' }) {
+		// Upper bounds and exclusive lower bounds say nothing about the floor
+		if strings.ContainsAny(part, "<!)]") || strings.HasPrefix(part, ">") && !strings.HasPrefix(part, ">=") {
+			continue
+		}
+		v := strings.TrimLeft(part, "[=>^~v")
+		if v == "" || v[0] < '0' || v[0] > '9' {
+			continue
+		}
+		// Wildcards such as 1.2.x or 1.* start at zero
+		segments := strings.Split(v, ".")
+		for i, s := range segments {
+			if s == "x" || s == "X" || s == "*" {
+				segments = append(segments[:i], "0")
+				break
+			}
+		}
+		return strings.Join(segments, ".")
+	}
+	return ""
+}
+
+// swiftPackageURL names a Swift package the way the SwiftURL ecosystem does,
+// as its repository URL without scheme, ref or .git suffix
+func swiftPackageURL(url string) string {
+	url = strings.SplitN(url, "#", 2)[0]
+	if idx := strings.Index(url, "://"); idx >= 0 {
+		url = url[idx+3:]
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVersionFloor(t *testing.T) {
+	tests := map[string]string{
+		"^1.2.0":         "1.2.0",
+		">=1.2,<2":       "1.2",
+		"[1.2,2.0)":      "1.2",
+		"~> 2.5":         "2.5",
+		"1.2.x":          "1.2.0",
+		"<2.0":           "",
+		"${lib.version}": "",
+	}
+	for constraint, want := range tests {
+		if got := versionFloor(constraint); got != want {
+			t.Errorf("versionFloor(%q) = %q, want %q", constraint, got, want)
+		}
+	}
+}
+
+func TestCVSS3BaseScore(t *testing.T) {
+	tests := []struct {
+		vector string
+		score  float64
+		rating string
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, "CRITICAL"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1, "MEDIUM"},
+		{"CVSS:3.0/AV:L/AC:H/PR:H/UI:R/S:U/C:N/I:N/A:N", 0, "UNKNOWN"},
+	}
+	for _, tt := range tests {
+		score, ok := cvss3BaseScore(tt.vector)
+		if !ok || score != tt.score || cvssRating(score) != tt.rating {
+			t.Errorf("cvss3BaseScore(%q) = %v, %v (%s), want %v (%s)", tt.vector, score, ok, cvssRating(score), tt.score, tt.rating)
+		}
+	}
+	if _, ok := cvss3BaseScore("CVSS:2.0/AV:N"); ok {
+		t.Errorf("CVSS v2 vector was scored")
+	}
+}
+
+const lodashAdvisory = `{
+  "id": "GHSA-test-0001",
+  "summary": "Prototype pollution in lodash",
+  "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}],
+  "affected": [{
+    "package": {"ecosystem": "npm", "name": "lodash"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]
+  }],
+  "references": [{"type": "WEB", "url": "https://example.com/web"}, {"type": "ADVISORY", "url": "https://example.com/advisory"}]
+}`
+
+func TestMatchVulnerabilities(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"package.json":     `{"name": "app", "dependencies": {"lodash": "^4.17.0", "react": "^18.2.0"}}`,
+		"old/package.json": `{"name": "old", "dependencies": {"lodash": "4.17.21"}}`,
+	})
+	db := filepath.Join(t.TempDir(), "osv")
+	if err := os.MkdirAll(db, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(db, "GHSA-test-0001.json"), []byte(lodashAdvisory), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c.Config.OSVDatabase = db
+	if err := c.MatchVulnerabilities(); err != nil {
+		t.Fatal(err)
+	}
+
+	vulns := c.Analysis.Dependencies.Vulnerabilities
+	if len(vulns) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(vulns), vulns)
+	}
+	v := vulns[0]
+	if v.Version != "4.17.0" || v.Basis != "declared" || !v.Direct || v.Severity != "CRITICAL" {
+		t.Errorf("finding = %+v", v)
+	}
+	if len(v.Files) != 1 || relativePath(root, v.Files[0]) != "package.json" {
+		t.Errorf("files = %q, want [package.json]", v.Files)
+	}
+	if len(v.Fixed) != 1 || v.Fixed[0] != "4.17.21" || v.URL != "https://example.com/advisory" {
+		t.Errorf("fixed = %q, url = %q", v.Fixed, v.URL)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+// parsePubspec parses pubspec.yaml: dependencies, dev_dependencies and
+// dependency_overrides with hosted, git, path and SDK sources
+func (c *Crawler) parsePubspec(content []byte, m *Manifest) {
+	doc, err := parseYAML(content)
+	if err != nil {
+		return
+	}
+	m.Name = yamlString(doc, "name")
+	m.Version = yamlString(doc, "version")
+
+	for _, group := range []string{"dependencies", "dev_dependencies", "dependency_overrides"} {
+		deps := yamlMap(doc, group)
+		for _, name := range sortedKeys(deps) {
+			switch spec := deps[name].(type) {
+			case nil:
+				m.addDependency(group, name, "any")
+			case string:
+				m.addDependency(group, name, spec)
+			case map[string]interface{}:
+				dep := m.addDependency(group, name, yamlString(spec, "version"))
+				switch {
+				case yamlString(spec, "sdk") != "":
+					dep.Source = "sdk"
+					dep.URL = yamlString(spec, "sdk")
+				case yamlString(spec, "path") != "":
+					dep.Source = "path"
+					dep.URL = yamlString(spec, "path")
+				case spec["git"] != nil:
+					dep.Source = "git"
+					dep.URL = yamlString(spec, "git")
+					if git := yamlMap(spec, "git"); git != nil {
+						dep.URL = yamlString(git, "url")
+						if ref := yamlString(git, "ref"); ref != "" {
+							dep.URL += "#ref=" + ref
+						}
+					}
+				case spec["hosted"] != nil:
+					dep.URL = yamlString(spec, "hosted")
+					if hosted := yamlMap(spec, "hosted"); hosted != nil {
+						dep.URL = yamlString(hosted, "url")
+					}
+				}
+			}
+		}
+	}
+}
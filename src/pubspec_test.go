@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePubspec(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"pubspec.yaml": `name: app
+version: 1.0.0+1
+
+dependencies:
+  flutter:
+    sdk: flutter
+  http: ^1.1.0
+  utils:
+    path: ../utils
+  pkg:
+    git:
+      url: https://github.com/org/pkg.git
+      ref: main
+
+dev_dependencies:
+  test: any
+`,
+	})
+
+	m := findManifest(c, root, "pubspec.yaml")
+	if m.Name != "app" || m.Version != "1.0.0+1" {
+		t.Errorf("manifest = %s@%s, want app@1.0.0+1", m.Name, m.Version)
+	}
+	want := []string{
+		"dependencies flutter ",
+		"dependencies http ^1.1.0",
+		"dependencies pkg ",
+		"dependencies utils ",
+		"dev_dependencies test any",
+	}
+	if got := manifestDeps(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("dependencies = %q, want %q", got, want)
+	}
+	sources := map[string]string{"flutter": "sdk", "pkg": "git", "utils": "path"}
+	for _, dep := range m.Dependencies {
+		if dep.Source != sources[dep.Name] {
+			t.Errorf("%s: source %q, want %q", dep.Name, dep.Source, sources[dep.Name])
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Python token kinds
+const (
+	pyName = iota
+	pyOp
+	pyString
+	pyNumber
+	pyNewline
+	pyIndent
+	pyDedent
+)
+
+// pyToken is one token of Python source
+type pyToken struct {
+	kind int
+	text string
+	line int
+}
+
+// tokenizePython splits Python source into tokens the way the tokenize module
+// does for the purposes of import extraction: comments and strings (including
+// triple-quoted docstrings) are opaque, newlines inside brackets and after a
+// backslash continue the logical line, and indentation changes produce
+// INDENT and DEDENT tokens. Operators are single characters.
+func tokenizePython(src string) []pyToken {
+	var tokens []pyToken
+	indents := []int{0}
+	depth := 0
+	line := 1
+	atLineStart := true
+	i := 0
+
+	for i < len(src) {
+		if atLineStart && depth == 0 {
+			// Measure indentation; blank and comment-only lines don't count
+			col, j := 0, i
+			for j < len(src) && (src[j] == ' ' || src[j] == '\t' || src[j] == '\f') {
+				if src[j] == '\t' {
+					col = (col/8 + 1) * 8
+				} else if src[j] == ' ' {
+					col++
+				}
+				j++
+			}
+			if j >= len(src) {
+				break
+			}
+			if src[j] == '\n' || src[j] == '\r' || src[j] == '#' {
+				for j < len(src) && src[j] != '\n' {
+					j++
+				}
+				if j < len(src) {
+					j++
+					line++
+				}
+				i = j
+				continue
+			}
+			i = j
+			atLineStart = false
+			if col > indents[len(indents)-1] {
+				indents = append(indents, col)
+				tokens = append(tokens, pyToken{pyIndent, "", line})
+			}
+			for col < indents[len(indents)-1] {
+				indents = indents[:len(indents)-1]
+				tokens = append(tokens, pyToken{pyDedent, "", line})
+			}
+		}
+
+		c := src[i]
+		switch {
+		case c == '\n':
+			if depth == 0 {
+				tokens = append(tokens, pyToken{pyNewline, "", line})
+				atLineStart = true
+			}
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '\\' && i+1 < len(src) && (src[i+1] == '\n' || src[i+1] == '\r'):
+			// Explicit line continuation
+			i++
+			if src[i] == '\r' && i+1 < len(src) && src[i+1] == '\n' {
+				i++
+			}
+			i++
+			line++
+		case c == '"' || c == '\'' || (isPyStringPrefix(src, i)):
+			start, startLine := i, line
+			for src[i] != '"' && src[i] != '\'' {
+				i++ // string prefix letters
+			}
+			i, line = skipPyString(src, i, line)
+			tokens = append(tokens, pyToken{pyString, src[start:i], startLine})
+		case c >= '0' && c <= '9' || (c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9'):
+			start := i
+			for i < len(src) && (isPyNameByte(src[i]) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, pyToken{pyNumber, src[start:i], line})
+		case isPyNameStart(src, i):
+			start := i
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, pyToken{pyName, src[start:i], line})
+		default:
+			switch c {
+			case '(', '[', '{':
+				depth++
+			case ')', ']', '}':
+				if depth > 0 {
+					depth--
+				}
+			}
+			tokens = append(tokens, pyToken{pyOp, string(c), line})
+			i++
+		}
+	}
+
+	if len(tokens) > 0 && tokens[len(tokens)-1].kind != pyNewline {
+		tokens = append(tokens, pyToken{pyNewline, "", line})
+	}
+	for len(indents) > 1 {
+		indents = indents[:len(indents)-1]
+		tokens = append(tokens, pyToken{pyDedent, "", line})
+	}
+	return tokens
+}
+
+// isPyStringPrefix reports whether src[i:] starts a string with a prefix such as r, b, f or rb
+func isPyStringPrefix(src string, i int) bool {
+	for n := 0; n < 2 && i+n < len(src); n++ {
+		switch src[i+n] {
+		case 'r', 'R', 'b', 'B', 'u', 'U', 'f', 'F':
+			if i+n+1 < len(src) && (src[i+n+1] == '"' || src[i+n+1] == '\'') {
+				return i == 0 || !isPyNameByte(src[i-1])
+			}
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// skipPyString returns the offset just past the string literal whose opening
+// quote is at src[i], and the line it ends on
+func skipPyString(src string, i, line int) (int, int) {
+	quote := src[i : i+1]
+	if strings.HasPrefix(src[i:], strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	i += len(quote)
+	for i < len(src) {
+		switch {
+		case src[i] == '\\' && i+1 < len(src):
+			if src[i+1] == '\n' {
+				line++
+			}
+			i += 2
+		case strings.HasPrefix(src[i:], quote):
+			return i + len(quote), line
+		case src[i] == '\n':
+			if len(quote) == 1 {
+				return i, line // unterminated single-quoted string
+			}
+			line++
+			i++
+		default:
+			i++
+		}
+	}
+	return i, line
+}
+
+func isPyNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func isPyNameStart(src string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(src[i:])
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// parsePythonImports extracts every import and from-import statement of a
+// Python file, at any indentation. Imports inside `if TYPE_CHECKING:` blocks
+// are marked with Kind "type".
+func parsePythonImports(content []byte) []ImportRef {
+	tokens := tokenizePython(string(content))
+	var refs []ImportRef
+
+	level := 0           // indentation level
+	var typeBlocks []int // levels of the open TYPE_CHECKING blocks
+	typeLine := false    // inside a one-line `if TYPE_CHECKING: ...`
+	pendingType := false // `if TYPE_CHECKING:` seen, block not yet opened
+	depth := 0           // bracket depth
+	statementStart := true
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.kind {
+		case pyNewline:
+			statementStart, typeLine = true, false
+			continue
+		case pyIndent:
+			level++
+			if pendingType {
+				typeBlocks = append(typeBlocks, level)
+				pendingType = false
+			}
+			statementStart = true
+			continue
+		case pyDedent:
+			level--
+			for len(typeBlocks) > 0 && typeBlocks[len(typeBlocks)-1] > level {
+				typeBlocks = typeBlocks[:len(typeBlocks)-1]
+			}
+			statementStart = true
+			continue
+		case pyOp:
+			switch tok.text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				if depth > 0 {
+					depth--
+				}
+			case ";":
+				statementStart = true
+				continue
+			case ":":
+				// The body of a one-line compound statement
+				if depth == 0 {
+					statementStart = true
+					continue
+				}
+			}
+		}
+
+		if !statementStart {
+			continue
+		}
+		statementStart = false
+		if tok.kind != pyName {
+			continue
+		}
+
+		kind := ""
+		if len(typeBlocks) > 0 || typeLine {
+			kind = "type"
+		}
+		switch tok.text {
+		case "import":
+			var stmt []ImportRef
+			stmt, i = parsePyImportNames(tokens, i+1)
+			for j := range stmt {
+				stmt[j].Kind = kind
+			}
+			refs = append(refs, stmt...)
+		case "from":
+			var ref *ImportRef
+			if ref, i = parsePyFromImport(tokens, i+1); ref != nil {
+				ref.Line, ref.Kind = tok.line, kind
+				refs = append(refs, *ref)
+			}
+		case "if":
+			if end, ok := pyTypeCheckingTest(tokens, i+1); ok {
+				i = end // the ':' token
+				if i+1 < len(tokens) && tokens[i+1].kind == pyNewline {
+					pendingType = true
+				} else {
+					typeLine = true
+				}
+				statementStart = true
+			}
+		}
+	}
+	return refs
+}
+
+// pyTypeCheckingTest matches `TYPE_CHECKING:` or `<module>.TYPE_CHECKING:`
+// after an `if`, returning the index of the colon
+func pyTypeCheckingTest(tokens []pyToken, i int) (int, bool) {
+	if i+1 < len(tokens) && tokens[i].text == "TYPE_CHECKING" && tokens[i+1].text == ":" {
+		return i + 1, true
+	}
+	if i+3 < len(tokens) && tokens[i].kind == pyName && tokens[i+1].text == "." &&
+		tokens[i+2].text == "TYPE_CHECKING" && tokens[i+3].text == ":" {
+		return i + 3, true
+	}
+	return i, false
+}
+
+// pyDottedName reads a dotted name starting at tokens[i]
+func pyDottedName(tokens []pyToken, i int) (string, int) {
+	var parts []string
+	for i < len(tokens) && tokens[i].kind == pyName {
+		parts = append(parts, tokens[i].text)
+		if i+1 < len(tokens) && tokens[i+1].text == "." {
+			i += 2
+			continue
+		}
+		i++
+		break
+	}
+	return strings.Join(parts, "."), i
+}
+
+// parsePyImportNames parses `a.b [as c], d` after `import`; the returned
+// index is the last token consumed
+func parsePyImportNames(tokens []pyToken, i int) ([]ImportRef, int) {
+	var refs []ImportRef
+	for i < len(tokens) {
+		line := tokens[i].line
+		name, next := pyDottedName(tokens, i)
+		if name == "" {
+			break
+		}
+		ref := ImportRef{Path: name, Line: line}
+		i = next
+		if i+1 < len(tokens) && tokens[i].text == "as" && tokens[i+1].kind == pyName {
+			ref.Alias = tokens[i+1].text
+			i += 2
+		}
+		refs = append(refs, ref)
+		if i < len(tokens) && tokens[i].text == "," {
+			i++
+			continue
+		}
+		break
+	}
+	return refs, i - 1
+}
+
+// parsePyFromImport parses `.. x.y import (a as b, c)` after `from`; the
+// module keeps its leading dots for relative imports
+func parsePyFromImport(tokens []pyToken, i int) (*ImportRef, int) {
+	dots := 0
+	for i < len(tokens) && tokens[i].text == "." {
+		dots++
+		i++
+	}
+	module := ""
+	if i < len(tokens) && tokens[i].text != "import" {
+		module, i = pyDottedName(tokens, i)
+	}
+	if (dots == 0 && module == "") || i >= len(tokens) || tokens[i].text != "import" {
+		return nil, i - 1
+	}
+	ref := &ImportRef{Path: strings.Repeat(".", dots) + module}
+	i++
+
+	parens := i < len(tokens) && tokens[i].text == "("
+	if parens {
+		i++
+	}
+	for i < len(tokens) {
+		tok := tokens[i]
+		switch {
+		case tok.text == "*":
+			ref.Names = append(ref.Names, "*")
+		case tok.kind == pyName && tok.text != "as":
+			ref.Names = append(ref.Names, tok.text)
+			if i+2 < len(tokens) && tokens[i+1].text == "as" {
+				i += 2
+			}
+		case tok.text == ",":
+		case parens && tok.text == ")":
+			return ref, i
+		default:
+			return ref, i - 1
+		}
+		i++
+	}
+	return ref, i - 1
+}
+
+// pythonPackage returns the dotted package a Python file belongs to, found by
+// walking up the directories that contain an __init__.py
+func pythonPackage(file string, packageDirs map[string]bool) string {
+	var parts []string
+	for dir := filepath.Dir(file); packageDirs[dir]; dir = filepath.Dir(dir) {
+		parts = append([]string{filepath.Base(dir)}, parts...)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePythonImports(t *testing.T) {
+	src := `import os, sys as system
+from . import sibling
+from ..pkg.mod import (a,
+    b as c)
+from x import *
+"""
+import fake
+"""
+if TYPE_CHECKING:
+    from typing_mod import T
+def f():
+    import lazy
+s = 'import nope'  # import nope2
+import a.b.c
+`
+	want := []ImportRef{
+		{Path: "os", Line: 1},
+		{Path: "sys", Alias: "system", Line: 1},
+		{Path: ".", Names: []string{"sibling"}, Line: 2},
+		{Path: "..pkg.mod", Names: []string{"a", "b"}, Line: 3},
+		{Path: "x", Names: []string{"*"}, Line: 5},
+		{Path: "typing_mod", Kind: "type", Names: []string{"T"}, Line: 10},
+		{Path: "lazy", Line: 12},
+		{Path: "a.b.c", Line: 14},
+	}
+	if got := parsePythonImports([]byte(src)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePythonImports:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func FuzzParsePythonImports(f *testing.F) {
+	for _, seed := range []string{
+		"from . import (a,\n  b)\n",
+		"if TYPE_CHECKING:\n    import x\n",
+		"s = '''unterminated\nimport y\n",
+		"from",
+		"x = \\\n  1\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, content []byte) {
+		parsePythonImports(content)
+	})
+}
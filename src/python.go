@@ -0,0 +1,412 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pythonRequirement is a parsed PEP 508 requirement
+type pythonRequirement struct {
+	Name      string
+	Extras    []string
+	Specifier string
+	URL       string
+	Markers   string
+}
+
+var (
+	pep508Head     = regexp.MustCompile(`^([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)\s*(?:\[([^\]]*)\])?\s*(.*)$`)
+	setupPyList    = regexp.MustCompile(`\b(install_requires|tests_require|setup_requires)\s*=\s*(\[|[A-Za-z_]\w*)`)
+	setupPyExtras  = regexp.MustCompile(`\bextras_require\s*=\s*(\{|[A-Za-z_]\w*)`)
+	setupPyMeta    = regexp.MustCompile(`\b(name|version)\s*=\s*["']([^"']+)["']`)
+	setupPyStrings = regexp.MustCompile(`["']([^"']*)["']`)
+)
+
+// parsePEP508 parses a dependency specification such as
+// `requests[socks] (>=2.8) ; python_version < "3.8"` or `pkg @ git+https://...`
+func parsePEP508(spec string) (pythonRequirement, bool) {
+	match := pep508Head.FindStringSubmatch(strings.TrimSpace(spec))
+	if match == nil {
+		return pythonRequirement{}, false
+	}
+	req := pythonRequirement{Name: match[1]}
+	for _, extra := range strings.Split(match[2], ",") {
+		if extra = strings.TrimSpace(extra); extra != "" {
+			req.Extras = append(req.Extras, extra)
+		}
+	}
+
+	rest := strings.TrimSpace(match[3])
+	if strings.HasPrefix(rest, "@") {
+		// In the URL form the marker separator must be preceded by whitespace
+		rest = strings.TrimSpace(rest[1:])
+		if idx := strings.Index(rest, " ;"); idx >= 0 {
+			req.Markers = strings.TrimSpace(rest[idx+2:])
+			rest = rest[:idx]
+		}
+		req.URL = strings.TrimSpace(rest)
+		return req, true
+	}
+
+	if idx := strings.Index(rest, ";"); idx >= 0 {
+		req.Markers = strings.TrimSpace(rest[idx+1:])
+		rest = rest[:idx]
+	}
+	rest = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(rest), "("), ")")
+	req.Specifier = strings.Join(strings.Fields(rest), "")
+	return req, true
+}
+
+// addPythonRequirement records a parsed requirement under its PEP 503 name
+func (m *Manifest) addPythonRequirement(group string, req pythonRequirement) *Dependency {
+	dep := m.addDependency(group, normalizePythonName(req.Name), req.Specifier)
+	dep.Extras = req.Extras
+	dep.Markers = req.Markers
+	if req.URL != "" {
+		dep.URL = req.URL
+		dep.Source = urlSource(req.URL)
+	}
+	return dep
+}
+
+// urlSource classifies where a direct-reference dependency comes from
+func urlSource(url string) string {
+	switch {
+	case strings.HasPrefix(url, "git+") || strings.HasSuffix(url, ".git"):
+		return "git"
+	case strings.HasPrefix(url, "hg+") || strings.HasPrefix(url, "svn+") || strings.HasPrefix(url, "bzr+"):
+		return "vcs"
+	case strings.HasPrefix(url, "file:") || strings.HasPrefix(url, ".") || strings.HasPrefix(url, "/"):
+		return "path"
+	}
+	return "url"
+}
+
+// addPythonRequirements records a list of PEP 508 strings in one group
+func (m *Manifest) addPythonRequirements(group string, specs []string) []*Dependency {
+	var deps []*Dependency
+	for _, spec := range specs {
+		if req, ok := parsePEP508(spec); ok {
+			deps = append(deps, m.addPythonRequirement(group, req))
+		}
+	}
+	return deps
+}
+
+// parsePyproject parses pyproject.toml: PEP 621 metadata, PEP 735 dependency
+// groups, and the Poetry, PDM and Hatch tool tables
+func (c *Crawler) parsePyproject(content []byte, m *Manifest) {
+	doc, err := parseTOML(content)
+	if err != nil {
+		return
+	}
+	tool := tomlTable(doc, "tool")
+
+	switch {
+	case tomlTable(tool, "poetry") != nil:
+		m.Manager = "poetry"
+	case tomlTable(tool, "pdm") != nil:
+		m.Manager = "pdm"
+	case tomlTable(tool, "hatch") != nil:
+		m.Manager = "hatch"
+	}
+
+	if project := tomlTable(doc, "project"); project != nil {
+		m.Name = tomlString(project, "name")
+		m.Version = tomlString(project, "version")
+		m.addPythonRequirements("dependencies", tomlStrings(project, "dependencies"))
+		optional := tomlTable(project, "optional-dependencies")
+		for _, extra := range sortedKeys(optional) {
+			for _, dep := range m.addPythonRequirements("optional-dependencies."+extra, tomlStrings(optional, extra)) {
+				dep.Optional = true
+			}
+		}
+	}
+
+	groups := tomlTable(doc, "dependency-groups")
+	for _, name := range sortedKeys(groups) {
+		m.addPythonRequirements("dependency-groups."+name, dependencyGroup(groups, name, make(map[string]bool)))
+	}
+
+	if poetry := tomlTable(tool, "poetry"); poetry != nil {
+		if m.Name == "" {
+			m.Name = tomlString(poetry, "name")
+			m.Version = tomlString(poetry, "version")
+		}
+		addPoetryDependencies(m, "dependencies", tomlTable(poetry, "dependencies"))
+		addPoetryDependencies(m, "dev-dependencies", tomlTable(poetry, "dev-dependencies"))
+		poetryGroups := tomlTable(poetry, "group")
+		for _, name := range sortedKeys(poetryGroups) {
+			addPoetryDependencies(m, "group."+name, tomlTable(poetryGroups, name, "dependencies"))
+		}
+	}
+
+	pdmDev := tomlTable(tool, "pdm", "dev-dependencies")
+	for _, name := range sortedKeys(pdmDev) {
+		m.addPythonRequirements("dev-dependencies."+name, tomlStrings(pdmDev, name))
+	}
+
+	hatchEnvs := tomlTable(tool, "hatch", "envs")
+	for _, name := range sortedKeys(hatchEnvs) {
+		env := tomlTable(hatchEnvs, name)
+		m.addPythonRequirements("envs."+name, append(tomlStrings(env, "dependencies"), tomlStrings(env, "extra-dependencies")...))
+	}
+}
+
+// dependencyGroup expands a PEP 735 group, following {include-group = "..."}
+func dependencyGroup(groups map[string]interface{}, name string, visiting map[string]bool) []string {
+	if visiting[name] {
+		return nil
+	}
+	visiting[name] = true
+
+	var specs []string
+	entries, _ := groups[name].([]interface{})
+	for _, entry := range entries {
+		switch e := entry.(type) {
+		case string:
+			specs = append(specs, e)
+		case map[string]interface{}:
+			if include := tomlString(e, "include-group"); include != "" {
+				specs = append(specs, dependencyGroup(groups, include, visiting)...)
+			}
+		}
+	}
+	return specs
+}
+
+// addPoetryDependencies records a Poetry dependency table; values are either
+// a constraint string, a table, or a list of tables with alternative markers
+func addPoetryDependencies(m *Manifest, group string, table map[string]interface{}) {
+	for _, name := range sortedKeys(table) {
+		if strings.EqualFold(name, "python") {
+			continue // the interpreter constraint, not a package
+		}
+
+		spec := table[name]
+		if alternatives, ok := spec.([]interface{}); ok && len(alternatives) > 0 {
+			spec = alternatives[0]
+		}
+
+		switch v := spec.(type) {
+		case string:
+			m.addDependency(group, normalizePythonName(name), v)
+		case map[string]interface{}:
+			dep := m.addDependency(group, normalizePythonName(name), tomlString(v, "version"))
+			dep.Extras = tomlStrings(v, "extras")
+			dep.Markers = tomlString(v, "markers")
+			dep.Optional, _ = v["optional"].(bool)
+			for _, key := range []string{"git", "path", "url"} {
+				if url := tomlString(v, key); url != "" {
+					dep.URL = url
+					dep.Source = key
+				}
+			}
+		}
+	}
+}
+
+// parsePipfile parses a Pipfile; every table other than the source, requires,
+// scripts and pipenv settings is a package category
+func (c *Crawler) parsePipfile(content []byte, m *Manifest) {
+	doc, err := parseTOML(content)
+	if err != nil {
+		return
+	}
+	for _, category := range sortedKeys(doc) {
+		if category == "source" || category == "requires" || category == "scripts" || category == "pipenv" {
+			continue
+		}
+		packages, ok := doc[category].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, name := range sortedKeys(packages) {
+			switch v := packages[name].(type) {
+			case string:
+				m.addDependency(category, normalizePythonName(name), v)
+			case map[string]interface{}:
+				dep := m.addDependency(category, normalizePythonName(name), tomlString(v, "version"))
+				dep.Extras = tomlStrings(v, "extras")
+				dep.Markers = tomlString(v, "markers")
+				for _, key := range []string{"git", "path", "file"} {
+					if url := tomlString(v, key); url != "" {
+						dep.URL = url
+						dep.Source = key
+					}
+				}
+				if dep.Source == "file" {
+					dep.Source = urlSource(dep.URL)
+				}
+			}
+		}
+	}
+}
+
+// parseSetupCfg parses the setuptools metadata and options of setup.cfg
+func (c *Crawler) parseSetupCfg(content []byte, m *Manifest) {
+	cfg := parseINI(string(content))
+	m.Name = cfg["metadata"]["name"]
+	m.Version = cfg["metadata"]["version"]
+
+	options := cfg["options"]
+	for _, key := range []string{"install_requires", "tests_require", "setup_requires"} {
+		m.addPythonRequirements(key, iniList(options[key]))
+	}
+	extras := cfg["options.extras_require"]
+	for _, extra := range sortedKeys(extras) {
+		for _, dep := range m.addPythonRequirements("extras_require."+extra, iniList(extras[extra])) {
+			dep.Optional = true
+		}
+	}
+}
+
+// parseINI reads an INI file into section -> key -> value, joining indented
+// continuation lines with newlines
+func parseINI(content string) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	section, key := "", ""
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';' {
+			continue
+		}
+		if key != "" && (line[0] == ' ' || line[0] == '\t') {
+			sections[section][key] += "\n" + trimmed
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section, key = strings.TrimSpace(trimmed[1:len(trimmed)-1]), ""
+			if sections[section] == nil {
+				sections[section] = make(map[string]string)
+			}
+			continue
+		}
+		if idx := strings.IndexAny(trimmed, "=:"); idx > 0 && sections[section] != nil {
+			key = strings.TrimSpace(trimmed[:idx])
+			sections[section][key] = strings.TrimSpace(trimmed[idx+1:])
+		}
+	}
+	return sections
+}
+
+// iniList splits a multi-line setup.cfg value, dropping comments
+func iniList(value string) []string {
+	var items []string
+	for _, line := range strings.Split(value, "\n") {
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = line[:idx]
+		}
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			items = append(items, line)
+		}
+	}
+	return items
+}
+
+// parseSetupPy extracts literal install_requires, tests_require, setup_requires
+// and extras_require values from setup.py, following simple module-level names
+func (c *Crawler) parseSetupPy(content []byte, m *Manifest) {
+	src := string(content)
+	if match := setupPyMeta.FindAllStringSubmatch(src, -1); match != nil {
+		for _, meta := range match {
+			if meta[1] == "name" && m.Name == "" {
+				m.Name = meta[2]
+			} else if meta[1] == "version" && m.Version == "" {
+				m.Version = meta[2]
+			}
+		}
+	}
+
+	for _, match := range setupPyList.FindAllStringSubmatchIndex(src, -1) {
+		key := src[match[2]:match[3]]
+		if list := pythonLiteral(src, match[4], '[', ']'); list != "" {
+			m.addPythonRequirements(key, pythonStrings(list))
+		}
+	}
+
+	if match := setupPyExtras.FindStringSubmatchIndex(src); match != nil {
+		dict := pythonLiteral(src, match[2], '{', '}')
+		for _, entry := range splitTopLevel(strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(dict, "{"), "}")), ',') {
+			colon := strings.Index(entry, ":")
+			if colon < 0 {
+				continue
+			}
+			extra, ok := quotedString(entry[:colon])
+			if !ok {
+				continue
+			}
+			value := strings.TrimSpace(entry[colon+1:])
+			if strings.HasPrefix(value, "[") {
+				value = pythonLiteral(value, 0, '[', ']')
+			} else if s, ok := quotedString(value); ok {
+				value = `"` + s + `"`
+			}
+			for _, dep := range m.addPythonRequirements("extras_require."+extra, pythonStrings(value)) {
+				dep.Optional = true
+			}
+		}
+	}
+}
+
+// pythonLiteral returns the bracketed literal starting at offset; when the
+// value is a name instead, it follows the name's module-level assignment
+func pythonLiteral(src string, offset int, open, close byte) string {
+	if offset >= len(src) {
+		return ""
+	}
+	if src[offset] != open {
+		end := offset
+		for end < len(src) && isIdentByte(src[end]) && src[end] != '.' {
+			end++
+		}
+		assign := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(src[offset:end]) + `\s*=\s*\` + string(open))
+		loc := assign.FindStringIndex(src)
+		if loc == nil {
+			return ""
+		}
+		offset = loc[1] - 1
+	}
+
+	depth := 0
+	var quote byte
+	for i := offset; i < len(src); i++ {
+		ch := src[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case ch == open:
+			depth++
+		case ch == close:
+			depth--
+			if depth == 0 {
+				return src[offset : i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// pythonStrings returns the string literals of a Python list literal
+func pythonStrings(literal string) []string {
+	var out []string
+	for _, line := range strings.Split(literal, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 && !strings.ContainsAny(line[:idx], `"'`) {
+			line = line[:idx]
+		}
+		for _, match := range setupPyStrings.FindAllStringSubmatch(line, -1) {
+			out = append(out, match[1])
+		}
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePEP508(t *testing.T) {
+	tests := []struct {
+		spec string
+		want pythonRequirement
+	}{
+		{"requests", pythonRequirement{Name: "requests"}},
+		{"requests[socks, security] (>= 2.8, < 3)", pythonRequirement{Name: "requests", Extras: []string{"socks", "security"}, Specifier: ">=2.8,<3"}},
+		{`pywin32 >= 1.0 ; sys_platform == "win32"`, pythonRequirement{Name: "pywin32", Specifier: ">=1.0", Markers: `sys_platform == "win32"`}},
+		{"pkg @ https://example.com/pkg.zip ; python_version < '3.9'", pythonRequirement{Name: "pkg", URL: "https://example.com/pkg.zip", Markers: "python_version < '3.9'"}},
+		{"pkg @ https://example.com/pkg.zip;v=1", pythonRequirement{Name: "pkg", URL: "https://example.com/pkg.zip;v=1"}},
+	}
+	for _, tt := range tests {
+		got, ok := parsePEP508(tt.spec)
+		if !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePEP508(%q) = %+v, %v, want %+v", tt.spec, got, ok, tt.want)
+		}
+	}
+	if _, ok := parsePEP508("-e ."); ok {
+		t.Errorf("parsePEP508 accepted an option line")
+	}
+}
+
+func TestPythonManifests(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"a/pyproject.toml": `[project]
+name = "a"
+version = "0.1.0"
+dependencies = ["requests[socks]>=2.31 ; python_version >= '3.8'", "pkg @ git+https://example.com/pkg.git"]
+
+[project.optional-dependencies]
+test = ["pytest>=7"]
+
+[dependency-groups]
+dev = ["ruff", {include-group = "lint"}]
+lint = ["mypy"]
+`,
+		"b/pyproject.toml": `[tool.poetry]
+name = "b"
+version = "1.0"
+
+[tool.poetry.dependencies]
+python = "^3.10"
+Django = { version = "^4.2", extras = ["argon2"] }
+local = { path = "../local" }
+
+[tool.poetry.group.dev.dependencies]
+pytest = "^7.0"
+`,
+		"c/setup.cfg": `[metadata]
+name = c
+version = 2.0
+
+[options]
+install_requires =
+    click>=8
+    attrs
+
+[options.extras_require]
+docs = sphinx
+`,
+		"d/setup.py": `from setuptools import setup
+
+REQS = ['numpy>=1.24', "pandas"]
+
+setup(
+    name='d',
+    version='3.0',
+    install_requires=REQS,
+    extras_require={'plot': ['matplotlib']},
+)
+`,
+		"e/Pipfile": `[packages]
+flask = "*"
+requests = {version = ">=2.0", extras = ["socks"]}
+mylib = {git = "https://github.com/org/mylib.git", ref = "main"}
+
+[dev-packages]
+pytest = "==7.4.0"
+`,
+	})
+
+	tests := []struct {
+		path    string
+		manager string
+		name    string
+		deps    []string
+	}{
+		{"a/pyproject.toml", "pip", "a", []string{
+			"dependencies requests >=2.31",
+			"dependencies pkg ",
+			"optional-dependencies.test pytest >=7",
+			"dependency-groups.dev ruff ",
+			"dependency-groups.dev mypy ",
+			"dependency-groups.lint mypy ",
+		}},
+		{"b/pyproject.toml", "poetry", "b", []string{
+			"dependencies django ^4.2",
+			"dependencies local ",
+			"group.dev pytest ^7.0",
+		}},
+		{"c/setup.cfg", "pip", "c", []string{
+			"install_requires click >=8",
+			"install_requires attrs ",
+			"extras_require.docs sphinx ",
+		}},
+		{"d/setup.py", "pip", "d", []string{
+			"install_requires numpy >=1.24",
+			"install_requires pandas ",
+			"extras_require.plot matplotlib ",
+		}},
+		{"e/Pipfile", "pipenv", "", []string{
+			"dev-packages pytest ==7.4.0",
+			"packages flask *",
+			"packages mylib ",
+			"packages requests >=2.0",
+		}},
+	}
+	for _, tt := range tests {
+		m := findManifest(c, root, tt.path)
+		if m == nil {
+			t.Errorf("%s: no manifest", tt.path)
+			continue
+		}
+		if m.Manager != tt.manager || m.Name != tt.name {
+			t.Errorf("%s: %s %q, want %s %q", tt.path, m.Manager, m.Name, tt.manager, tt.name)
+		}
+		if got := manifestDeps(m); !reflect.DeepEqual(got, tt.deps) {
+			t.Errorf("%s: dependencies = %q, want %q", tt.path, got, tt.deps)
+		}
+	}
+
+	a := findManifest(c, root, "a/pyproject.toml")
+	if dep := a.Dependencies[0]; dep.Markers != "python_version >= '3.8'" || !reflect.DeepEqual(dep.Extras, []string{"socks"}) {
+		t.Errorf("requests: markers %q, extras %v", dep.Markers, dep.Extras)
+	}
+	if dep := a.Dependencies[1]; dep.Source != "git" || dep.URL != "git+https://example.com/pkg.git" {
+		t.Errorf("pkg: source %q, url %q", dep.Source, dep.URL)
+	}
+	if dep := findManifest(c, root, "b/pyproject.toml").Dependencies[1]; dep.Source != "path" || dep.URL != "../local" {
+		t.Errorf("local: source %q, url %q", dep.Source, dep.URL)
+	}
+}
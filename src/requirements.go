@@ -0,0 +1,207 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// requirementsFile is one pip requirements file being read
+type requirementsFile struct {
+	manifest    *Manifest
+	visited     map[string]bool
+	constraints map[string]string // PEP 503 name -> specifier from -c files
+}
+
+var (
+	requirementOption  = regexp.MustCompile(`^(-[A-Za-z]|--[a-z][a-z-]*)(?:[= ]\s*(.*))?$`)
+	requirementComment = regexp.MustCompile(`(^|\s)#.*$`)
+	requirementTrailer = regexp.MustCompile(`\s+--[a-z][a-z-]*(?:[= ]\S+)?`)
+	eggFragment        = regexp.MustCompile(`[#&]egg=([^&\s]+)`)
+	archiveName        = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._]*?)-\d[^/]*\.(?:whl|tar\.gz|zip|tar\.bz2|tgz)$`)
+	urlScheme          = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://`)
+)
+
+// parseRequirementsTxt parses a pip requirements file, following -r includes
+// and applying -c constraints relative to the file
+func (c *Crawler) parseRequirementsTxt(content []byte, m *Manifest) {
+	req := &requirementsFile{
+		manifest:    m,
+		visited:     map[string]bool{m.Path: true},
+		constraints: make(map[string]string),
+	}
+	req.parse(m.Path, content, "dependencies", false)
+
+	for _, dep := range m.Dependencies {
+		spec, ok := req.constraints[dep.Name]
+		if !ok || spec == "" || spec == dep.Version {
+			continue
+		}
+		if dep.Version == "" {
+			dep.Version = spec
+		} else {
+			dep.Version += "," + spec
+		}
+	}
+}
+
+// parse reads one requirements or constraints file; lines of a constraints
+// file only narrow the versions of packages required elsewhere
+func (r *requirementsFile) parse(filePath string, content []byte, group string, constraints bool) {
+	for _, line := range requirementLines(string(content)) {
+		if match := requirementOption.FindStringSubmatch(line); match != nil {
+			r.option(filePath, match[1], strings.TrimSpace(match[2]), group, constraints)
+			continue
+		}
+
+		req, ok := parseRequirementLine(line)
+		if !ok {
+			continue
+		}
+		req = locateRequirement(filePath, req)
+		if constraints {
+			r.constraints[normalizePythonName(req.Name)] = req.Specifier
+			continue
+		}
+		r.manifest.addPythonRequirement(group, req)
+	}
+}
+
+// option handles a global option line such as -r, -c or -e
+func (r *requirementsFile) option(filePath, flag, value, group string, constraints bool) {
+	switch flag {
+	case "-r", "--requirement", "-c", "--constraint":
+		if value == "" || strings.Contains(value, "://") {
+			return
+		}
+		included := filepath.Join(filepath.Dir(filePath), filepath.FromSlash(value))
+		if r.visited[included] {
+			return
+		}
+		r.visited[included] = true
+		content, err := os.ReadFile(included)
+		if err != nil {
+			return
+		}
+		if flag == "-c" || flag == "--constraint" {
+			r.parse(included, content, group, true)
+			return
+		}
+		// Dependencies of an included file are grouped by its name, so that
+		// requirements-dev.txt lands in a dev group
+		r.parse(included, content, strings.TrimSuffix(filepath.Base(included), filepath.Ext(included)), constraints)
+
+	case "-e", "--editable":
+		req, ok := parseRequirementLine(value)
+		if !ok || constraints {
+			return
+		}
+		dep := r.manifest.addPythonRequirement(group, locateRequirement(filePath, req))
+		dep.Editable = true
+	}
+}
+
+// locateRequirement names a local path requirement such as `.` or `../lib`
+// after the directory it points to
+func locateRequirement(filePath string, req pythonRequirement) pythonRequirement {
+	if req.Name == "" && req.URL != "" {
+		req.Name = filepath.Base(filepath.Join(filepath.Dir(filePath), filepath.FromSlash(strings.TrimPrefix(req.URL, "file:"))))
+	}
+	return req
+}
+
+// requirementLines joins backslash continuations and strips comments
+func requirementLines(content string) []string {
+	var lines []string
+	var cur strings.Builder
+	for _, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if strings.HasSuffix(raw, "\\") {
+			cur.WriteString(strings.TrimSuffix(raw, "\\"))
+			continue
+		}
+		cur.WriteString(raw)
+		line := strings.TrimSpace(requirementComment.ReplaceAllString(cur.String(), ""))
+		cur.Reset()
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if line := strings.TrimSpace(requirementComment.ReplaceAllString(cur.String(), "")); line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseRequirementLine parses a requirement specifier, URL or local path,
+// dropping per-requirement options such as --hash
+func parseRequirementLine(line string) (pythonRequirement, bool) {
+	line = strings.TrimSpace(requirementTrailer.ReplaceAllString(" "+line, ""))
+	if line == "" {
+		return pythonRequirement{}, false
+	}
+
+	if !isRequirementLocation(line) {
+		return parsePEP508(line)
+	}
+
+	// A URL or path, optionally followed by extras and markers
+	location, markers := line, ""
+	if idx := strings.Index(line, " ;"); idx >= 0 {
+		location, markers = strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+2:])
+	} else if idx := strings.Index(line, ";"); idx >= 0 && !strings.Contains(line, "://") {
+		location, markers = strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:])
+	}
+	req := pythonRequirement{URL: location, Markers: markers}
+
+	if open := strings.LastIndex(location, "["); open > 0 && strings.HasSuffix(location, "]") && !strings.Contains(location[open:], "/") {
+		for _, extra := range strings.Split(location[open+1:len(location)-1], ",") {
+			if extra = strings.TrimSpace(extra); extra != "" {
+				req.Extras = append(req.Extras, extra)
+			}
+		}
+		req.URL = location[:open]
+	}
+	req.Name = requirementLocationName(req.URL)
+	return req, true
+}
+
+// isRequirementLocation reports whether a requirement line is a URL or path
+// rather than a PEP 508 name
+func isRequirementLocation(line string) bool {
+	// "name @ url" is PEP 508; a URL, even one with credentials, starts with its scheme
+	if urlScheme.MatchString(line) {
+		return true
+	}
+	for _, prefix := range []string{"git+", "hg+", "svn+", "bzr+", "file:", ".", "/", "~"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requirementLocationName derives the project name of a URL or path
+// requirement from its #egg= fragment or archive file name
+func requirementLocationName(location string) string {
+	if match := eggFragment.FindStringSubmatch(location); match != nil {
+		return match[1]
+	}
+	base := location
+	if u, err := url.Parse(location); err == nil && u.Path != "" {
+		base = u.Path
+	}
+	base = path.Base(strings.TrimSuffix(filepath.ToSlash(base), "/"))
+	if match := archiveName.FindStringSubmatch(base); match != nil {
+		return match[1]
+	}
+	if strings.HasPrefix(location, "git+") || strings.HasSuffix(base, ".git") {
+		return strings.TrimSuffix(strings.SplitN(base, "@", 2)[0], ".git")
+	}
+	if base == "." || base == ".." || base == "/" {
+		return ""
+	}
+	return base
+}
@@ -0,0 +1,621 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Import classes
+const (
+	importInternal = "internal"
+	importExternal = "external"
+	importStdlib   = "stdlib"
+)
+
+// ImportEdge is one resolved import, from a source file to a repository file
+// (internal) or to the raw import string (external, stdlib)
+type ImportEdge struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Import string   `json:"import"`
+	Class  string   `json:"class"`
+	Line   int      `json:"line"`
+	Cycles []string `json:"cycles,omitempty"` // levels at which the edge lies on an import cycle
+}
+
+// importResolver maps import strings to the repository files they refer to
+type importResolver struct {
+	fileLang   map[string]string   // file path -> language
+	dirFiles   map[string][]string // directory -> files directly inside it
+	goModules  []goModuleRoot
+	namespaces map[string][]string  // language + "|" + namespace -> files
+	pyModules  map[string][]string  // slash-separated module path suffix -> files
+	pyPackages map[string][]string  // dotted module name within its package -> files
+	rbFiles    map[string][]string  // require path suffix -> files
+	rustCrates map[string]string    // crate name (underscored) -> crate src root file
+	tsConfigs  map[string]*tsConfig // tsconfig path -> loaded config, nil if unreadable
+	tsNearest  map[string]*tsConfig // directory -> nearest tsconfig.json
+}
+
+// goModuleRoot is a Go module found in the repository
+type goModuleRoot struct {
+	Path string
+	Dir  string
+}
+
+// resolveImports classifies every recorded import and links internal imports to files
+func (c *Crawler) resolveImports() {
+	r := c.newImportResolver()
+	deps := c.Analysis.Dependencies
+	deps.ImportEdges = []ImportEdge{}
+
+	var files []string
+	for file := range deps.FileImports {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		refs := deps.FileImports[file]
+		for i := range refs {
+			ref := &refs[i]
+			ref.Class, ref.Package, ref.Resolved = r.resolve(file, ref)
+
+			if len(ref.Resolved) == 0 {
+				to := ref.Path
+				if ref.Package != "" {
+					to = ref.Package
+				}
+				deps.ImportEdges = append(deps.ImportEdges, ImportEdge{
+					From: file, To: to, Import: ref.Path, Class: ref.Class, Line: ref.Line,
+				})
+				continue
+			}
+
+			for _, target := range ref.Resolved {
+				deps.ImportEdges = append(deps.ImportEdges, ImportEdge{
+					From: file, To: target, Import: ref.Path, Class: ref.Class, Line: ref.Line,
+				})
+			}
+		}
+	}
+}
+
+// newImportResolver indexes the scanned files for import resolution
+func (c *Crawler) newImportResolver() *importResolver {
+	r := &importResolver{
+		fileLang:   make(map[string]string),
+		dirFiles:   make(map[string][]string),
+		namespaces: make(map[string][]string),
+		pyModules:  make(map[string][]string),
+		pyPackages: make(map[string][]string),
+		rbFiles:    make(map[string][]string),
+		rustCrates: make(map[string]string),
+		tsConfigs:  make(map[string]*tsConfig),
+		tsNearest:  make(map[string]*tsConfig),
+	}
+
+	// Every file must be known before manifests look for their sources
+	for lang, files := range c.Analysis.FilesByType {
+		for _, file := range files {
+			r.fileLang[file.Path] = lang
+			dir := filepath.Dir(file.Path)
+			r.dirFiles[dir] = append(r.dirFiles[dir], file.Path)
+		}
+	}
+
+	var langs []string
+	for lang := range c.Analysis.FilesByType {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		for _, file := range c.Analysis.FilesByType[lang] {
+			dir := filepath.Dir(file.Path)
+			switch file.Name {
+			case "go.mod":
+				if content, err := os.ReadFile(file.Path); err == nil {
+					if modPath := goModulePath(content); modPath != "" {
+						r.goModules = append(r.goModules, goModuleRoot{Path: modPath, Dir: dir})
+					}
+				}
+			case "tsconfig.json":
+				if cfg := r.tsConfig(file.Path); cfg != nil {
+					r.tsProjects(cfg)
+				}
+			case "Cargo.toml":
+				if content, err := os.ReadFile(file.Path); err == nil {
+					if name := cargoPackageName(content); name != "" {
+						if root := rustCrateRoot(dir, r.fileExists); root != "" {
+							r.rustCrates[strings.ReplaceAll(name, "-", "_")] = root
+						}
+					}
+				}
+			}
+
+			switch lang {
+			case "Python":
+				r.indexPythonFile(file.Path, c.Analysis.Dependencies.FileNamespaces[file.Path])
+			case "Ruby":
+				indexSuffixes(r.rbFiles, strings.TrimSuffix(filepath.ToSlash(file.Path), ".rb"), file.Path)
+			}
+		}
+	}
+
+	for file, ns := range c.Analysis.Dependencies.FileNamespaces {
+		key := r.fileLang[file] + "|" + ns
+		r.namespaces[key] = append(r.namespaces[key], file)
+	}
+
+	for _, files := range r.dirFiles {
+		sort.Strings(files)
+	}
+	for _, files := range r.namespaces {
+		sort.Strings(files)
+	}
+
+	// Longest module path wins for nested modules
+	sort.Slice(r.goModules, func(i, j int) bool {
+		return len(r.goModules[i].Path) > len(r.goModules[j].Path)
+	})
+
+	return r
+}
+
+// indexPythonFile registers every dotted-name suffix under which a module may
+// be imported, and its fully qualified name within its package
+func (r *importResolver) indexPythonFile(path, pkg string) {
+	module := strings.TrimSuffix(filepath.ToSlash(path), ".py")
+	module = strings.TrimSuffix(module, "/__init__")
+	indexSuffixes(r.pyModules, module, path)
+
+	name := strings.TrimSuffix(filepath.Base(path), ".py")
+	switch {
+	case name == "__init__":
+		name = pkg
+	case pkg != "":
+		name = pkg + "." + name
+	}
+	if name != "" {
+		r.pyPackages[name] = append(r.pyPackages[name], path)
+	}
+}
+
+// indexSuffixes records file under each trailing run of the segments of modulePath
+func indexSuffixes(index map[string][]string, modulePath, file string) {
+	segments := strings.Split(strings.TrimPrefix(modulePath, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		key := strings.Join(segments[i:], "/")
+		index[key] = append(index[key], file)
+	}
+}
+
+// fileExists reports whether path was part of the scan
+func (r *importResolver) fileExists(path string) bool {
+	_, ok := r.fileLang[path]
+	return ok
+}
+
+// resolve returns the class of an import, the package directory or namespace it
+// names (if any) and the repository files it resolves to
+func (r *importResolver) resolve(fromFile string, ref *ImportRef) (string, string, []string) {
+	importPath := ref.Path
+	switch r.fileLang[fromFile] {
+	case "Go":
+		return r.resolveGo(importPath)
+	case "JavaScript", "TypeScript":
+		return r.resolveJS(fromFile, importPath)
+	case "Python":
+		return r.resolvePython(fromFile, importPath, ref.Names)
+	case "Java":
+		return r.resolveJava(importPath)
+	case "C#":
+		return r.resolveCSharp(importPath)
+	case "Rust":
+		return r.resolveRust(fromFile, importPath)
+	case "Ruby":
+		return r.resolveRuby(fromFile, importPath)
+	}
+	return importExternal, "", nil
+}
+
+// resolveGo maps an import path onto a package directory of a module in the repository
+func (r *importResolver) resolveGo(importPath string) (string, string, []string) {
+	for _, mod := range r.goModules {
+		if importPath != mod.Path && !strings.HasPrefix(importPath, mod.Path+"/") {
+			continue
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(importPath, mod.Path), "/")
+		dir := filepath.Join(mod.Dir, filepath.FromSlash(rel))
+
+		var files []string
+		for _, file := range r.dirFiles[dir] {
+			if r.fileLang[file] == "Go" && !strings.HasSuffix(file, "_test.go") {
+				files = append(files, file)
+			}
+		}
+		return importInternal, dir, files
+	}
+
+	// Standard library paths have no dot in their first element
+	first := strings.SplitN(importPath, "/", 2)[0]
+	if !strings.Contains(first, ".") {
+		return importStdlib, "", nil
+	}
+	return importExternal, "", nil
+}
+
+// jsExtensions are tried in order when a relative JS/TS import omits the extension
+var jsExtensions = []string{".ts", ".tsx", ".d.ts", ".js", ".jsx", ".mjs", ".cjs", ".json", ".vue", ".svelte"}
+
+// resolveJS resolves relative module specifiers against the importing file
+func (r *importResolver) resolveJS(fromFile, spec string) (string, string, []string) {
+	if strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || spec == "." || spec == ".." {
+		target := filepath.Join(filepath.Dir(fromFile), filepath.FromSlash(spec))
+		if file := r.resolveTSPath(target); file != "" {
+			return importInternal, "", []string{file}
+		}
+		return importInternal, "", nil
+	}
+
+	// tsconfig.json paths, baseUrl and project references
+	if cfg := r.tsConfigFor(fromFile); cfg != nil {
+		if file := r.resolveTSConfig(cfg, spec); file != "" {
+			return importInternal, "", []string{file}
+		}
+	}
+
+	name := strings.TrimPrefix(spec, "node:")
+	if strings.HasPrefix(spec, "node:") || nodeBuiltins[strings.SplitN(name, "/", 2)[0]] {
+		return importStdlib, "", nil
+	}
+	return importExternal, "", nil
+}
+
+// resolveJSPath applies Node-style file, extension and index lookup to an absolute path
+func (r *importResolver) resolveJSPath(target string) string {
+	if r.fileExists(target) {
+		return target
+	}
+
+	// TypeScript sources are imported with the .js extension they compile to
+	ext := filepath.Ext(target)
+	base := target
+	if ext == ".js" || ext == ".jsx" || ext == ".mjs" || ext == ".cjs" {
+		base = strings.TrimSuffix(target, ext)
+	}
+
+	for _, e := range jsExtensions {
+		if r.fileExists(base + e) {
+			return base + e
+		}
+	}
+	for _, e := range jsExtensions {
+		index := filepath.Join(target, "index"+e)
+		if r.fileExists(index) {
+			return index
+		}
+	}
+	return ""
+}
+
+// resolvePython resolves dotted and relative module names to .py files or
+// packages; names imported from a package resolve to its submodules when
+// they are modules themselves
+func (r *importResolver) resolvePython(fromFile, module string, names []string) (string, string, []string) {
+	class, files := r.pythonModule(fromFile, module)
+	if class != importInternal || len(names) == 0 {
+		return class, "", files
+	}
+
+	var resolved []string
+	seen := make(map[string]bool)
+	add := func(targets []string) {
+		for _, target := range targets {
+			if !seen[target] {
+				seen[target] = true
+				resolved = append(resolved, target)
+			}
+		}
+	}
+	for _, name := range names {
+		if name != "*" {
+			submodule := module + "." + name
+			if strings.HasSuffix(module, ".") {
+				submodule = module + name
+			}
+			if _, targets := r.pythonModule(fromFile, submodule); len(targets) > 0 {
+				add(targets)
+				continue
+			}
+		}
+		add(files)
+	}
+	return importInternal, "", resolved
+}
+
+// pythonModule returns the class of a module name and the files it resolves to
+func (r *importResolver) pythonModule(fromFile, module string) (string, []string) {
+	if strings.HasPrefix(module, ".") {
+		level := len(module) - len(strings.TrimLeft(module, "."))
+		dir := filepath.Dir(fromFile)
+		for i := 1; i < level; i++ {
+			dir = filepath.Dir(dir)
+		}
+		rest := strings.TrimLeft(module, ".")
+		candidates := []string{filepath.Join(dir, "__init__.py")}
+		if rest != "" {
+			base := filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(rest, ".", "/")))
+			candidates = []string{base + ".py", filepath.Join(base, "__init__.py")}
+		}
+		for _, candidate := range candidates {
+			if r.fileExists(candidate) {
+				return importInternal, []string{candidate}
+			}
+		}
+		return importInternal, nil
+	}
+
+	// A repository file named like a standard library module does not shadow it
+	if pythonStdlib[strings.SplitN(module, ".", 2)[0]] {
+		return importStdlib, nil
+	}
+
+	// Fully qualified name of a module in an __init__.py package or at a root,
+	// then any module path ending in the dotted name
+	if matches := r.pyPackages[module]; len(matches) > 0 {
+		return importInternal, closestFiles(fromFile, matches)
+	}
+	if matches := r.pyModules[strings.ReplaceAll(module, ".", "/")]; len(matches) > 0 {
+		return importInternal, closestFiles(fromFile, matches)
+	}
+	return importExternal, nil
+}
+
+// resolveJava resolves class and package imports through the package clauses of Java files
+func (r *importResolver) resolveJava(importPath string) (string, string, []string) {
+	importPath = strings.TrimSuffix(importPath, ".")
+
+	// Whole-package (wildcard) import
+	if files := r.namespaces["Java|"+importPath]; len(files) > 0 {
+		return importInternal, importPath, files
+	}
+
+	// Class import; walk back over nested classes and static members
+	parts := strings.Split(importPath, ".")
+	for i := len(parts) - 1; i > 0; i-- {
+		pkg := strings.Join(parts[:i], ".")
+		for _, file := range r.namespaces["Java|"+pkg] {
+			if strings.TrimSuffix(filepath.Base(file), ".java") == parts[i] {
+				return importInternal, pkg, []string{file}
+			}
+		}
+	}
+
+	for _, prefix := range []string{"java.", "javax.", "jdk.", "sun.", "com.sun."} {
+		if strings.HasPrefix(importPath, prefix) {
+			return importStdlib, "", nil
+		}
+	}
+	return importExternal, "", nil
+}
+
+// resolveCSharp resolves using directives to the files declaring that namespace
+func (r *importResolver) resolveCSharp(namespace string) (string, string, []string) {
+	if files := r.namespaces["C#|"+namespace]; len(files) > 0 {
+		return importInternal, namespace, files
+	}
+	if namespace == "System" || strings.HasPrefix(namespace, "System.") || strings.HasPrefix(namespace, "Microsoft.") {
+		return importStdlib, "", nil
+	}
+	return importExternal, "", nil
+}
+
+// resolveRust resolves crate-relative, self/super and workspace crate paths to module files
+func (r *importResolver) resolveRust(fromFile, usePath string) (string, string, []string) {
+	segments := strings.Split(strings.Trim(usePath, ":"), "::")
+	if len(segments) == 0 || segments[0] == "" {
+		return importExternal, "", nil
+	}
+
+	switch segments[0] {
+	case "std", "core", "alloc", "proc_macro", "test":
+		return importStdlib, "", nil
+	case "crate":
+		root := rustCrateRoot(rustCrateDir(fromFile), r.fileExists)
+		return importInternal, "", r.rustModuleFile(filepath.Dir(root), root, segments[1:])
+	case "self", "super":
+		dir := rustModuleDir(fromFile)
+		for _, seg := range segments {
+			if seg == "super" {
+				dir = filepath.Dir(dir)
+			} else if seg != "self" {
+				break
+			}
+		}
+		rest := segments
+		for len(rest) > 0 && (rest[0] == "self" || rest[0] == "super") {
+			rest = rest[1:]
+		}
+		return importInternal, "", r.rustModuleFile(dir, "", rest)
+	}
+
+	if root, ok := r.rustCrates[segments[0]]; ok {
+		return importInternal, "", r.rustModuleFile(filepath.Dir(root), root, segments[1:])
+	}
+
+	// 2018-edition paths may name a sibling module directly
+	if files := r.rustModuleFile(rustModuleDir(fromFile), "", segments); len(files) > 0 {
+		return importInternal, "", files
+	}
+	return importExternal, "", nil
+}
+
+// rustModuleFile finds the file for the longest module prefix of segments under dir,
+// falling back to fallback (the crate root) when no module file matches
+func (r *importResolver) rustModuleFile(dir, fallback string, segments []string) []string {
+	for n := len(segments); n > 0; n-- {
+		base := filepath.Join(append([]string{dir}, segments[:n]...)...)
+		for _, candidate := range []string{base + ".rs", filepath.Join(base, "mod.rs")} {
+			if r.fileExists(candidate) {
+				return []string{candidate}
+			}
+		}
+	}
+	if fallback != "" {
+		return []string{fallback}
+	}
+	return nil
+}
+
+// rustModuleDir returns the directory holding the child modules of a Rust source file
+func rustModuleDir(file string) string {
+	dir := filepath.Dir(file)
+	switch filepath.Base(file) {
+	case "mod.rs", "lib.rs", "main.rs":
+		return dir
+	}
+	return filepath.Join(dir, strings.TrimSuffix(filepath.Base(file), ".rs"))
+}
+
+// rustCrateDir walks up from a source file to the directory containing Cargo.toml
+func rustCrateDir(file string) string {
+	dir := filepath.Dir(file)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "Cargo.toml")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return filepath.Dir(file)
+		}
+		dir = parent
+	}
+}
+
+// rustCrateRoot returns src/lib.rs or src/main.rs of the crate in crateDir
+func rustCrateRoot(crateDir string, exists func(string) bool) string {
+	for _, name := range []string{"lib.rs", "main.rs"} {
+		root := filepath.Join(crateDir, "src", name)
+		if exists(root) {
+			return root
+		}
+	}
+	return ""
+}
+
+// resolveRuby resolves require paths against the repository's .rb files
+func (r *importResolver) resolveRuby(fromFile, feature string) (string, string, []string) {
+	feature = strings.TrimSuffix(feature, ".rb")
+	if strings.HasPrefix(feature, "./") || strings.HasPrefix(feature, "../") {
+		target := filepath.Join(filepath.Dir(fromFile), filepath.FromSlash(feature)) + ".rb"
+		if r.fileExists(target) {
+			return importInternal, "", []string{target}
+		}
+		return importInternal, "", nil
+	}
+
+	if matches := r.rbFiles[feature]; len(matches) > 0 {
+		return importInternal, "", closestFiles(fromFile, matches)
+	}
+	if rubyStdlib[strings.SplitN(feature, "/", 2)[0]] {
+		return importStdlib, "", nil
+	}
+	return importExternal, "", nil
+}
+
+// closestFiles narrows candidate files to those sharing the longest directory
+// prefix with fromFile, so sibling projects in a monorepo don't cross-link
+func closestFiles(fromFile string, candidates []string) []string {
+	best := -1
+	var result []string
+	for _, candidate := range candidates {
+		n := commonPrefixLen(filepath.Dir(fromFile), filepath.Dir(candidate))
+		switch {
+		case n > best:
+			best = n
+			result = []string{candidate}
+		case n == best:
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+// commonPrefixLen counts the leading path segments two paths share
+func commonPrefixLen(a, b string) int {
+	as := strings.Split(filepath.ToSlash(a), "/")
+	bs := strings.Split(filepath.ToSlash(b), "/")
+	n := 0
+	for n < len(as) && n < len(bs) && as[n] == bs[n] {
+		n++
+	}
+	return n
+}
+
+// goModulePath returns the module path declared in go.mod content
+func goModulePath(content []byte) string {
+	for _, line := range parseGoModLines(content) {
+		if line.verb == "module" && len(line.args) > 0 {
+			return line.args[0]
+		}
+	}
+	return ""
+}
+
+// cargoPackageName returns the [package] name declared in Cargo.toml content
+func cargoPackageName(content []byte) string {
+	doc, err := parseTOML(content)
+	if err != nil {
+		return ""
+	}
+	return tomlString(tomlTable(doc, "package"), "name")
+}
+
+// nodeBuiltins are the Node.js core modules
+var nodeBuiltins = setOf(
+	"assert", "async_hooks", "buffer", "child_process", "cluster", "console", "constants",
+	"crypto", "dgram", "diagnostics_channel", "dns", "domain", "events", "fs", "http", "http2",
+	"https", "inspector", "module", "net", "os", "path", "perf_hooks", "process", "punycode",
+	"querystring", "readline", "repl", "stream", "string_decoder", "sys", "timers", "tls",
+	"trace_events", "tty", "url", "util", "v8", "vm", "wasi", "worker_threads", "zlib",
+)
+
+// pythonStdlib are the top-level modules of the Python 3 standard library
+var pythonStdlib = setOf(
+	"__future__", "abc", "argparse", "array", "ast", "asyncio", "atexit", "base64", "bdb",
+	"binascii", "bisect", "builtins", "bz2", "calendar", "cgi", "cmath", "cmd", "code",
+	"codecs", "collections", "colorsys", "compileall", "concurrent", "configparser",
+	"contextlib", "contextvars", "copy", "copyreg", "cProfile", "csv", "ctypes", "curses",
+	"dataclasses", "datetime", "dbm", "decimal", "difflib", "dis", "doctest", "email",
+	"encodings", "ensurepip", "enum", "errno", "faulthandler", "fcntl", "filecmp",
+	"fileinput", "fnmatch", "fractions", "ftplib", "functools", "gc", "getopt", "getpass",
+	"gettext", "glob", "graphlib", "grp", "gzip", "hashlib", "heapq", "hmac", "html", "http",
+	"imaplib", "importlib", "inspect", "io", "ipaddress", "itertools", "json", "keyword",
+	"linecache", "locale", "logging", "lzma", "mailbox", "marshal", "math", "mimetypes",
+	"mmap", "modulefinder", "msvcrt", "multiprocessing", "netrc", "numbers", "operator",
+	"optparse", "os", "pathlib", "pdb", "pickle", "pickletools", "pkgutil", "platform",
+	"plistlib", "poplib", "posix", "posixpath", "pprint", "profile", "pstats", "pty", "pwd",
+	"py_compile", "pyclbr", "pydoc", "queue", "quopri", "random", "re", "readline",
+	"reprlib", "resource", "rlcompleter", "runpy", "sched", "secrets", "select",
+	"selectors", "shelve", "shlex", "shutil", "signal", "site", "smtplib", "socket",
+	"socketserver", "sqlite3", "ssl", "stat", "statistics", "string", "stringprep",
+	"struct", "subprocess", "symtable", "sys", "sysconfig", "syslog", "tabnanny", "tarfile",
+	"tempfile", "termios", "textwrap", "threading", "time", "timeit", "tkinter", "token",
+	"tokenize", "tomllib", "trace", "traceback", "tracemalloc", "tty", "turtle", "types",
+	"typing", "unicodedata", "unittest", "urllib", "uuid", "venv", "warnings", "wave",
+	"weakref", "webbrowser", "winreg", "wsgiref", "xml", "xmlrpc", "zipapp", "zipfile",
+	"zipimport", "zlib", "zoneinfo",
+)
+
+// rubyStdlib are commonly required Ruby standard library features
+var rubyStdlib = setOf(
+	"abbrev", "base64", "benchmark", "bigdecimal", "cgi", "csv", "date", "delegate",
+	"digest", "English", "erb", "etc", "fileutils", "find", "forwardable", "io", "ipaddr",
+	"json", "logger", "monitor", "net", "observer", "open-uri", "open3", "openssl",
+	"optparse", "ostruct", "pathname", "pp", "prettyprint", "pstore", "psych", "securerandom",
+	"set", "shellwords", "singleton", "socket", "stringio", "strscan", "tempfile", "time",
+	"timeout", "tmpdir", "tsort", "uri", "weakref", "yaml", "zlib",
+)
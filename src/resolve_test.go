@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// scanFixture writes files into a temporary repository, then scans and
+// analyzes it the way main does
+func scanFixture(t *testing.T, files map[string]string) (*Crawler, string) {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := NewCrawler(&CrawlerConfig{TargetPath: root, TopN: 10})
+	if err := c.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	c.AnalyzeDependencies()
+	return c, root
+}
+
+// importEdges lists a fixture's edges as "from -> to (class)", relative to root
+func importEdges(c *Crawler, root string) []string {
+	var edges []string
+	for _, e := range c.Analysis.Dependencies.ImportEdges {
+		to := e.To
+		if filepath.IsAbs(to) {
+			to = relativePath(root, to)
+		}
+		edges = append(edges, relativePath(root, e.From)+" -> "+to+" ("+e.Class+")")
+	}
+	sort.Strings(edges)
+	return edges
+}
+
+func TestResolveImports(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"go.mod":             "module example.com/app\n",
+		"main.go":            "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/util\"\n\t\"github.com/pkg/errors\"\n)\n",
+		"util/util.go":       "package util\n",
+		"util/util_test.go":  "package util\n",
+		"web/app.js":         "import { a } from './lib';\nimport fs from 'node:fs';\nimport React from 'react';\n",
+		"web/lib/index.js":   "export const a = 1;\n",
+		"py/pkg/__init__.py": "",
+		"py/pkg/mod.py":      "import os\nfrom . import helper\nimport pkg.helper\n",
+		"py/pkg/helper.py":   "",
+		"py/pkg/json.py":     "import json\n",
+	})
+
+	want := []string{
+		"main.go -> fmt (stdlib)",
+		"main.go -> github.com/pkg/errors (external)",
+		"main.go -> util/util.go (internal)",
+		"py/pkg/json.py -> json (stdlib)",
+		"py/pkg/mod.py -> os (stdlib)",
+		"py/pkg/mod.py -> py/pkg/helper.py (internal)",
+		"py/pkg/mod.py -> py/pkg/helper.py (internal)",
+		"web/app.js -> node:fs (stdlib)",
+		"web/app.js -> react (external)",
+		"web/app.js -> web/lib/index.js (internal)",
+	}
+	got := importEdges(c, root)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("edges:\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestClosestFiles(t *testing.T) {
+	candidates := []string{"a/x/mod.py", "b/x/mod.py", "b/y/mod.py"}
+	got := closestFiles("b/x/main.py", candidates)
+	if want := []string{"b/x/mod.py"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("closestFiles = %v, want %v", got, want)
+	}
+	got = closestFiles("c/main.py", candidates)
+	if !reflect.DeepEqual(got, candidates) {
+		t.Errorf("closestFiles with no shared prefix = %v, want every candidate", got)
+	}
+}
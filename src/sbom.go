@@ -0,0 +1,619 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// sbomFormats maps the -sbom formats to the file they are written to
+var sbomFormats = map[string]string{
+	"cyclonedx-json": "sbom.cdx.json",
+	"spdx-json":      "sbom.spdx.json",
+}
+
+// purlTypes maps OSV ecosystems to package URL types
+var purlTypes = map[string]string{
+	"npm":       "npm",
+	"PyPI":      "pypi",
+	"Go":        "golang",
+	"crates.io": "cargo",
+	"Maven":     "maven",
+	"Packagist": "composer",
+	"RubyGems":  "gem",
+	"Pub":       "pub",
+	"NuGet":     "nuget",
+	"SwiftURL":  "swift",
+}
+
+var (
+	bareVersion = regexp.MustCompile(`^v?\d[0-9A-Za-z.+_-]*$`)
+	fullVersion = regexp.MustCompile(`^v?\d+\.\d+\.\d+([-+][0-9A-Za-z.+-]*)?$`)
+)
+
+// pinnedVersion returns the version a declared constraint pins, or "" when
+// it allows a range; what a bare version means depends on the ecosystem
+func pinnedVersion(ecosystem, constraint string) string {
+	constraint = strings.TrimSpace(constraint)
+	switch ecosystem {
+	case "Go":
+		return constraint
+	case "PyPI":
+		v := strings.TrimPrefix(strings.TrimPrefix(constraint, "==="), "==")
+		if v != constraint && bareVersion.MatchString(strings.TrimSpace(v)) {
+			return strings.TrimSpace(v)
+		}
+	case "crates.io":
+		// A bare Cargo version is a caret requirement
+		if v := strings.TrimPrefix(constraint, "="); v != constraint && bareVersion.MatchString(strings.TrimSpace(v)) {
+			return strings.TrimSpace(v)
+		}
+	case "Maven", "NuGet":
+		// A bare version is a soft requirement on exactly that version
+		if bareVersion.MatchString(constraint) {
+			return constraint
+		}
+	default:
+		if v := strings.TrimPrefix(constraint, "="); fullVersion.MatchString(v) {
+			return v
+		}
+	}
+	return ""
+}
+
+// sbomComponent is a package, or a project of the repository, in the SBOM
+type sbomComponent struct {
+	ref       string // CycloneDX bom-ref; unique within the SBOM
+	kind      string // "application" for projects, "library" for dependencies
+	name      string
+	group     string // Maven group ID
+	version   string
+	rangeSpec string // declared constraint of a package no lockfile or pin resolves
+	purl      string
+	license   string
+	dev       bool
+	dependsOn map[string]bool // refs
+}
+
+// sbomFile is a scanned file with its hashes
+type sbomFile struct {
+	path         string // relative to the repository, slash-separated
+	sha1, sha256 string
+}
+
+// sbomGraph is the repository, its projects and packages, the dependencies
+// between them and the hashes of the scanned files
+type sbomGraph struct {
+	root       *sbomComponent
+	components []*sbomComponent // projects, then packages, sorted by ref
+	byRef      map[string]*sbomComponent
+	files      []*sbomFile
+}
+
+// purl builds a package URL (https://github.com/package-url/purl-spec)
+func purl(ecosystem, name, version string) string {
+	typ := purlTypes[ecosystem]
+	if typ == "" || name == "" {
+		return ""
+	}
+	switch ecosystem {
+	case "PyPI":
+		name = normalizePythonName(name)
+	case "Maven":
+		// Maven purls need the group ID as namespace
+		if !strings.Contains(name, ":") {
+			return ""
+		}
+		name = strings.Replace(name, ":", "/", 1)
+	case "SwiftURL":
+		name = swiftPackageURL(name)
+	}
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = purlEscape(s)
+	}
+	p := "pkg:" + typ + "/" + strings.Join(segments, "/")
+	if version != "" {
+		p += "@" + purlEscape(version)
+	}
+	return p
+}
+
+// purlEscape percent-encodes a purl segment, including the "@" of npm scopes
+// and the "+" of build metadata
+func purlEscape(s string) string {
+	return strings.NewReplacer("@", "%40", "+", "%2B").Replace(url.PathEscape(s))
+}
+
+// buildSBOM collects the components of the SBOM: one per manifest, one per
+// package version found in manifests and lockfiles, with the dependency edges
+// from manifests, lockfiles and workspaces
+func (c *Crawler) buildSBOM() (*sbomGraph, error) {
+	deps := c.Analysis.Dependencies
+	g := &sbomGraph{root: &sbomComponent{
+		ref:       "repository",
+		kind:      "application",
+		name:      filepath.Base(c.Config.TargetPath),
+		dependsOn: make(map[string]bool),
+	}}
+	for _, f := range deps.LicenseFiles {
+		if filepath.Dir(f.Path) == c.Config.TargetPath && f.License != "" {
+			g.root.license = f.License
+		}
+	}
+
+	licenses := make(map[string]string) // ecosystem|name|version -> license
+	for _, l := range deps.Licenses {
+		licenses[l.Ecosystem+"|"+l.Package+"|"+l.Version] = l.License
+	}
+
+	byRef := make(map[string]*sbomComponent)
+	packages := make(map[string]*sbomComponent) // ecosystem|name|version|range
+	// An unresolved package has one component per declared range, so that
+	// different projects' constraints are not merged into one
+	pkg := func(ecosystem, name, version, rangeSpec string, dev bool) *sbomComponent {
+		key := ecosystem + "|" + name + "|" + version + "|" + rangeSpec
+		if p := packages[key]; p != nil {
+			p.dev = p.dev && dev
+			return p
+		}
+		p := &sbomComponent{kind: "library", name: name, version: version, rangeSpec: rangeSpec, dev: dev, dependsOn: make(map[string]bool)}
+		p.purl = purl(ecosystem, name, version)
+		p.ref = p.purl
+		if p.ref == "" {
+			p.ref = "pkg:generic/" + purlEscape(name)
+			if version != "" {
+				p.ref += "@" + purlEscape(version)
+			}
+		}
+		if rangeSpec != "" {
+			p.ref += "?range=" + url.QueryEscape(rangeSpec)
+		}
+		if ecosystem == "Maven" {
+			if group, artifact, ok := strings.Cut(name, ":"); ok {
+				p.group, p.name = group, artifact
+			}
+		}
+		p.license = licenses[ecosystem+"|"+name+"|"+version]
+		if p.license == "" && rangeSpec != "" {
+			p.license = licenses[ecosystem+"|"+name+"|"+rangeSpec]
+		}
+		if p.license == "" {
+			p.license = licenses[ecosystem+"|"+name+"|"]
+		}
+		packages[key] = p
+		byRef[p.ref] = p
+		return p
+	}
+
+	// Projects: one per manifest, found by name or directory for workspace
+	// and path dependencies
+	projects := make(map[*Manifest]*sbomComponent)
+	projectByName := make(map[string]*sbomComponent) // ecosystem|name
+	projectByDir := make(map[string]*sbomComponent)
+	for _, m := range deps.Manifests {
+		rel := filepath.ToSlash(relativePath(c.Config.TargetPath, m.Path))
+		ecosystem := osvEcosystems[m.Manager]
+		p := &sbomComponent{ref: "project:" + rel, kind: "application", name: m.Name, version: m.Version, dependsOn: make(map[string]bool)}
+		if p.name == "" {
+			p.name = rel
+		} else {
+			p.purl = purl(ecosystem, m.Name, m.Version)
+			projectByName[ecosystem+"|"+m.Name] = p
+		}
+		if ecosystem == "Maven" {
+			if group, artifact, ok := strings.Cut(m.Name, ":"); ok {
+				p.group, p.name = group, artifact
+			}
+		}
+		projects[m] = p
+		projectByDir[filepath.Dir(m.Path)] = p
+		byRef[p.ref] = p
+		g.root.dependsOn[p.ref] = true
+	}
+
+	for _, m := range deps.Manifests {
+		project := projects[m]
+		ecosystem := osvEcosystems[m.Manager]
+		for _, dep := range m.Dependencies {
+			switch dep.Source {
+			case "sdk":
+				continue
+			case "workspace", "path":
+				target := projectByName[ecosystem+"|"+dep.Name]
+				if dep.Source == "path" {
+					target = projectByDir[dep.URL]
+				}
+				if target != nil && target != project {
+					project.dependsOn[target.ref] = true
+				}
+				continue
+			}
+			name := dep.Name
+			if ecosystem == "SwiftURL" && dep.URL != "" {
+				name = dep.URL
+			}
+			version, rangeSpec := dep.Resolved, ""
+			if version == "" {
+				version = pinnedVersion(ecosystem, dep.Version)
+			}
+			if version == "" {
+				rangeSpec = normalizeConstraint(ecosystem, dep.Version)
+			}
+			project.dependsOn[pkg(ecosystem, name, version, rangeSpec, isDevGroup(dep.Group)).ref] = true
+		}
+	}
+
+	// Workspace edges also cover members referenced by project path (Gradle)
+	byPath := make(map[string]*sbomComponent)
+	for m, p := range projects {
+		byPath[m.Path] = p
+	}
+	for _, ws := range deps.Workspaces {
+		for _, e := range ws.Edges {
+			if from, to := byPath[e.From], byPath[e.To]; from != nil && to != nil && from != to {
+				from.dependsOn[to.ref] = true
+			}
+		}
+	}
+
+	for _, lock := range deps.Lockfiles {
+		ecosystem := osvEcosystems[lock.Manager]
+		if lock.Error != "" {
+			continue
+		}
+		refs := make(map[string]*sbomComponent) // name@version
+		for _, p := range lock.Packages {
+			// go.sum also lists modules of which only the go.mod was downloaded
+			if lock.Manager == "go modules" && p.Integrity == "" {
+				continue
+			}
+			refs[p.ID()] = pkg(ecosystem, p.Name, p.Version, "", p.Dev)
+		}
+		for _, p := range lock.Packages {
+			from := refs[p.ID()]
+			for _, id := range p.Requires {
+				if to := refs[id]; from != nil && to != nil && to != from {
+					from.dependsOn[to.ref] = true
+				}
+			}
+		}
+	}
+
+	g.byRef = byRef
+	for _, p := range byRef {
+		g.components = append(g.components, p)
+	}
+	sort.Slice(g.components, func(i, j int) bool {
+		a, b := g.components[i], g.components[j]
+		if a.kind != b.kind {
+			return a.kind == "application"
+		}
+		return a.ref < b.ref
+	})
+
+	for _, files := range c.Analysis.FilesByType {
+		for _, info := range files {
+			f, err := hashFile(info.Path)
+			if err != nil {
+				return nil, err
+			}
+			f.path = filepath.ToSlash(relativePath(c.Config.TargetPath, info.Path))
+			g.files = append(g.files, f)
+		}
+	}
+	sort.Slice(g.files, func(i, j int) bool { return g.files[i].path < g.files[j].path })
+	return g, nil
+}
+
+// hashFile computes the SHA-1 and SHA-256 of a file
+func hashFile(path string) (*sbomFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h1, h256 := sha1.New(), sha256.New()
+	if _, err := io.Copy(io.MultiWriter(h1, h256), f); err != nil {
+		return nil, err
+	}
+	return &sbomFile{sha1: hex.EncodeToString(h1.Sum(nil)), sha256: hex.EncodeToString(h256.Sum(nil))}, nil
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// WriteSBOM writes the SBOM in Config.SBOMFormat ("cyclonedx-json" or
+// "spdx-json") to the output directory and returns its path
+func (c *Crawler) WriteSBOM() (string, error) {
+	name, ok := sbomFormats[c.Config.SBOMFormat]
+	if !ok {
+		return "", fmt.Errorf("unknown SBOM format %q (want cyclonedx-json or spdx-json)", c.Config.SBOMFormat)
+	}
+	g, err := c.buildSBOM()
+	if err != nil {
+		return "", err
+	}
+	var doc interface{}
+	if c.Config.SBOMFormat == "spdx-json" {
+		doc = c.spdxDocument(g)
+	} else {
+		doc = c.cycloneDXDocument(g)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(c.Config.OutputPath, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(c.Config.OutputPath, name)
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// CycloneDX 1.5 JSON (https://cyclonedx.org/docs/1.5/json/)
+type (
+	cdxBOM struct {
+		BOMFormat    string          `json:"bomFormat"`
+		SpecVersion  string          `json:"specVersion"`
+		SerialNumber string          `json:"serialNumber"`
+		Version      int             `json:"version"`
+		Metadata     cdxMetadata     `json:"metadata"`
+		Components   []*cdxComponent `json:"components"`
+		Dependencies []cdxDependency `json:"dependencies"`
+	}
+	cdxMetadata struct {
+		Timestamp string `json:"timestamp"`
+		Tools     struct {
+			Components []*cdxComponent `json:"components"`
+		} `json:"tools"`
+		Component *cdxComponent `json:"component"`
+	}
+	cdxComponent struct {
+		Type       string        `json:"type"`
+		BOMRef     string        `json:"bom-ref,omitempty"`
+		Group      string        `json:"group,omitempty"`
+		Name       string        `json:"name"`
+		Version    string        `json:"version,omitempty"`
+		Scope      string        `json:"scope,omitempty"`
+		Hashes     []cdxHash     `json:"hashes,omitempty"`
+		Licenses   []cdxLicense  `json:"licenses,omitempty"`
+		PURL       string        `json:"purl,omitempty"`
+		Properties []cdxProperty `json:"properties,omitempty"`
+	}
+	cdxHash struct {
+		Alg     string `json:"alg"`
+		Content string `json:"content"`
+	}
+	cdxLicense struct {
+		Expression string `json:"expression"`
+	}
+	cdxProperty struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	cdxDependency struct {
+		Ref       string   `json:"ref"`
+		DependsOn []string `json:"dependsOn"`
+	}
+)
+
+// cdxComponentOf converts a component; dependencies only needed for
+// development are out of the shipped product's scope
+func cdxComponentOf(p *sbomComponent) *cdxComponent {
+	out := &cdxComponent{Type: p.kind, BOMRef: p.ref, Group: p.group, Name: p.name, Version: p.version, PURL: p.purl}
+	if p.kind == "library" && p.dev {
+		out.Scope = "excluded"
+	}
+	if p.license != "" {
+		out.Licenses = []cdxLicense{{Expression: p.license}}
+	}
+	if p.rangeSpec != "" {
+		out.Properties = []cdxProperty{{Name: "code-crawler:declared-range", Value: p.rangeSpec}}
+	}
+	return out
+}
+
+// cycloneDXDocument builds a CycloneDX 1.5 BOM: projects and packages as
+// components, scanned files as file components with their hashes
+func (c *Crawler) cycloneDXDocument(g *sbomGraph) *cdxBOM {
+	bom := &cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Components:   []*cdxComponent{},
+		Dependencies: []cdxDependency{},
+	}
+	bom.Metadata.Timestamp = c.Analysis.AnalyzedAt.UTC().Format("2006-01-02T15:04:05Z")
+	bom.Metadata.Tools.Components = []*cdxComponent{{Type: "application", Name: "code-crawler", Version: version}}
+	bom.Metadata.Component = cdxComponentOf(g.root)
+
+	for _, p := range append([]*sbomComponent{g.root}, g.components...) {
+		if p != g.root {
+			bom.Components = append(bom.Components, cdxComponentOf(p))
+		}
+		bom.Dependencies = append(bom.Dependencies, cdxDependency{Ref: p.ref, DependsOn: sortedKeys(p.dependsOn)})
+	}
+	for _, f := range g.files {
+		bom.Components = append(bom.Components, &cdxComponent{
+			Type:   "file",
+			BOMRef: "file:" + f.path,
+			Name:   f.path,
+			Hashes: []cdxHash{{"SHA-1", f.sha1}, {"SHA-256", f.sha256}},
+		})
+	}
+	return bom
+}
+
+// SPDX 2.3 JSON (https://spdx.github.io/spdx-spec/v2.3/)
+type (
+	spdxDocument struct {
+		SPDXVersion       string             `json:"spdxVersion"`
+		DataLicense       string             `json:"dataLicense"`
+		SPDXID            string             `json:"SPDXID"`
+		Name              string             `json:"name"`
+		DocumentNamespace string             `json:"documentNamespace"`
+		CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+		Packages          []*spdxPackage     `json:"packages"`
+		Files             []*spdxFile        `json:"files"`
+		Relationships     []spdxRelationship `json:"relationships"`
+	}
+	spdxCreationInfo struct {
+		Created  string   `json:"created"`
+		Creators []string `json:"creators"`
+	}
+	spdxPackage struct {
+		SPDXID                  string                `json:"SPDXID"`
+		Name                    string                `json:"name"`
+		VersionInfo             string                `json:"versionInfo,omitempty"`
+		DownloadLocation        string                `json:"downloadLocation"`
+		FilesAnalyzed           bool                  `json:"filesAnalyzed"`
+		PackageVerificationCode *spdxVerificationCode `json:"packageVerificationCode,omitempty"`
+		HasFiles                []string              `json:"hasFiles,omitempty"`
+		LicenseConcluded        string                `json:"licenseConcluded"`
+		LicenseDeclared         string                `json:"licenseDeclared"`
+		CopyrightText           string                `json:"copyrightText"`
+		ExternalRefs            []spdxExternalRef     `json:"externalRefs,omitempty"`
+		PrimaryPackagePurpose   string                `json:"primaryPackagePurpose,omitempty"`
+		Comment                 string                `json:"comment,omitempty"`
+	}
+	spdxVerificationCode struct {
+		Value string `json:"packageVerificationCodeValue"`
+	}
+	spdxExternalRef struct {
+		Category string `json:"referenceCategory"`
+		Type     string `json:"referenceType"`
+		Locator  string `json:"referenceLocator"`
+	}
+	spdxFile struct {
+		SPDXID    string         `json:"SPDXID"`
+		FileName  string         `json:"fileName"`
+		Checksums []spdxChecksum `json:"checksums"`
+	}
+	spdxChecksum struct {
+		Algorithm string `json:"algorithm"`
+		Value     string `json:"checksumValue"`
+	}
+	spdxRelationship struct {
+		Element string `json:"spdxElementId"`
+		Type    string `json:"relationshipType"`
+		Related string `json:"relatedSpdxElement"`
+	}
+)
+
+// spdxIDChars are the characters an SPDX identifier may not contain
+var spdxIDChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// spdxDocument builds an SPDX 2.3 document: the repository package contains
+// the scanned files and its projects, which depend on the packages
+func (c *Crawler) spdxDocument(g *sbomGraph) *spdxDocument {
+	doc := &spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              g.root.name,
+		DocumentNamespace: "https://spdx.org/spdxdocs/" + url.PathEscape(g.root.name) + "-" + newUUID(),
+		CreationInfo: spdxCreationInfo{
+			Created:  c.Analysis.AnalyzedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			Creators: []string{"Tool: code-crawler-" + version},
+		},
+		Packages:      []*spdxPackage{},
+		Files:         []*spdxFile{},
+		Relationships: []spdxRelationship{},
+	}
+
+	// Identifiers are numbered so that different refs never collide once
+	// sanitized
+	ids := map[string]string{g.root.ref: "SPDXRef-Repository"}
+	for i, p := range g.components {
+		prefix := "SPDXRef-Package-"
+		if p.kind == "application" {
+			prefix = "SPDXRef-Project-"
+		}
+		ids[p.ref] = fmt.Sprintf("%s%d-%s", prefix, i+1, strings.Trim(spdxIDChars.ReplaceAllString(p.name, "-"), "-"))
+	}
+
+	root := spdxPackageOf(g.root, ids[g.root.ref])
+	root.FilesAnalyzed = len(g.files) > 0
+	sums := make([]string, 0, len(g.files))
+	for i, f := range g.files {
+		id := fmt.Sprintf("SPDXRef-File-%d", i+1)
+		doc.Files = append(doc.Files, &spdxFile{
+			SPDXID:    id,
+			FileName:  "./" + f.path,
+			Checksums: []spdxChecksum{{"SHA1", f.sha1}, {"SHA256", f.sha256}},
+		})
+		root.HasFiles = append(root.HasFiles, id)
+		sums = append(sums, f.sha1)
+	}
+	if root.FilesAnalyzed {
+		// SPDX package verification code: SHA-1 of the sorted file SHA-1s
+		sort.Strings(sums)
+		h := sha1.Sum([]byte(strings.Join(sums, "")))
+		root.PackageVerificationCode = &spdxVerificationCode{hex.EncodeToString(h[:])}
+	}
+	doc.Packages = append(doc.Packages, root)
+	doc.Relationships = append(doc.Relationships, spdxRelationship{"SPDXRef-DOCUMENT", "DESCRIBES", ids[g.root.ref]})
+
+	for _, p := range append([]*sbomComponent{g.root}, g.components...) {
+		if p != g.root {
+			doc.Packages = append(doc.Packages, spdxPackageOf(p, ids[p.ref]))
+		}
+		for _, ref := range sortedKeys(p.dependsOn) {
+			to := g.byRef[ref]
+			switch {
+			case p == g.root:
+				doc.Relationships = append(doc.Relationships, spdxRelationship{ids[p.ref], "CONTAINS", ids[ref]})
+			case to != nil && to.dev && to.kind == "library":
+				doc.Relationships = append(doc.Relationships, spdxRelationship{ids[ref], "DEV_DEPENDENCY_OF", ids[p.ref]})
+			default:
+				doc.Relationships = append(doc.Relationships, spdxRelationship{ids[p.ref], "DEPENDS_ON", ids[ref]})
+			}
+		}
+	}
+	return doc
+}
+
+// spdxPackageOf converts a component to an SPDX package
+func spdxPackageOf(p *sbomComponent, id string) *spdxPackage {
+	out := &spdxPackage{
+		SPDXID:           id,
+		Name:             p.name,
+		VersionInfo:      p.version,
+		DownloadLocation: "NOASSERTION",
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  "NOASSERTION",
+		CopyrightText:    "NOASSERTION",
+	}
+	if p.group != "" {
+		out.Name = p.group + ":" + p.name
+	}
+	if p.license != "" {
+		out.LicenseDeclared = p.license
+	}
+	if p.purl != "" {
+		out.ExternalRefs = []spdxExternalRef{{"PACKAGE-MANAGER", "purl", p.purl}}
+	}
+	if p.rangeSpec != "" {
+		out.Comment = "Unresolved; declared as " + p.rangeSpec
+	}
+	out.PrimaryPackagePurpose = strings.ToUpper(p.kind)
+	return out
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPURL(t *testing.T) {
+	tests := []struct {
+		ecosystem, name, version string
+		want                     string
+	}{
+		{"npm", "@babel/core", "7.23.0", "pkg:npm/%40babel/core@7.23.0"},
+		{"PyPI", "Django_REST", "3.14", "pkg:pypi/django-rest@3.14"},
+		{"Maven", "org.slf4j:slf4j-api", "2.0.9", "pkg:maven/org.slf4j/slf4j-api@2.0.9"},
+		{"Maven", "slf4j-api", "2.0.9", ""},
+		{"Go", "golang.org/x/mod", "v0.14.0+incompatible", "pkg:golang/golang.org/x/mod@v0.14.0%2Bincompatible"},
+		{"crates.io", "serde", "", "pkg:cargo/serde"},
+		{"unknown", "x", "1", ""},
+	}
+	for _, tt := range tests {
+		if got := purl(tt.ecosystem, tt.name, tt.version); got != tt.want {
+			t.Errorf("purl(%q, %q, %q) = %q, want %q", tt.ecosystem, tt.name, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestPinnedVersion(t *testing.T) {
+	tests := []struct {
+		ecosystem, constraint, want string
+	}{
+		{"npm", "4.17.21", "4.17.21"},
+		{"npm", "^4.17.21", ""},
+		{"PyPI", "==2.31.0", "2.31.0"},
+		{"PyPI", ">=2.31", ""},
+		{"crates.io", "1.0.2", ""},
+		{"crates.io", "=1.0.2", "1.0.2"},
+		{"Maven", "2.0.9", "2.0.9"},
+		{"Go", "v0.14.0", "v0.14.0"},
+	}
+	for _, tt := range tests {
+		if got := pinnedVersion(tt.ecosystem, tt.constraint); got != tt.want {
+			t.Errorf("pinnedVersion(%q, %q) = %q, want %q", tt.ecosystem, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestBuildSBOM(t *testing.T) {
+	c, _ := scanFixture(t, map[string]string{
+		"package.json":              `{"name": "monorepo", "private": true, "workspaces": ["packages/*"]}`,
+		"packages/ui/package.json":  `{"name": "ui", "version": "1.0.0", "dependencies": {"lodash": "4.17.21", "react": "^17.0.0"}, "devDependencies": {"jest": "^29.0.0"}}`,
+		"packages/web/package.json": `{"name": "web", "version": "2.0.0", "dependencies": {"ui": "workspace:*", "lodash": "4.17.21", "react": "^18.2.0"}, "devDependencies": {"jest": "^ 29.0.0"}}`,
+	})
+	g, err := c.buildSBOM()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unresolved packages get one component per normalized range
+	var refs []string
+	for _, p := range g.components {
+		refs = append(refs, p.ref)
+	}
+	want := []string{
+		"project:package.json", "project:packages/ui/package.json", "project:packages/web/package.json",
+		"pkg:npm/jest?range=%5E29.0.0", "pkg:npm/lodash@4.17.21",
+		"pkg:npm/react?range=%5E17.0.0", "pkg:npm/react?range=%5E18.2.0",
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("components = %q, want %q", refs, want)
+	}
+
+	web := g.byRef["project:packages/web/package.json"]
+	wantDeps := []string{"pkg:npm/jest?range=%5E29.0.0", "pkg:npm/lodash@4.17.21", "pkg:npm/react?range=%5E18.2.0", "project:packages/ui/package.json"}
+	if deps := sortedKeys(web.dependsOn); !reflect.DeepEqual(deps, wantDeps) {
+		t.Errorf("web depends on %q, want %q", deps, wantDeps)
+	}
+	if jest := g.byRef["pkg:npm/jest?range=%5E29.0.0"]; jest == nil || !jest.dev {
+		t.Errorf("jest not marked as a development dependency: %+v", jest)
+	}
+	if len(g.root.dependsOn) != 3 || len(g.files) != 3 {
+		t.Errorf("root depends on %d projects and hashes %d files, want 3 and 3", len(g.root.dependsOn), len(g.files))
+	}
+
+	react := cdxComponentOf(g.byRef["pkg:npm/react?range=%5E18.2.0"])
+	if react.PURL != "pkg:npm/react" || react.Version != "" ||
+		!reflect.DeepEqual(react.Properties, []cdxProperty{{"code-crawler:declared-range", "^18.2.0"}}) {
+		t.Errorf("react component = %+v", react)
+	}
+}
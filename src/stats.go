@@ -0,0 +1,147 @@
+package main
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// fileMetric is a per-file measure that gets a top-N list and a distribution
+type fileMetric struct {
+	Name  string
+	Value func(f FileInfo) (int64, bool)
+}
+
+// fileMetrics lists the metrics reported in Statistics.TopFiles and Statistics.Distributions
+var fileMetrics = []fileMetric{
+	{
+		Name:  "size",
+		Value: func(f FileInfo) (int64, bool) { return f.Size, true },
+	},
+	{
+		// Lines are only counted for text files
+		Name:  "lines",
+		Value: func(f FileInfo) (int64, bool) { return int64(f.Lines), isTextFile(f.Extension) },
+	},
+}
+
+// Distribution summarises the spread of a file metric
+type Distribution struct {
+	Count     int               `json:"count"`
+	Min       int64             `json:"min"`
+	Max       int64             `json:"max"`
+	Mean      float64           `json:"mean"`
+	P50       int64             `json:"p50"`
+	P90       int64             `json:"p90"`
+	P99       int64             `json:"p99"`
+	Histogram []HistogramBucket `json:"histogram"`
+}
+
+// HistogramBucket is one log2-scaled bucket covering [Lower, Upper)
+type HistogramBucket struct {
+	Lower int64 `json:"lower"`
+	Upper int64 `json:"upper"`
+	Count int   `json:"count"`
+}
+
+// topFiles returns the n files with the highest value for the metric
+func topFiles(files []FileInfo, metric fileMetric, n int) []FileInfo {
+	var ranked []FileInfo
+	for _, f := range files {
+		if _, ok := metric.Value(f); ok {
+			ranked = append(ranked, f)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		vi, _ := metric.Value(ranked[i])
+		vj, _ := metric.Value(ranked[j])
+		if vi != vj {
+			return vi > vj
+		}
+		return ranked[i].Path < ranked[j].Path
+	})
+
+	if n >= 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// newDistribution computes percentiles and a log-scale histogram for the metric
+func newDistribution(files []FileInfo, metric fileMetric) *Distribution {
+	var values []int64
+	for _, f := range files {
+		if v, ok := metric.Value(f); ok {
+			values = append(values, v)
+		}
+	}
+
+	dist := &Distribution{Count: len(values), Histogram: []HistogramBucket{}}
+	if len(values) == 0 {
+		return dist
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var total float64
+	for _, v := range values {
+		total += float64(v)
+	}
+
+	dist.Min = values[0]
+	dist.Max = values[len(values)-1]
+	dist.Mean = total / float64(len(values))
+	dist.P50 = percentile(values, 50)
+	dist.P90 = percentile(values, 90)
+	dist.P99 = percentile(values, 99)
+	dist.Histogram = logHistogram(values)
+
+	return dist
+}
+
+// percentile returns the nearest-rank percentile p of sorted values
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// logHistogram buckets values into [0,1), [1,2), [2,4), [4,8), ...
+// Empty buckets between the smallest and largest values are kept so charts line up.
+func logHistogram(values []int64) []HistogramBucket {
+	bucketOf := func(v int64) int {
+		if v <= 0 {
+			return 0
+		}
+		return bits.Len64(uint64(v))
+	}
+
+	counts := make(map[int]int)
+	lo, hi := bucketOf(values[0]), bucketOf(values[0])
+	for _, v := range values {
+		b := bucketOf(v)
+		counts[b]++
+		if b < lo {
+			lo = b
+		}
+		if b > hi {
+			hi = b
+		}
+	}
+
+	var buckets []HistogramBucket
+	for b := lo; b <= hi; b++ {
+		bucket := HistogramBucket{Upper: 1, Count: counts[b]}
+		if b > 0 {
+			bucket.Lower = int64(1) << (b - 1)
+			bucket.Upper = int64(1) << b
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
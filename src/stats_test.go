@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopFiles(t *testing.T) {
+	files := []FileInfo{
+		{Path: "b.go", Extension: ".go", Size: 10, Lines: 3},
+		{Path: "a.go", Extension: ".go", Size: 10, Lines: 7},
+		{Path: "logo.png", Extension: ".png", Size: 500},
+		{Path: "c.go", Extension: ".go", Size: 1, Lines: 1},
+	}
+	size, lines := fileMetrics[0], fileMetrics[1]
+
+	var got []string
+	for _, f := range topFiles(files, size, 3) {
+		got = append(got, f.Path)
+	}
+	// Ties are broken by path
+	if want := []string{"logo.png", "a.go", "b.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("top files by size = %v, want %v", got, want)
+	}
+
+	got = nil
+	for _, f := range topFiles(files, lines, -1) {
+		got = append(got, f.Path)
+	}
+	// Binary files have no line count
+	if want := []string{"a.go", "b.go", "c.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("top files by lines = %v, want %v", got, want)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p    int
+		want int64
+	}{
+		{0, 1},
+		{50, 5},
+		{90, 9},
+		{99, 10},
+		{100, 10},
+	}
+	for _, tt := range tests {
+		if got := percentile(values, tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of no values = %d, want 0", got)
+	}
+}
+
+func TestLogHistogram(t *testing.T) {
+	got := logHistogram([]int64{0, 1, 5, 6, 40})
+	want := []HistogramBucket{
+		{Lower: 0, Upper: 1, Count: 1},
+		{Lower: 1, Upper: 2, Count: 1},
+		{Lower: 2, Upper: 4, Count: 0},
+		{Lower: 4, Upper: 8, Count: 2},
+		{Lower: 8, Upper: 16, Count: 0},
+		{Lower: 16, Upper: 32, Count: 0},
+		{Lower: 32, Upper: 64, Count: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("logHistogram = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewDistribution(t *testing.T) {
+	files := []FileInfo{{Size: 4}, {Size: 1}, {Size: 7}}
+	dist := newDistribution(files, fileMetrics[0])
+	if dist.Count != 3 || dist.Min != 1 || dist.Max != 7 || dist.Mean != 4 || dist.P50 != 4 {
+		t.Errorf("newDistribution = %+v", dist)
+	}
+	if empty := newDistribution(nil, fileMetrics[0]); empty.Count != 0 || empty.Histogram == nil {
+		t.Errorf("empty distribution = %+v, want a zero count and an empty histogram", empty)
+	}
+}
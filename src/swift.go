@@ -0,0 +1,114 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	swiftPackageName = regexp.MustCompile(`Package\s*\(\s*name\s*:\s*"([^"]+)"`)
+	swiftArgument    = regexp.MustCompile(`(?s)^(\w+)\s*:\s*(.+)$`)
+	swiftRange       = regexp.MustCompile(`^"([^"]+)"\s*(\.\.[<.])\s*"([^"]+)"$`)
+	swiftRequirement = regexp.MustCompile(`(?s)^\.(upToNextMajor|upToNextMinor|exact|branch|revision)\s*\((?:from\s*:\s*)?"([^"]+)"\)$`)
+)
+
+// parsePackageSwift parses the .package(...) declarations of Package.swift.
+// Requirements are recorded in npm range notation: from/upToNextMajor as ^,
+// upToNextMinor as ~, exact versions as-is and ranges as >=/<.
+func (c *Crawler) parsePackageSwift(content []byte, m *Manifest) {
+	src := stripCStyleComments(string(content))
+	if match := swiftPackageName.FindStringSubmatch(src); match != nil {
+		m.Name = match[1]
+	}
+
+	for offset := 0; ; {
+		idx := strings.Index(src[offset:], ".package(")
+		if idx < 0 {
+			return
+		}
+		open := offset + idx + len(".package")
+		end := matchingBracket(src, open)
+		if end < 0 {
+			return
+		}
+		offset = end
+		addSwiftPackage(m, src[open+1:end])
+	}
+}
+
+// addSwiftPackage records one .package(...) declaration
+func addSwiftPackage(m *Manifest, args string) {
+	var name, url, location, version, ref string
+	for i, arg := range splitTopLevel(args, ',') {
+		arg = strings.TrimSpace(arg)
+		match := swiftArgument.FindStringSubmatch(arg)
+		if match == nil {
+			// The unlabeled requirement after url:, e.g. "1.0.0"..<"2.0.0" or .exact("1.0.0")
+			if i > 0 {
+				version, ref = swiftRequirementSpec(arg)
+			}
+			continue
+		}
+		value, _ := quotedString(match[2])
+		switch match[1] {
+		case "name":
+			name = value
+		case "url":
+			url = value
+		case "path":
+			location = value
+		case "id":
+			name = value // registry identity, scope.name
+		case "from":
+			version = "^" + value
+		case "exact":
+			version = value
+		case "branch", "revision":
+			ref = "#" + match[1] + "=" + value
+		}
+	}
+
+	switch {
+	case url != "":
+		if name == "" {
+			name = strings.TrimSuffix(path.Base(strings.TrimSuffix(url, "/")), ".git")
+		}
+		dep := m.addDependency("dependencies", name, version)
+		dep.Source = "git"
+		dep.URL = url + ref
+	case location != "":
+		if name == "" {
+			name = path.Base(strings.TrimSuffix(location, "/"))
+		}
+		dep := m.addDependency("dependencies", name, "")
+		dep.Source = "path"
+		dep.URL = location
+	case name != "":
+		m.addDependency("dependencies", name, version)
+	}
+}
+
+// swiftRequirementSpec converts an unlabeled requirement expression into a
+// version range, or a git ref for branch and revision requirements
+func swiftRequirementSpec(expr string) (version, ref string) {
+	if match := swiftRange.FindStringSubmatch(expr); match != nil {
+		if match[2] == "..." {
+			return ">=" + match[1] + " <=" + match[3], ""
+		}
+		return ">=" + match[1] + " <" + match[3], ""
+	}
+	if match := swiftRequirement.FindStringSubmatch(expr); match != nil {
+		switch match[1] {
+		case "upToNextMajor":
+			return "^" + match[2], ""
+		case "upToNextMinor":
+			return "~" + match[2], ""
+		case "exact":
+			return match[2], ""
+		default:
+			return "", "#" + match[1] + "=" + match[2]
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePackageSwift(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"Package.swift": `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "App",
+    dependencies: [
+        .package(url: "https://github.com/apple/swift-nio.git", from: "2.0.0"),
+        .package(url: "https://github.com/a/b.git", .upToNextMinor(from: "1.2.0")),
+        .package(url: "https://github.com/c/d.git", exact: "3.0.0"),
+        .package(url: "https://github.com/e/f.git", "1.0.0"..<"2.0.0"),
+        // .package(url: "https://github.com/x/y.git", from: "9.0.0"),
+        .package(url: "https://github.com/g/h.git", branch: "main"),
+        .package(path: "../Local"),
+    ]
+)
+`,
+	})
+
+	m := findManifest(c, root, "Package.swift")
+	if m.Name != "App" {
+		t.Errorf("name %q, want App", m.Name)
+	}
+	want := []string{
+		"dependencies swift-nio ^2.0.0",
+		"dependencies b ~1.2.0",
+		"dependencies d 3.0.0",
+		"dependencies f >=1.0.0 <2.0.0",
+		"dependencies h ",
+		"dependencies Local ",
+	}
+	if got := manifestDeps(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("dependencies = %q, want %q", got, want)
+	}
+	if h := m.Dependencies[4]; h.Source != "git" || h.URL != "https://github.com/g/h.git#branch=main" {
+		t.Errorf("h: source %q, url %q", h.Source, h.URL)
+	}
+}
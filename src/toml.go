@@ -0,0 +1,550 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML decodes a TOML document into nested maps. Tables become
+// map[string]interface{}, arrays []interface{}, and strings, integers (int64),
+// floats, booleans and date-times (kept as their literal text) become Go scalars.
+func parseTOML(content []byte) (map[string]interface{}, error) {
+	p := &tomlParser{src: string(content), line: 1}
+	return p.parse()
+}
+
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("toml: line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) parse() (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	current := root
+
+	for {
+		p.skipWhitespaceAndComments()
+		if p.eof() {
+			return root, nil
+		}
+
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], "[["):
+			p.pos += 2
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume("]]") {
+				return nil, p.errorf("expected ]] after array of tables")
+			}
+			parent, err := tomlDescend(root, keys[:len(keys)-1])
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			last := keys[len(keys)-1]
+			arr, _ := parent[last].([]interface{})
+			if _, exists := parent[last]; exists && arr == nil {
+				return nil, p.errorf("%s is not an array of tables", strings.Join(keys, "."))
+			}
+			table := make(map[string]interface{})
+			parent[last] = append(arr, table)
+			current = table
+
+		case p.peek() == '[':
+			p.pos++
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume("]") {
+				return nil, p.errorf("expected ] after table header")
+			}
+			table, err := tomlDescend(root, keys)
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			current = table
+
+		default:
+			if err := p.parseKeyValue(current); err != nil {
+				return nil, err
+			}
+		}
+
+		p.skipSpaces()
+		if !p.eof() && p.peek() == '#' {
+			p.skipComment()
+		}
+		if !p.eof() && !p.consumeNewline() {
+			return nil, p.errorf("unexpected %q after value", p.peek())
+		}
+	}
+}
+
+// tomlDescend walks (and creates) nested tables; the last element of an array
+// of tables is used when a path runs through one
+func tomlDescend(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		switch next := table[key].(type) {
+		case nil:
+			child := make(map[string]interface{})
+			table[key] = child
+			table = child
+		case map[string]interface{}:
+			table = next
+		case []interface{}:
+			if len(next) == 0 {
+				return nil, fmt.Errorf("empty array %s", key)
+			}
+			child, ok := next[len(next)-1].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is not a table", key)
+			}
+			table = child
+		default:
+			return nil, fmt.Errorf("%s is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+func (p *tomlParser) parseKeyValue(table map[string]interface{}) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	if !p.consume("=") {
+		return p.errorf("expected = after key %s", strings.Join(keys, "."))
+	}
+	p.skipSpaces()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	parent, err := tomlDescend(table, keys[:len(keys)-1])
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+	parent[keys[len(keys)-1]] = value
+	return nil
+}
+
+// parseKey reads a bare, quoted or dotted key
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return nil, p.errorf("unexpected end of input in key")
+		}
+
+		switch c := p.peek(); {
+		case c == '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, s)
+		case c == '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, s)
+		default:
+			start := p.pos
+			for !p.eof() && isTOMLBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("invalid key character %q", p.peek())
+			}
+			keys = append(keys, p.src[start:p.pos])
+		}
+
+		p.skipSpaces()
+		if p.eof() || p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	if p.eof() {
+		return nil, p.errorf("missing value")
+	}
+
+	switch c := p.peek(); {
+	case strings.HasPrefix(p.src[p.pos:], `"""`):
+		return p.parseMultilineBasicString()
+	case strings.HasPrefix(p.src[p.pos:], `'''`):
+		return p.parseMultilineLiteralString()
+	case c == '"':
+		return p.parseBasicString()
+	case c == '\'':
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case strings.HasPrefix(p.src[p.pos:], "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(p.src[p.pos:], "false"):
+		p.pos += 5
+		return false, nil
+	}
+
+	// Numbers and date-times run until a delimiter
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == ',' || c == ']' || c == '}' || c == '#' || c == '\n' || c == '\r' {
+			break
+		}
+		// Date-times may contain a single space between date and time
+		if c == ' ' || c == '\t' {
+			if c == ' ' && p.pos+1 < len(p.src) && isDigit(p.src[p.pos+1]) && strings.Count(p.src[start:p.pos], "-") == 2 {
+				p.pos++
+				continue
+			}
+			break
+		}
+		p.pos++
+	}
+	literal := p.src[start:p.pos]
+	if literal == "" {
+		return nil, p.errorf("invalid value")
+	}
+	return tomlScalar(literal), nil
+}
+
+// tomlScalar converts a number literal; anything else (date-times) stays text
+func tomlScalar(literal string) interface{} {
+	clean := strings.ReplaceAll(literal, "_", "")
+	if n, err := strconv.ParseInt(clean, 0, 64); err == nil {
+		return n
+	}
+	switch clean {
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return clean
+	}
+	if f, err := strconv.ParseFloat(clean, 64); err == nil {
+		return f
+	}
+	return literal
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (p *tomlParser) parseArray() ([]interface{}, error) {
+	p.pos++ // [
+	arr := []interface{}{}
+	for {
+		p.skipWhitespaceAndComments()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, value)
+
+		p.skipWhitespaceAndComments()
+		if p.consume(",") {
+			continue
+		}
+		if !p.eof() && p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+		return nil, p.errorf("expected , or ] in array")
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]interface{}, error) {
+	p.pos++ // {
+	table := make(map[string]interface{})
+	for {
+		// TOML 1.1 allows newlines inside inline tables; accept them
+		p.skipWhitespaceAndComments()
+		if p.eof() {
+			return nil, p.errorf("unterminated inline table")
+		}
+		if p.peek() == '}' {
+			p.pos++
+			return table, nil
+		}
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipWhitespaceAndComments()
+		if p.consume(",") {
+			continue
+		}
+		if !p.eof() && p.peek() == '}' {
+			p.pos++
+			return table, nil
+		}
+		return nil, p.errorf("expected , or } in inline table")
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // "
+	var sb strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		if c == '"' {
+			p.pos++
+			return sb.String(), nil
+		}
+		if c == '\\' {
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+			continue
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+	p.pos += 3
+	p.consumeNewline()
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if strings.HasPrefix(p.src[p.pos:], `"""`) {
+			p.pos += 3
+			// Up to two quotes may directly precede the closing delimiter
+			for i := 0; i < 2 && !p.eof() && p.peek() == '"'; i++ {
+				sb.WriteByte('"')
+				p.pos++
+			}
+			return sb.String(), nil
+		}
+		c := p.peek()
+		if c == '\\' {
+			// Line-ending backslash trims the newline and following whitespace
+			rest := strings.TrimLeft(p.src[p.pos+1:], " \t")
+			if strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+				p.pos++
+				for !p.eof() && strings.ContainsRune(" \t\r\n", rune(p.peek())) {
+					if p.peek() == '\n' {
+						p.line++
+					}
+					p.pos++
+				}
+				continue
+			}
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if c == '\n' {
+			p.line++
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseEscape(sb *strings.Builder) error {
+	p.pos++ // backslash
+	if p.eof() {
+		return p.errorf("unterminated escape")
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		sb.WriteByte('\b')
+	case 't':
+		sb.WriteByte('\t')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'r':
+		sb.WriteByte('\r')
+	case 'e':
+		sb.WriteByte(0x1b)
+	case '"':
+		sb.WriteByte('"')
+	case '\\':
+		sb.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return p.errorf("short unicode escape")
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape")
+		}
+		sb.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return p.errorf("invalid escape \\%c", c)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++ // '
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end < 0 || p.src[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated literal string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+	p.pos += 3
+	p.consumeNewline()
+	end := strings.Index(p.src[p.pos:], `'''`)
+	if end < 0 {
+		return "", p.errorf("unterminated multi-line literal string")
+	}
+	// Up to two quotes may directly precede the closing delimiter
+	for i := 0; i < 2 && p.pos+end+3 < len(p.src) && p.src[p.pos+end+3] == '\''; i++ {
+		end++
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.line += strings.Count(s, "\n")
+	p.pos += end + 3
+	return s, nil
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) consumeNewline() bool {
+	if p.consume("\r\n") || p.consume("\n") {
+		p.line++
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipWhitespaceAndComments() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t':
+			p.pos++
+		case '#':
+			p.skipComment()
+		case '\r', '\n':
+			if !p.consumeNewline() {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// tomlTable returns the nested table at the dotted path, or nil
+func tomlTable(doc map[string]interface{}, path ...string) map[string]interface{} {
+	table := doc
+	for _, key := range path {
+		next, ok := table[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		table = next
+	}
+	return table
+}
+
+// tomlString returns a string value from a table, or ""
+func tomlString(table map[string]interface{}, key string) string {
+	s, _ := table[key].(string)
+	return s
+}
+
+// tomlStrings returns an array of strings from a table, skipping other values
+func tomlStrings(table map[string]interface{}, key string) []string {
+	arr, _ := table[key].([]interface{})
+	var out []string
+	for _, v := range arr {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// tomlTables returns an array of tables from a table, skipping other values
+func tomlTables(table map[string]interface{}, key string) []map[string]interface{} {
+	arr, _ := table[key].([]interface{})
+	var out []map[string]interface{}
+	for _, v := range arr {
+		if t, ok := v.(map[string]interface{}); ok {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]interface{}
+	}{
+		{
+			"inline tables",
+			"dep = { version = \"1.0\", features = [\"std\"], opt.level = 3 }\n",
+			map[string]interface{}{"dep": map[string]interface{}{
+				"version":  "1.0",
+				"features": []interface{}{"std"},
+				"opt":      map[string]interface{}{"level": int64(3)},
+			}},
+		},
+		{
+			"nested arrays",
+			"a = [1, [2, 3], 'lit',\n  # comment\n  \"end\",\n]\n",
+			map[string]interface{}{"a": []interface{}{int64(1), []interface{}{int64(2), int64(3)}, "lit", "end"}},
+		},
+		{
+			"multi-line strings",
+			"basic = \"\"\"\nline1\\\n   line2\"\"\"\nliteral = '''\nraw\\n'''\n",
+			map[string]interface{}{"basic": "line1line2", "literal": "raw\\n"},
+		},
+		{
+			"dotted keys and headers",
+			"a.b.c = 1\n[t.\"quoted key\"]\nd = 1979-05-27T07:32:00Z\n",
+			map[string]interface{}{
+				"a": map[string]interface{}{"b": map[string]interface{}{"c": int64(1)}},
+				"t": map[string]interface{}{"quoted key": map[string]interface{}{"d": "1979-05-27T07:32:00Z"}},
+			},
+		},
+		{
+			"arrays of tables",
+			"[[package]]\nname = \"a\"\n[[package]]\nname = \"b\"\n[package.source]\nurl = \"u\"\n",
+			map[string]interface{}{"package": []interface{}{
+				map[string]interface{}{"name": "a"},
+				map[string]interface{}{"name": "b", "source": map[string]interface{}{"url": "u"}},
+			}},
+		},
+		{
+			"scalars",
+			"t = true\nf = 1.5\nh = 0x1F\nu = 1_000\n",
+			map[string]interface{}{"t": true, "f": 1.5, "h": int64(31), "u": int64(1000)},
+		},
+	}
+	for _, tt := range tests {
+		got, err := parseTOML([]byte(tt.input))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	for _, input := range []string{
+		"a = {",
+		"a = { b = 1",
+		"x = [1,",
+		"[t\n",
+		"s = \"unterminated\n",
+		"s = '''never closed",
+		"= 1\n",
+	} {
+		if _, err := parseTOML([]byte(input)); err == nil {
+			t.Errorf("parseTOML(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func FuzzParseTOML(f *testing.F) {
+	for _, seed := range []string{
+		"a = { b = [1, 2], c.d = 'x' }\n",
+		"[[package]]\nname = \"a\"\ndependencies = [\"b 1.0 (registry)\"]\n",
+		"s = \"\"\"\nx\\\n  y\"\"\"\n",
+		"a = [",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, content []byte) {
+		parseTOML(content)
+	})
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tsConfig is the module resolution part of a tsconfig.json with its extends
+// chain applied; every path in it is absolute
+type tsConfig struct {
+	Path       string
+	Dir        string
+	BaseURL    string
+	Paths      map[string][]string
+	PathsDir   string // directory of the config that declared paths
+	Files      []string
+	Include    []string // slash-separated globs
+	OutDir     string
+	RootDir    string
+	References []string // referenced tsconfig files
+	HasInclude bool     // include was given; otherwise everything under Dir unless files is set
+}
+
+// loadTSConfig reads a tsconfig file and the configs it extends; seen guards
+// against extends cycles
+func loadTSConfig(path string, seen map[string]bool) (*tsConfig, error) {
+	if seen[path] {
+		return &tsConfig{Path: path, Dir: filepath.Dir(path)}, nil
+	}
+	seen[path] = true
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(stripJSONComments(content), &data); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	cfg := &tsConfig{Dir: dir}
+
+	// "extends" is a path or package, or since TypeScript 5.0 a list of them
+	extends := stringList(data["extends"])
+	if s, ok := data["extends"].(string); ok {
+		extends = []string{s}
+	}
+	for _, spec := range extends {
+		if basePath := tsExtendsPath(dir, spec); basePath != "" {
+			if base, err := loadTSConfig(basePath, seen); err == nil {
+				cfg.inherit(base)
+			}
+		}
+	}
+	cfg.Path, cfg.Dir, cfg.References = path, dir, nil
+
+	abs := func(p string) string {
+		if filepath.IsAbs(p) {
+			return filepath.Clean(p)
+		}
+		return filepath.Join(dir, filepath.FromSlash(p))
+	}
+	options, _ := data["compilerOptions"].(map[string]interface{})
+	if baseURL, ok := options["baseUrl"].(string); ok {
+		cfg.BaseURL = abs(baseURL)
+	}
+	if paths, ok := options["paths"].(map[string]interface{}); ok {
+		cfg.Paths = make(map[string][]string)
+		for pattern, targets := range paths {
+			cfg.Paths[pattern] = stringList(targets)
+		}
+		cfg.PathsDir = dir
+	}
+	if outDir, ok := options["outDir"].(string); ok {
+		cfg.OutDir = abs(outDir)
+	}
+	if rootDir, ok := options["rootDir"].(string); ok {
+		cfg.RootDir = abs(rootDir)
+	}
+
+	if files, ok := data["files"]; ok {
+		cfg.Files = []string{}
+		for _, file := range stringList(files) {
+			cfg.Files = append(cfg.Files, abs(file))
+		}
+	}
+	if include, ok := data["include"]; ok {
+		cfg.Include, cfg.HasInclude = []string{}, true
+		for _, pattern := range stringList(include) {
+			cfg.Include = append(cfg.Include, filepath.ToSlash(abs(pattern)))
+		}
+	}
+
+	references, _ := data["references"].([]interface{})
+	for _, item := range references {
+		ref, _ := item.(map[string]interface{})
+		target, _ := ref["path"].(string)
+		if target == "" {
+			continue
+		}
+		// A reference names a tsconfig file or a directory containing tsconfig.json
+		target = abs(target)
+		if !strings.HasSuffix(target, ".json") {
+			target = filepath.Join(target, "tsconfig.json")
+		}
+		cfg.References = append(cfg.References, target)
+	}
+	return cfg, nil
+}
+
+// inherit copies the settings of an extended config; later bases override earlier ones
+func (cfg *tsConfig) inherit(base *tsConfig) {
+	if base.BaseURL != "" {
+		cfg.BaseURL = base.BaseURL
+	}
+	if base.Paths != nil {
+		cfg.Paths, cfg.PathsDir = base.Paths, base.PathsDir
+	}
+	if base.Files != nil {
+		cfg.Files = base.Files
+	}
+	if base.HasInclude {
+		cfg.Include, cfg.HasInclude = base.Include, true
+	}
+	if base.OutDir != "" {
+		cfg.OutDir = base.OutDir
+	}
+	if base.RootDir != "" {
+		cfg.RootDir = base.RootDir
+	}
+}
+
+// tsExtendsPath locates the config an "extends" entry names: a relative path,
+// or a package (or file inside one) under node_modules
+func tsExtendsPath(dir, spec string) string {
+	candidates := func(base string) []string {
+		if strings.HasSuffix(base, ".json") {
+			return []string{base}
+		}
+		return []string{base, base + ".json", filepath.Join(base, "tsconfig.json")}
+	}
+
+	if strings.HasPrefix(spec, ".") || filepath.IsAbs(spec) {
+		base := spec
+		if !filepath.IsAbs(spec) {
+			base = filepath.Join(dir, filepath.FromSlash(spec))
+		}
+		for _, candidate := range candidates(base) {
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate
+			}
+		}
+		return ""
+	}
+
+	for d := dir; ; d = filepath.Dir(d) {
+		for _, candidate := range candidates(filepath.Join(d, "node_modules", filepath.FromSlash(spec))) {
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate
+			}
+		}
+		if filepath.Dir(d) == d {
+			return ""
+		}
+	}
+}
+
+// includes reports whether a source file is part of the project
+func (cfg *tsConfig) includes(file string) bool {
+	for _, f := range cfg.Files {
+		if f == file {
+			return true
+		}
+	}
+	if !cfg.HasInclude {
+		if cfg.Files != nil {
+			return false
+		}
+		rel, err := filepath.Rel(cfg.Dir, file)
+		return err == nil && !strings.HasPrefix(rel, "..")
+	}
+	slashed := filepath.ToSlash(file)
+	for _, pattern := range cfg.Include {
+		// A pattern without wildcards in its last segment may name a directory
+		if matchPathGlob(pattern, slashed) || matchPathGlob(pattern+"/**", slashed) {
+			return true
+		}
+	}
+	return false
+}
+
+// tsConfigFor returns the project a file belongs to: the nearest tsconfig.json,
+// or the first project it references (directly or not) that includes the file
+func (r *importResolver) tsConfigFor(file string) *tsConfig {
+	dir := filepath.Dir(file)
+	nearest, ok := r.tsNearest[dir]
+	if !ok {
+		for d := dir; ; d = filepath.Dir(d) {
+			if path := filepath.Join(d, "tsconfig.json"); r.fileExists(path) {
+				nearest = r.tsConfig(path)
+				if nearest != nil {
+					break
+				}
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+		r.tsNearest[dir] = nearest
+	}
+	if nearest == nil {
+		return nil
+	}
+
+	for _, project := range r.tsProjects(nearest) {
+		if project.includes(file) {
+			return project
+		}
+	}
+	return nearest
+}
+
+// tsConfig loads a tsconfig file once
+func (r *importResolver) tsConfig(path string) *tsConfig {
+	cfg, ok := r.tsConfigs[path]
+	if !ok {
+		cfg, _ = loadTSConfig(path, make(map[string]bool))
+		r.tsConfigs[path] = cfg
+	}
+	return cfg
+}
+
+// tsProjects returns a project followed by every project it references,
+// breadth first
+func (r *importResolver) tsProjects(root *tsConfig) []*tsConfig {
+	projects := []*tsConfig{root}
+	seen := map[string]bool{root.Path: true}
+	for i := 0; i < len(projects); i++ {
+		for _, path := range projects[i].References {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			if cfg := r.tsConfig(path); cfg != nil {
+				projects = append(projects, cfg)
+			}
+		}
+	}
+	return projects
+}
+
+// resolveTSConfig resolves a bare specifier through the paths mappings and
+// baseUrl of a project, then through the package names of the projects it
+// references
+func (r *importResolver) resolveTSConfig(cfg *tsConfig, spec string) string {
+	// An exact pattern wins, then the wildcard pattern with the longest prefix
+	best, star, bestLen := "", "", -1
+	for _, pattern := range sortedKeys(cfg.Paths) {
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		switch {
+		case !wildcard && pattern == spec:
+			best, star, bestLen = pattern, "", len(spec)+1
+		case wildcard && len(prefix) > bestLen && len(spec) >= len(prefix)+len(suffix) &&
+			strings.HasPrefix(spec, prefix) && strings.HasSuffix(spec, suffix):
+			best, star, bestLen = pattern, spec[len(prefix):len(spec)-len(suffix)], len(prefix)
+		}
+	}
+	if best != "" {
+		base := cfg.PathsDir
+		if cfg.BaseURL != "" {
+			base = cfg.BaseURL
+		}
+		for _, target := range cfg.Paths[best] {
+			if file := r.resolveTSPath(filepath.Join(base, filepath.FromSlash(strings.Replace(target, "*", star, 1)))); file != "" {
+				return file
+			}
+		}
+	}
+
+	if cfg.BaseURL != "" {
+		if file := r.resolveTSPath(filepath.Join(cfg.BaseURL, filepath.FromSlash(spec))); file != "" {
+			return file
+		}
+	}
+
+	for _, project := range r.tsProjects(cfg)[1:] {
+		if file := r.resolveProjectPackage(project, spec); file != "" {
+			return file
+		}
+	}
+	return ""
+}
+
+// resolveProjectPackage resolves an import of a referenced project by the
+// name in its package.json to that project's source files
+func (r *importResolver) resolveProjectPackage(project *tsConfig, spec string) string {
+	var pkg struct {
+		Name    string `json:"name"`
+		Types   string `json:"types"`
+		Typings string `json:"typings"`
+		Module  string `json:"module"`
+		Main    string `json:"main"`
+	}
+	content, err := os.ReadFile(filepath.Join(project.Dir, "package.json"))
+	if err != nil || json.Unmarshal(content, &pkg) != nil || pkg.Name == "" {
+		return ""
+	}
+	if spec != pkg.Name && !strings.HasPrefix(spec, pkg.Name+"/") {
+		return ""
+	}
+
+	sourceRoot := project.RootDir
+	if sourceRoot == "" {
+		sourceRoot = project.Dir
+	}
+	if sub := strings.TrimPrefix(strings.TrimPrefix(spec, pkg.Name), "/"); sub != "" {
+		for _, base := range []string{project.Dir, sourceRoot} {
+			if file := r.resolveTSPath(filepath.Join(base, filepath.FromSlash(sub))); file != "" {
+				return file
+			}
+		}
+		return ""
+	}
+
+	for _, entry := range []string{pkg.Types, pkg.Typings, pkg.Module, pkg.Main} {
+		if entry == "" {
+			continue
+		}
+		if file := r.resolveTSPath(filepath.Join(project.Dir, filepath.FromSlash(entry))); file != "" {
+			return file
+		}
+	}
+	for _, index := range []string{filepath.Join(sourceRoot, "index"), filepath.Join(project.Dir, "src", "index")} {
+		if file := r.resolveJSPath(index); file != "" {
+			return file
+		}
+	}
+	return ""
+}
+
+// resolveTSPath resolves a path like resolveJSPath, mapping build output of a
+// project (its outDir, including .d.ts declarations) back to the source under
+// its rootDir
+func (r *importResolver) resolveTSPath(target string) string {
+	if file := r.resolveJSPath(target); file != "" {
+		return file
+	}
+
+	for _, path := range sortedKeys(r.tsConfigs) {
+		cfg := r.tsConfigs[path]
+		if cfg == nil || cfg.OutDir == "" {
+			continue
+		}
+		rel, err := filepath.Rel(cfg.OutDir, target)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		sourceRoot := cfg.RootDir
+		if sourceRoot == "" {
+			sourceRoot = cfg.Dir
+		}
+		source := filepath.Join(sourceRoot, strings.TrimSuffix(rel, ".d.ts"))
+		if rel == "." {
+			source = sourceRoot
+		}
+		if file := r.resolveJSPath(source); file != "" {
+			return file
+		}
+	}
+	return ""
+}
+
+// stripJSONComments removes the comments and trailing commas that tsconfig
+// files may contain, leaving plain JSON
+func stripJSONComments(content []byte) []byte {
+	out := make([]byte, 0, len(content))
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(content) && content[j] != '"' {
+				if content[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(content) {
+				j = len(content) - 1
+			}
+			out = append(out, content[i:j+1]...)
+			i = j
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := strings.Index(string(content[i+2:]), "*/")
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end + 3
+			}
+		case c == ',':
+			// Drop a comma followed only by whitespace and comments before } or ]
+			j := i + 1
+			for j < len(content) {
+				if content[j] == ' ' || content[j] == '\t' || content[j] == '\n' || content[j] == '\r' {
+					j++
+				} else if j+1 < len(content) && content[j] == '/' && content[j+1] == '/' {
+					for j < len(content) && content[j] != '\n' {
+						j++
+					}
+				} else if j+1 < len(content) && content[j] == '/' && content[j+1] == '*' {
+					if end := strings.Index(string(content[j+2:]), "*/"); end >= 0 {
+						j += end + 4
+					} else {
+						j = len(content)
+					}
+				} else {
+					break
+				}
+			}
+			if j < len(content) && (content[j] == '}' || content[j] == ']') {
+				continue
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// Patterns over TypeScript source with comments, regular expressions and
+	// the contents of string and template literals blanked out; group 2 (or 1)
+	// is the opening quote of the module specifier
+	tsStaticImport  = regexp.MustCompile(`\bimport\s+(type\s+)?(?:[\w$*{}\s,]+?\s+from\s*)?(['"])[^'"\n]*['"]`)
+	tsReexport      = regexp.MustCompile(`\bexport\s+(type\s+)?(?:\*(?:\s+as\s+[\w$]+)?|\{[^{}]*\})\s*from\s*(['"])[^'"\n]*['"]`)
+	tsDynamicImport = regexp.MustCompile(`\bimport\s*\(\s*(['"])[^'"\n]*['"]`)
+	tsRequire       = regexp.MustCompile(`\brequire\s*\(\s*(['"])[^'"\n]*['"]\s*\)`)
+	tsReference     = regexp.MustCompile(`^///\s*<reference\s+(path|types)\s*=\s*["']([^"']+)["']`)
+)
+
+// jsSource is TypeScript source prepared for matching: Code has the same
+// length and line breaks as the original, Strings maps the offset of each
+// opening quote to the literal's value
+type jsSource struct {
+	Code       string
+	Strings    map[int]string
+	References []ImportRef
+}
+
+// parseTypeScriptImports extracts the imports of a TypeScript file: import
+// declarations (Kind "type" for `import type`), re-exports (Kind "reexport",
+// or "type" for `export type`), dynamic import() (Kind "dynamic"), require()
+// and triple-slash reference directives (Kind "reference")
+func parseTypeScriptImports(content []byte) []ImportRef {
+	src := scanJSSource(string(content))
+	type match struct {
+		offset int
+		ref    ImportRef
+	}
+	var matches []match
+	add := func(pattern *regexp.Regexp, quoteGroup, typeGroup int, kind string) {
+		for _, m := range pattern.FindAllStringSubmatchIndex(src.Code, -1) {
+			if m[0] > 0 && src.Code[m[0]-1] == '.' {
+				continue // a member such as foo.require()
+			}
+			spec, ok := src.Strings[m[2*quoteGroup]]
+			if !ok || spec == "" {
+				continue
+			}
+			ref := ImportRef{Path: spec, Kind: kind, Line: lineAt(src.Code, m[0])}
+			if typeGroup > 0 && m[2*typeGroup] >= 0 {
+				ref.Kind = "type"
+			}
+			matches = append(matches, match{m[0], ref})
+		}
+	}
+	add(tsStaticImport, 2, 1, "")
+	add(tsReexport, 2, 1, "reexport")
+	add(tsDynamicImport, 1, 0, "dynamic")
+	add(tsRequire, 1, 0, "")
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].offset < matches[j].offset })
+	refs := src.References
+	for _, m := range matches {
+		refs = append(refs, m.ref)
+	}
+	return refs
+}
+
+// scanJSSource blanks the comments, regular expression literals and template
+// literals of JavaScript/TypeScript source, keeping line breaks, and records
+// the value of every string literal; triple-slash reference directives are
+// collected on the way
+func scanJSSource(src string) *jsSource {
+	out := []byte(src)
+	result := &jsSource{Strings: make(map[int]string)}
+	blank := func(from, to int) {
+		for k := from; k < to && k < len(out); k++ {
+			if out[k] != '\n' {
+				out[k] = ' '
+			}
+		}
+	}
+
+	var templates []int // brace depth at each open ${ of a template literal
+	braces := 0
+	inTemplate := false
+	var prev byte // last significant code character
+	i := 0
+	for i < len(src) {
+		if inTemplate {
+			start := i
+			for i < len(src) && src[i] != '`' && !strings.HasPrefix(src[i:], "${") {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			blank(start, i)
+			switch {
+			case i >= len(src):
+			case src[i] == '`':
+				blank(i, i+1)
+				i++
+				inTemplate, prev = false, '`'
+			default:
+				blank(i, i+2)
+				i += 2
+				braces++
+				templates = append(templates, braces)
+				inTemplate, prev = false, '{'
+			}
+			continue
+		}
+
+		c := src[i]
+		switch {
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			if m := tsReference.FindStringSubmatch(src[i : i+end]); m != nil {
+				ref := ImportRef{Path: m[2], Kind: "reference", Line: lineAt(src, i)}
+				if m[1] == "path" && !strings.HasPrefix(ref.Path, ".") && !strings.HasPrefix(ref.Path, "/") {
+					ref.Path = "./" + ref.Path // reference paths are always file-relative
+				}
+				result.References = append(result.References, ref)
+			}
+			blank(i, i+end)
+			i += end
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src) - i
+			} else {
+				end += 4
+			}
+			blank(i, i+end)
+			i += end
+		case c == '\'' || c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != c && src[j] != '\n' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(src) && src[j] == c {
+				result.Strings[i] = src[i+1 : j]
+				blank(i+1, j)
+				j++
+			}
+			i, prev = j, c
+		case c == '`':
+			blank(i, i+1)
+			i++
+			inTemplate = true
+		case c == '/' && jsRegexAllowed(prev):
+			j := i + 1
+			inClass := false
+			for j < len(src) && src[j] != '\n' && (inClass || src[j] != '/') {
+				switch src[j] {
+				case '\\':
+					j++
+				case '[':
+					inClass = true
+				case ']':
+					inClass = false
+				}
+				j++
+			}
+			if j < len(src) && src[j] == '/' {
+				j++
+			}
+			blank(i, j)
+			i, prev = j, '/'
+		default:
+			switch c {
+			case '{':
+				braces++
+			case '}':
+				if n := len(templates); n > 0 && templates[n-1] == braces {
+					templates = templates[:n-1]
+					braces--
+					blank(i, i+1)
+					i++
+					inTemplate = true
+					continue
+				}
+				braces--
+			}
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				prev = c
+			}
+			i++
+		}
+	}
+
+	result.Code = string(out)
+	return result
+}
+
+// jsRegexAllowed reports whether a '/' after the given character starts a
+// regular expression literal rather than a division
+func jsRegexAllowed(prev byte) bool {
+	if prev == 0 {
+		return true
+	}
+	return strings.IndexByte("(,=:[!&|?{};+-*%<>~^", prev) >= 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTypeScriptImports(t *testing.T) {
+	src := `import React, { useState } from 'react';
+import type { T } from "./types";
+export * from './reexp';
+export { a } from './reexp2';
+const m = await import('./lazy');
+const fs = require('fs');
+/// <reference path="./globals.d.ts" />
+// import nope from 'nope'
+const s = "import x from 'y'";
+const re = /import('z')/;
+import './side-effect';
+`
+	want := []ImportRef{
+		{Path: "./globals.d.ts", Kind: "reference", Line: 7},
+		{Path: "react", Line: 1},
+		{Path: "./types", Kind: "type", Line: 2},
+		{Path: "./reexp", Kind: "reexport", Line: 3},
+		{Path: "./reexp2", Kind: "reexport", Line: 4},
+		{Path: "./lazy", Kind: "dynamic", Line: 5},
+		{Path: "fs", Line: 6},
+		{Path: "./side-effect", Line: 11},
+	}
+	if got := parseTypeScriptImports([]byte(src)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTypeScriptImports:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestStripJSONComments(t *testing.T) {
+	src := "{\n // comment\n \"a\": \"http://x\", /* b */ \"b\": [1,],\n}"
+	want := "{\n \n \"a\": \"http://x\",  \"b\": [1]\n}"
+	if got := string(stripJSONComments([]byte(src))); got != want {
+		t.Errorf("stripJSONComments = %q, want %q", got, want)
+	}
+}
+
+func FuzzParseTypeScriptImports(f *testing.F) {
+	for _, seed := range []string{
+		"import a from 'a';\nexport * from \"b\";\n",
+		"const re = /[/]import('x')/g;\n",
+		"`template ${import('y')}`",
+		"/* unterminated",
+		"{\"compilerOptions\": {\"paths\": {\"@/*\": [\"src/*\"]}}, // c\n}",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, content []byte) {
+		parseTypeScriptImports(content)
+		stripJSONComments(content)
+	})
+}
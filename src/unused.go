@@ -0,0 +1,650 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// UnusedDependency is a declared dependency that no source file of its project imports
+type UnusedDependency struct {
+	Ecosystem string `json:"ecosystem"`
+	Package   string `json:"package"`
+	Manifest  string `json:"manifest"`
+	Group     string `json:"group"`
+}
+
+// UndeclaredDependency is a third-party package imported by source files but
+// not declared in the nearest manifest of its ecosystem
+type UndeclaredDependency struct {
+	Ecosystem string       `json:"ecosystem"`
+	Package   string       `json:"package"`
+	Manifest  string       `json:"manifest"`
+	Imports   []ImportSite `json:"imports"`
+}
+
+// ImportSite is one import statement of an undeclared package
+type ImportSite struct {
+	File   string `json:"file"`
+	Import string `json:"import"`
+	Line   int    `json:"line"`
+}
+
+// languageEcosystems maps source languages to the ecosystem their imports are declared in
+var languageEcosystems = map[string]string{
+	"Go":         "Go",
+	"JavaScript": "npm",
+	"TypeScript": "npm",
+	"Python":     "PyPI",
+	"Rust":       "crates.io",
+	"Ruby":       "RubyGems",
+	"Java":       "Maven",
+	"C#":         "NuGet",
+}
+
+// pythonImportAliases maps import names to the distribution that provides
+// them, where the two differ beyond PEP 503 normalization
+var pythonImportAliases = map[string]string{
+	"attr":            "attrs",
+	"bs4":             "beautifulsoup4",
+	"Crypto":          "pycryptodome",
+	"cv2":             "opencv-python",
+	"dateutil":        "python-dateutil",
+	"discord":         "discord-py",
+	"dns":             "dnspython",
+	"docx":            "python-docx",
+	"dotenv":          "python-dotenv",
+	"engineio":        "python-engineio",
+	"faiss":           "faiss-cpu",
+	"fitz":            "pymupdf",
+	"git":             "gitpython",
+	"github":          "pygithub",
+	"google.protobuf": "protobuf",
+	"googleapiclient": "google-api-python-client",
+	"grpc":            "grpcio",
+	"jose":            "python-jose",
+	"jwt":             "pyjwt",
+	"kafka":           "kafka-python",
+	"ldap":            "python-ldap",
+	"Levenshtein":     "python-levenshtein",
+	"magic":           "python-magic",
+	"multipart":       "python-multipart",
+	"MySQLdb":         "mysqlclient",
+	"nacl":            "pynacl",
+	"OpenSSL":         "pyopenssl",
+	"PIL":             "pillow",
+	"pkg_resources":   "setuptools",
+	"pptx":            "python-pptx",
+	"pythoncom":       "pywin32",
+	"serial":          "pyserial",
+	"skimage":         "scikit-image",
+	"sklearn":         "scikit-learn",
+	"slugify":         "python-slugify",
+	"socketio":        "python-socketio",
+	"telegram":        "python-telegram-bot",
+	"usb":             "pyusb",
+	"websocket":       "websocket-client",
+	"win32api":        "pywin32",
+	"win32con":        "pywin32",
+	"yaml":            "pyyaml",
+	"zmq":             "pyzmq",
+}
+
+// pythonNamespacePackages are top-level packages shared by many
+// distributions, each providing one subpackage, e.g. google-cloud-storage
+var pythonNamespacePackages = setOf("azure", "backports", "google", "jaraco", "sphinxcontrib", "zope")
+
+// pythonDistSuffixes are build variants of a distribution that install the same modules
+var pythonDistSuffixes = []string{"-binary", "-headless", "-cpu", "-gpu"}
+
+// rubyRequireAliases maps require paths to the gem that provides them
+var rubyRequireAliases = map[string]string{
+	"action_controller": "actionpack",
+	"action_dispatch":   "actionpack",
+	"action_mailer":     "actionmailer",
+	"action_view":       "actionview",
+	"active_job":        "activejob",
+	"active_model":      "activemodel",
+	"active_record":     "activerecord",
+	"active_support":    "activesupport",
+}
+
+// javaPackageAliases maps Java package prefixes to the Maven artifact that
+// provides them, where the package does not follow the group id
+var javaPackageAliases = map[string]string{
+	"com.google.common":        "com.google.guava:guava",
+	"lombok":                   "org.projectlombok:lombok",
+	"org.apache.commons.io":    "commons-io:commons-io",
+	"org.apache.commons.lang3": "org.apache.commons:commons-lang3",
+	"org.junit":                "junit:junit",
+	"org.junit.jupiter":        "org.junit.jupiter:junit-jupiter",
+}
+
+// toolPackages are packages used as command-line tools, plugins or runtime
+// drivers rather than imported; a trailing or leading "*" matches any suffix or prefix
+var toolPackages = map[string][]string{
+	"npm": {
+		"@babel/*", "@types/*", "@typescript-eslint/*", "autoprefixer", "babel-*",
+		"concurrently", "cross-env", "esbuild", "eslint", "eslint-*", "husky", "jest",
+		"lint-staged", "nodemon", "npm-run-all", "postcss", "prettier", "prettier-*",
+		"rimraf", "rollup", "sass", "tailwindcss", "ts-node", "tsx", "typescript",
+		"vite", "vitest", "webpack", "webpack-cli",
+	},
+	"PyPI": {
+		"black", "build", "coverage", "flake8", "flake8-*", "gunicorn", "hatchling",
+		"isort", "mkdocs", "mkdocs-*", "mypy", "nox", "pip", "poetry-core", "pre-commit",
+		"pylint", "pytest", "pytest-*", "ruff", "setuptools", "sphinx", "sphinx-*", "tox",
+		"twine", "types-*", "uvicorn", "wheel",
+	},
+	"NuGet": {
+		"coverlet.*", "Microsoft.NET.Test.Sdk", "Microsoft.SourceLink.*", "*.Analyzers",
+		"xunit.runner.*",
+	},
+	"RubyGems": {"bootsnap", "puma", "rake", "rubocop", "rubocop-*", "unicorn"},
+	"Maven":    {"org.springframework.boot:spring-boot-starter*"},
+}
+
+// nonImportGroups are dependency groups whose packages are not imported by
+// source code: runtime-only and annotation-processor configurations, .NET
+// project and framework references, and named tool environments
+var nonImportGroups = setOf(
+	"runtime", "runtimeOnly", "annotationProcessor", "kapt", "ksp", "developmentOnly",
+	"classpath", "import", "system", "projects", "frameworks",
+)
+
+// isToolPackage reports whether a declared package is a tool rather than a library
+func isToolPackage(ecosystem, name string) bool {
+	for _, pattern := range toolPackages[ecosystem] {
+		switch {
+		case strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*")),
+			strings.HasPrefix(pattern, "*") && strings.HasSuffix(name, strings.TrimPrefix(pattern, "*")),
+			pattern == name:
+			return true
+		}
+	}
+	return false
+}
+
+// expectsImport reports whether source code is expected to import a declared dependency
+func expectsImport(ecosystem string, m *Manifest, dep *Dependency) bool {
+	switch {
+	case isDevGroup(dep.Group), nonImportGroups[dep.Group], dep.Indirect,
+		strings.HasPrefix(dep.Group, "dependency-groups."), strings.HasPrefix(dep.Group, "envs."),
+		dep.Source == "sdk", isToolPackage(ecosystem, dep.Name):
+		return false
+	}
+	if m.Go != nil {
+		for _, tool := range m.Go.Tools {
+			if tool == dep.Name || strings.HasPrefix(tool, dep.Name+"/") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dependencyUsage tracks, for one analysis run, which declared dependencies
+// are imported and which imported packages are missing from their manifest
+type dependencyUsage struct {
+	c          *Crawler
+	manifests  map[string][]*Manifest // ecosystem|directory -> manifests
+	projects   map[*Manifest][]string // manifest -> source files it owns
+	used       map[*Dependency]bool
+	undeclared map[string]*UndeclaredDependency // manifest|package
+	pyModules  map[string]map[string]string     // directory -> import name -> distribution
+}
+
+// analyzeDependencyUsage compares the packages each project's source files
+// import against the dependencies its nearest manifest declares
+func (c *Crawler) analyzeDependencyUsage() {
+	deps := c.Analysis.Dependencies
+	u := &dependencyUsage{
+		c:          c,
+		manifests:  make(map[string][]*Manifest),
+		projects:   make(map[*Manifest][]string),
+		used:       make(map[*Dependency]bool),
+		undeclared: make(map[string]*UndeclaredDependency),
+		pyModules:  make(map[string]map[string]string),
+	}
+	for _, m := range deps.Manifests {
+		if ecosystem := osvEcosystems[m.Manager]; ecosystem != "" {
+			key := ecosystem + "|" + filepath.Dir(m.Path)
+			u.manifests[key] = append(u.manifests[key], m)
+		}
+	}
+
+	for _, lang := range sortedKeys(c.Analysis.FilesByType) {
+		ecosystem := languageEcosystems[lang]
+		if ecosystem == "" {
+			continue
+		}
+		for _, file := range c.Analysis.FilesByType[lang] {
+			refs, ok := deps.FileImports[file.Path]
+			if !ok {
+				continue
+			}
+			manifests := u.nearestManifests(ecosystem, file.Path)
+			for _, m := range manifests {
+				u.projects[m] = append(u.projects[m], file.Path)
+			}
+			for _, ref := range refs {
+				if ref.Class == importInternal {
+					continue
+				}
+				u.recordImport(ecosystem, file.Path, ref, manifests)
+			}
+		}
+	}
+
+	deps.UnusedDeps = u.unusedDependencies()
+	deps.UndeclaredDeps = []*UndeclaredDependency{}
+	for _, key := range sortedKeys(u.undeclared) {
+		deps.UndeclaredDeps = append(deps.UndeclaredDeps, u.undeclared[key])
+	}
+}
+
+// nearestManifests returns the manifests of an ecosystem in the closest
+// directory at or above file, within the scanned repository
+func (u *dependencyUsage) nearestManifests(ecosystem, file string) []*Manifest {
+	dir := filepath.Dir(file)
+	for {
+		if manifests := u.manifests[ecosystem+"|"+dir]; len(manifests) > 0 {
+			return manifests
+		}
+		parent := filepath.Dir(dir)
+		if rel, err := filepath.Rel(u.c.Config.TargetPath, dir); err != nil || rel == "." || strings.HasPrefix(rel, "..") || parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+// recordImport marks the dependencies an import uses, or records the import
+// as undeclared when it names a third-party package none of them provide
+func (u *dependencyUsage) recordImport(ecosystem, file string, ref ImportRef, manifests []*Manifest) {
+	if ref.Kind == "reference" {
+		return // triple-slash directives name type declarations, not packages
+	}
+	var distributions []string
+	if ecosystem == "PyPI" {
+		distributions = u.pythonDistributions(ref.Path, manifests)
+	}
+	pkg := u.importPackage(ecosystem, ref.Path, manifests, distributions)
+	if pkg == "" {
+		return
+	}
+	found := false
+	for _, m := range manifests {
+		if u.isSelf(ecosystem, m, pkg) {
+			return
+		}
+		for _, dep := range m.Dependencies {
+			if u.provides(ecosystem, dep, ref.Path, pkg, distributions) {
+				u.used[dep] = true
+				found = true
+			}
+		}
+	}
+
+	// Standard-library imports only count towards usage, and imports outside
+	// any project of their ecosystem have no manifest to be declared in
+	if found || ref.Class != importExternal || len(manifests) == 0 {
+		return
+	}
+	manifest := manifests[0].Path
+	key := manifest + "|" + pkg
+	d := u.undeclared[key]
+	if d == nil {
+		d = &UndeclaredDependency{Ecosystem: ecosystem, Package: pkg, Manifest: manifest}
+		u.undeclared[key] = d
+	}
+	d.Imports = append(d.Imports, ImportSite{File: file, Import: ref.Path, Line: ref.Line})
+}
+
+// importPackage maps an import string to the name of the package it comes
+// from, or "" when the import does not name a package; distributions are the
+// candidates of a Python import
+func (u *dependencyUsage) importPackage(ecosystem, importPath string, manifests []*Manifest, distributions []string) string {
+	switch ecosystem {
+	case "npm":
+		// Subpath imports (#x), path aliases (@/x, ~/x) and bundler virtual modules
+		if strings.HasPrefix(importPath, "#") || strings.HasPrefix(importPath, "@/") ||
+			strings.HasPrefix(importPath, "~") || strings.HasPrefix(importPath, "/") || strings.Contains(importPath, ":") {
+			return ""
+		}
+		parts := strings.SplitN(importPath, "/", 3)
+		if strings.HasPrefix(importPath, "@") && len(parts) > 1 {
+			return parts[0] + "/" + parts[1]
+		}
+		return parts[0]
+	case "Go":
+		for _, m := range manifests {
+			for _, dep := range m.Dependencies {
+				if importPath == dep.Name || strings.HasPrefix(importPath, dep.Name+"/") {
+					return dep.Name
+				}
+			}
+		}
+		parts := strings.Split(importPath, "/")
+		switch {
+		case parts[0] == "gopkg.in" && len(parts) >= 2:
+			return strings.Join(parts[:2], "/")
+		case len(parts) >= 3 && (parts[0] == "github.com" || parts[0] == "gitlab.com" || parts[0] == "bitbucket.org" || parts[0] == "golang.org"):
+			// Keep the major version suffix of a v2+ module
+			if len(parts) >= 4 && goMajorVersion.MatchString(parts[3]) {
+				return strings.Join(parts[:4], "/")
+			}
+			return strings.Join(parts[:3], "/")
+		}
+		return importPath
+	case "PyPI":
+		return distributions[0]
+	case "crates.io":
+		return strings.SplitN(importPath, "::", 2)[0]
+	case "RubyGems":
+		first := strings.SplitN(importPath, "/", 2)[0]
+		if gem, ok := rubyRequireAliases[first]; ok {
+			return gem
+		}
+		return first
+	case "Maven":
+		for prefix := importPath; prefix != ""; prefix = parentPackage(prefix) {
+			if artifact, ok := javaPackageAliases[prefix]; ok {
+				return artifact
+			}
+		}
+		segments := strings.Split(importPath, ".")
+		for i, segment := range segments {
+			if segment != "" && segment[0] >= 'A' && segment[0] <= 'Z' {
+				segments = segments[:i]
+				break
+			}
+		}
+		if len(segments) > 3 {
+			segments = segments[:3]
+		}
+		return strings.Join(segments, ".")
+	case "NuGet":
+		segments := strings.Split(importPath, ".")
+		if len(segments) > 2 {
+			segments = segments[:2]
+		}
+		return strings.Join(segments, ".")
+	}
+	return ""
+}
+
+// provides reports whether a declared dependency supplies an imported package
+func (u *dependencyUsage) provides(ecosystem string, dep *Dependency, importPath, pkg string, distributions []string) bool {
+	switch ecosystem {
+	case "npm":
+		return dep.Name == pkg || dep.Alias == pkg
+	case "Go":
+		return dep.Name == pkg
+	case "PyPI":
+		name := normalizePythonName(dep.Name)
+		for _, suffix := range pythonDistSuffixes {
+			name = strings.TrimSuffix(name, suffix)
+		}
+		if containsString(distributions, name) {
+			return true
+		}
+		// An import of the namespace itself may use any of its distributions
+		segments := strings.Split(importPath, ".")
+		return pythonNamespacePackages[segments[0]] && strings.HasPrefix(name, normalizePythonName(strings.Join(segments, "-"))+"-")
+	case "crates.io":
+		crate := dep.Name
+		if dep.Alias != "" {
+			crate = dep.Alias
+		}
+		return strings.ReplaceAll(crate, "-", "_") == pkg
+	case "RubyGems":
+		name := strings.ToLower(dep.Name)
+		dashed := strings.ReplaceAll(importPath, "/", "-")
+		return name == pkg || name == importPath || strings.HasPrefix(importPath, name+"/") ||
+			name == dashed || strings.HasPrefix(dashed, name+"-") || name == strings.ReplaceAll(pkg, "_", "")
+	case "Maven":
+		if dep.Name == pkg {
+			return true
+		}
+		return mavenArtifactProvides(dep.Name, importPath)
+	case "NuGet":
+		name, namespace := strings.ToLower(dep.Name), strings.ToLower(importPath)
+		return name == namespace || strings.HasPrefix(namespace, name+".") || strings.HasPrefix(name, namespace+".")
+	}
+	return false
+}
+
+// genericArtifactWords are artifact id words that say nothing about the packages inside
+var genericArtifactWords = setOf("all", "api", "core", "impl", "java", "jvm", "lib")
+
+// mavenArtifactProvides reports whether a group:artifact dependency provides a
+// Java package. Below the group id (or a sibling package sharing at least three
+// segments with it) the package must name the artifact: org.apache.commons:commons-text
+// provides org.apache.commons.text but not org.apache.commons.lang3, and
+// com.fasterxml.jackson.core:jackson-databind provides com.fasterxml.jackson.databind
+func mavenArtifactProvides(name, importPath string) bool {
+	group, artifact, _ := strings.Cut(name, ":")
+	if importPath == group {
+		return true
+	}
+	groupSegments := strings.Split(group, ".")
+	importSegments := strings.Split(importPath, ".")
+	shared := commonPrefixLen(strings.Join(groupSegments, "/"), strings.Join(importSegments, "/"))
+	if shared < len(groupSegments) && shared < 3 {
+		return false
+	}
+
+	inGroup := setOf(groupSegments...)
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(artifact), func(r rune) bool { return r == '-' || r == '.' || r == '_' }) {
+		if !inGroup[word] && !genericArtifactWords[word] {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		return shared == len(groupSegments) // the artifact is named after its group
+	}
+	for _, segment := range importSegments[shared:] {
+		if segment == "" || segment[0] < 'a' || segment[0] > 'z' {
+			continue // class names
+		}
+		for _, word := range words {
+			if len(segment) >= 3 && len(word) >= 3 && (strings.HasPrefix(segment, word) || strings.HasPrefix(word, segment)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isSelf reports whether an import names the project of the manifest itself
+func (u *dependencyUsage) isSelf(ecosystem string, m *Manifest, pkg string) bool {
+	if m.Name == "" {
+		return false
+	}
+	switch ecosystem {
+	case "PyPI":
+		return normalizePythonName(m.Name) == normalizePythonName(pkg)
+	case "crates.io":
+		return strings.ReplaceAll(m.Name, "-", "_") == pkg
+	}
+	return m.Name == pkg
+}
+
+// pythonDistributions lists the distribution names that may provide a dotted
+// module path, most likely first: known aliases, the top_level.txt of
+// distributions installed in a virtual environment, the top-level module
+// itself, then namespace-package and "python-"/"py" prefixed spellings
+func (u *dependencyUsage) pythonDistributions(module string, manifests []*Manifest) []string {
+	segments := strings.Split(module, ".")
+	var names []string
+	add := func(name string) {
+		if name = normalizePythonName(name); !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	for n := len(segments); n > 0; n-- {
+		if dist, ok := pythonImportAliases[strings.Join(segments[:n], ".")]; ok {
+			add(dist)
+		}
+	}
+	for _, m := range manifests {
+		if dist, ok := u.installedPythonModules(filepath.Dir(m.Path))[segments[0]]; ok {
+			add(dist)
+		}
+	}
+	if pythonNamespacePackages[segments[0]] && len(segments) > 1 {
+		add(strings.Join(segments, "-"))
+	}
+	add(segments[0])
+
+	// Namespace packages such as google.cloud.storage or azure.identity
+	for n := len(segments); n > 1; n-- {
+		add(strings.Join(segments[:n], "-"))
+	}
+	add("python-" + segments[0])
+	add("py" + segments[0])
+	return names
+}
+
+// installedPythonModules maps the top-level import names of the distributions
+// installed in the virtual environments of dir to their distribution name
+func (u *dependencyUsage) installedPythonModules(dir string) map[string]string {
+	if modules, ok := u.pyModules[dir]; ok {
+		return modules
+	}
+	modules := make(map[string]string)
+	u.pyModules[dir] = modules
+	for _, venv := range pythonVirtualenvs {
+		matches, _ := filepath.Glob(filepath.Join(dir, venv, "lib", "python*", "site-packages", "*.dist-info"))
+		windows, _ := filepath.Glob(filepath.Join(dir, venv, "Lib", "site-packages", "*.dist-info"))
+		for _, distInfo := range append(matches, windows...) {
+			dist, _, _ := strings.Cut(strings.TrimSuffix(filepath.Base(distInfo), ".dist-info"), "-")
+			for _, module := range distTopLevel(distInfo) {
+				if _, taken := modules[module]; !taken {
+					modules[module] = dist
+				}
+			}
+		}
+	}
+	return modules
+}
+
+// distTopLevel reads the top-level import names of an installed distribution
+// from top_level.txt, falling back to the paths listed in RECORD
+func distTopLevel(distInfo string) []string {
+	if content, err := os.ReadFile(filepath.Join(distInfo, "top_level.txt")); err == nil {
+		return strings.Fields(string(content))
+	}
+	file, err := os.Open(filepath.Join(distInfo, "RECORD"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	seen := make(map[string]bool)
+	var modules []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		path := strings.SplitN(scanner.Text(), ",", 2)[0]
+		first := strings.SplitN(path, "/", 2)[0]
+		switch {
+		case strings.HasSuffix(first, ".dist-info"), strings.HasPrefix(first, ".."), first == "__pycache__":
+			continue
+		case strings.HasSuffix(first, ".py"):
+			first = strings.TrimSuffix(first, ".py")
+		case !strings.Contains(path, "/"):
+			continue // data files and compiled extensions at the top level
+		}
+		if !seen[first] {
+			seen[first] = true
+			modules = append(modules, first)
+		}
+	}
+	return modules
+}
+
+// goMajorVersion matches the /vN element of a major version 2+ module path
+var goMajorVersion = regexp.MustCompile(`^v([2-9]|[1-9][0-9]+)$`)
+
+// rustCratePath matches a path rooted at a crate name, e.g. serde_json::json!
+var rustCratePath = regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)::`)
+
+// unusedDependencies lists the declared dependencies of every project with
+// analyzed sources that none of those sources import
+func (u *dependencyUsage) unusedDependencies() []*UnusedDependency {
+	unused := []*UnusedDependency{}
+	for _, m := range u.c.Analysis.Dependencies.Manifests {
+		files, ok := u.projects[m]
+		if !ok {
+			continue
+		}
+		ecosystem := osvEcosystems[m.Manager]
+
+		// Bundler.require loads every gem of a Rails app without a require
+		if ecosystem == "RubyGems" && declares(m, "rails") {
+			continue
+		}
+
+		// Rust code refers to crates by path without a use declaration
+		var mentioned map[string]bool
+		if ecosystem == "crates.io" {
+			mentioned = rustCrateMentions(files)
+		}
+
+		for _, dep := range m.Dependencies {
+			if u.used[dep] || !expectsImport(ecosystem, m, dep) {
+				continue
+			}
+			if mentioned != nil {
+				crate := dep.Name
+				if dep.Alias != "" {
+					crate = dep.Alias
+				}
+				if mentioned[strings.ReplaceAll(crate, "-", "_")] {
+					continue
+				}
+			}
+			unused = append(unused, &UnusedDependency{Ecosystem: ecosystem, Package: dep.Name, Manifest: m.Path, Group: dep.Group})
+		}
+	}
+	sort.SliceStable(unused, func(i, j int) bool { return unused[i].Manifest < unused[j].Manifest })
+	return unused
+}
+
+// declares reports whether a manifest declares a package
+func declares(m *Manifest, name string) bool {
+	for _, dep := range m.Dependencies {
+		if dep.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// rustCrateMentions collects the leading path segments used in Rust sources
+func rustCrateMentions(files []string) map[string]bool {
+	mentioned := make(map[string]bool)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, match := range rustCratePath.FindAllStringSubmatch(string(content), -1) {
+			mentioned[match[1]] = true
+		}
+	}
+	return mentioned
+}
+
+// parentPackage drops the last segment of a dotted package name
+func parentPackage(pkg string) string {
+	if i := strings.LastIndex(pkg, "."); i >= 0 {
+		return pkg[:i]
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestMavenArtifactProvides(t *testing.T) {
+	tests := []struct {
+		artifact string
+		pkg      string
+		want     bool
+	}{
+		{"org.slf4j:slf4j-api", "org.slf4j", true},
+		{"org.slf4j:slf4j-api", "org.slf4j.Logger", true},
+		{"org.apache.commons:commons-text", "org.apache.commons.text.StringSubstitutor", true},
+		{"org.apache.commons:commons-text", "org.apache.commons.lang3.StringUtils", false},
+		{"com.fasterxml.jackson.core:jackson-databind", "com.fasterxml.jackson.databind.ObjectMapper", true},
+		{"com.fasterxml.jackson.core:jackson-annotations", "com.fasterxml.jackson.annotation.JsonProperty", true},
+		{"com.fasterxml.jackson.core:jackson-core", "com.fasterxml.jackson.core.JsonParser", true},
+		{"com.fasterxml.jackson.core:jackson-databind", "com.fasterxml.jackson.dataformat.yaml.YAMLFactory", false},
+		{"io.netty:netty-handler", "io.netty.handler.ssl.SslContext", true},
+		{"io.netty:netty-handler", "io.netty.buffer.ByteBuf", false},
+		{"org.apache.httpcomponents:httpclient", "org.apache.http.client.HttpClient", false},
+	}
+	for _, tt := range tests {
+		if got := mavenArtifactProvides(tt.artifact, tt.pkg); got != tt.want {
+			t.Errorf("mavenArtifactProvides(%q, %q) = %v, want %v", tt.artifact, tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestDependencyUsage(t *testing.T) {
+	c, root := scanFixture(t, map[string]string{
+		"web/package.json":     `{"name": "web", "dependencies": {"lodash": "^4.17.21", "express": "^4.18.0"}, "devDependencies": {"jest": "^29.0.0"}}`,
+		"web/index.js":         "import _ from 'lodash';\nimport fs from 'fs';\nimport axios from 'axios';\nimport { helper } from './helper';\n",
+		"web/helper.js":        "export const helper = 1;\n",
+		"api/requirements.txt": "requests==2.31.0\nPyYAML==6.0\nboto3==1.34.0\n",
+		"api/app.py":           "import os\nimport requests\nimport yaml\nfrom numpy import array\n",
+	})
+
+	var unused []string
+	for _, u := range c.Analysis.Dependencies.UnusedDeps {
+		unused = append(unused, u.Ecosystem+" "+u.Package+" "+relativePath(root, u.Manifest))
+	}
+	wantUnused := []string{"PyPI boto3 api/requirements.txt", "npm express web/package.json"}
+	if !reflect.DeepEqual(unused, wantUnused) {
+		t.Errorf("unused = %q, want %q", unused, wantUnused)
+	}
+
+	var undeclared []string
+	for _, u := range c.Analysis.Dependencies.UndeclaredDeps {
+		for _, site := range u.Imports {
+			undeclared = append(undeclared, u.Package+" "+relativePath(root, site.File)+":"+strconv.Itoa(site.Line))
+		}
+	}
+	wantUndeclared := []string{"numpy api/app.py:4", "axios web/index.js:3"}
+	if !reflect.DeepEqual(undeclared, wantUndeclared) {
+		t.Errorf("undeclared = %q, want %q", undeclared, wantUndeclared)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStripCStyleComments(t *testing.T) {
+	src := "a // line\nb \"// kept\" /* block\nspans */ c '/*'\n"
+	want := "a \nb \"// kept\" \n c '/*'\n"
+	if got := stripCStyleComments(src); got != want {
+		t.Errorf("stripCStyleComments = %q, want %q", got, want)
+	}
+}
+
+func TestSplitTopLevel(t *testing.T) {
+	got := splitTopLevel(`a, f(b, c), "d,e", [g, h]`, ',')
+	want := []string{"a", " f(b, c)", ` "d,e"`, " [g, h]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitTopLevel = %q, want %q", got, want)
+	}
+}
+
+func TestQuotedString(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+		ok   bool
+	}{
+		{` "double" `, "double", true},
+		{"'single'", "single", true},
+		{`"mismatched'`, "", false},
+		{"bare", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := quotedString(tt.expr)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("quotedString(%q) = %q, %v, want %q, %v", tt.expr, got, ok, tt.want, tt.ok)
+		}
+	}
+}
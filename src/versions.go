@@ -0,0 +1,373 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// compareVersions orders two versions under the rules of an OSV ecosystem:
+// SemVer for npm, crates.io, Go (including pseudo-versions), NuGet and Pub,
+// PEP 440 for PyPI, Maven's ComparableVersion for Maven and RubyGems-style
+// segment comparison otherwise. It returns -1, 0 or 1.
+func compareVersions(ecosystem, a, b string) int {
+	switch ecosystem {
+	case "PyPI":
+		return comparePEP440(a, b)
+	case "Maven":
+		return compareMaven(a, b)
+	case "npm", "crates.io", "Go", "NuGet", "Pub", "SwiftURL":
+		return compareSemver(a, b)
+	}
+	return compareSegments(a, b)
+}
+
+// compareNumeric compares two strings of digits of any length
+func compareNumeric(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// compareSemver compares SemVer 2.0 versions. A leading "v" is ignored,
+// missing minor and patch numbers count as 0 and build metadata (such as Go's
+// +incompatible) does not affect ordering.
+func compareSemver(a, b string) int {
+	coreA, preA := splitSemver(a)
+	coreB, preB := splitSemver(b)
+	for i := 0; i < len(coreA) || i < len(coreB); i++ {
+		x, y := "0", "0"
+		if i < len(coreA) {
+			x = coreA[i]
+		}
+		if i < len(coreB) {
+			y = coreB[i]
+		}
+		if c := compareNumeric(x, y); c != 0 {
+			return c
+		}
+	}
+
+	// A version without a prerelease sorts after any prerelease of it
+	switch {
+	case preA == "" && preB == "":
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	idsA, idsB := strings.Split(preA, "."), strings.Split(preB, ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		x, y := idsA[i], idsB[i]
+		var c int
+		switch {
+		case isDigits(x) && isDigits(y):
+			c = compareNumeric(x, y)
+		case isDigits(x):
+			c = -1
+		case isDigits(y):
+			c = 1
+		default:
+			c = strings.Compare(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(idsA), len(idsB))
+}
+
+// splitSemver splits a version into its numeric core and prerelease
+func splitSemver(v string) ([]string, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if idx := strings.Index(v, "+"); idx >= 0 {
+		v = v[:idx]
+	}
+	pre := ""
+	if idx := strings.Index(v, "-"); idx >= 0 {
+		v, pre = v[:idx], v[idx+1:]
+	}
+	core := strings.Split(v, ".")
+	for i, part := range core {
+		if !isDigits(part) {
+			core[i] = "0"
+		}
+	}
+	return core, pre
+}
+
+// compareInts compares two ints
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// pep440Version is a parsed PEP 440 version
+type pep440Version struct {
+	epoch   string
+	release []string
+	pre     string // "a", "b" or "rc"; "" for none
+	preN    string
+	post    string // post-release number, "" for none
+	dev     string // dev-release number, "" for none
+}
+
+var (
+	versionSegment = regexp.MustCompile(`\d+|[A-Za-z]+`)
+	pep440Pattern  = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+		`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d*))?` +
+		`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+		`(?:[-_.]?(dev)[-_.]?(\d*))?(?:\+[a-z0-9._-]+)?$`)
+)
+
+// parsePEP440 parses a version; ok is false for versions PEP 440 cannot read
+func parsePEP440(s string) (pep440Version, bool) {
+	match := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if match == nil {
+		return pep440Version{}, false
+	}
+	v := pep440Version{epoch: match[1], release: strings.Split(match[2], ".")}
+	if v.epoch == "" {
+		v.epoch = "0"
+	}
+	switch match[3] {
+	case "alpha":
+		v.pre = "a"
+	case "beta":
+		v.pre = "b"
+	case "c", "pre", "preview":
+		v.pre = "rc"
+	default:
+		v.pre = match[3]
+	}
+	v.preN = orZero(match[4])
+	switch {
+	case match[5] != "":
+		v.post = match[5] // implicit post-release, 1.0-1
+	case match[6] != "":
+		v.post = orZero(match[7])
+	}
+	if match[8] != "" {
+		v.dev = orZero(match[9])
+	}
+	return v, true
+}
+
+// orZero returns s, or "0" when it is empty
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// comparePEP440 compares Python versions: epoch, release, then pre-, post-
+// and dev-releases, with local versions ignored. Versions PEP 440 cannot read
+// fall back to segment comparison.
+func comparePEP440(a, b string) int {
+	x, okA := parsePEP440(a)
+	y, okB := parsePEP440(b)
+	if !okA || !okB {
+		return compareSegments(a, b)
+	}
+	if c := compareNumeric(x.epoch, y.epoch); c != 0 {
+		return c
+	}
+	for i := 0; i < len(x.release) || i < len(y.release); i++ {
+		p, q := "0", "0"
+		if i < len(x.release) {
+			p = x.release[i]
+		}
+		if i < len(y.release) {
+			q = y.release[i]
+		}
+		if c := compareNumeric(p, q); c != 0 {
+			return c
+		}
+	}
+	if c := compareInts(x.preRank(), y.preRank()); c != 0 {
+		return c
+	}
+	if x.pre != "" && x.pre == y.pre {
+		if c := compareNumeric(x.preN, y.preN); c != 0 {
+			return c
+		}
+	}
+	if c := compareOptional(x.post, y.post, -1); c != 0 {
+		return c
+	}
+	return compareOptional(x.dev, y.dev, 1)
+}
+
+// preRank orders the prerelease phase: a dev release of the final version
+// sorts before its prereleases, and the final version after them
+func (v pep440Version) preRank() int {
+	switch {
+	case v.pre == "a":
+		return 1
+	case v.pre == "b":
+		return 2
+	case v.pre == "rc":
+		return 3
+	case v.post == "" && v.dev != "":
+		return 0
+	}
+	return 4
+}
+
+// compareOptional compares optional numbers; missing sorts as sign times infinity
+func compareOptional(a, b string, missing int) int {
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return missing
+	case b == "":
+		return -missing
+	}
+	return compareNumeric(a, b)
+}
+
+// mavenQualifiers ranks Maven's well-known qualifiers; unknown qualifiers
+// sort after all of them, alphabetically
+var mavenQualifiers = map[string]int{
+	"alpha": 1, "a": 1,
+	"beta": 2, "b": 2,
+	"milestone": 3, "m": 3,
+	"rc": 4, "cr": 4,
+	"snapshot": 5,
+	"":         6, "ga": 6, "final": 6, "release": 6,
+	"sp": 7,
+}
+
+// mavenItems splits a version at dots, hyphens and digit/letter transitions,
+// dropping trailing zeros and release qualifiers
+func mavenItems(v string) []string {
+	items := versionSegment.FindAllString(strings.ToLower(v), -1)
+	for len(items) > 0 {
+		last := items[len(items)-1]
+		if isDigits(last) && strings.Trim(last, "0") == "" || !isDigits(last) && mavenQualifiers[last] == 6 {
+			items = items[:len(items)-1]
+			continue
+		}
+		break
+	}
+	return items
+}
+
+// compareMaven compares Maven versions the way ComparableVersion does for
+// common versions: numbers numerically, qualifiers by rank, and a number
+// sorts after a qualifier
+func compareMaven(a, b string) int {
+	x, y := mavenItems(a), mavenItems(b)
+	for i := 0; i < len(x) || i < len(y); i++ {
+		p, q := "", ""
+		if i < len(x) {
+			p = x[i]
+		}
+		if i < len(y) {
+			q = y[i]
+		}
+		// A missing item is 0 next to a number and a release next to a qualifier
+		if p == "" && isDigits(q) {
+			p = "0"
+		}
+		if q == "" && isDigits(p) {
+			q = "0"
+		}
+		var c int
+		switch {
+		case isDigits(p) && isDigits(q):
+			c = compareNumeric(p, q)
+		case isDigits(p):
+			c = 1
+		case isDigits(q):
+			c = -1
+		default:
+			c = compareMavenQualifiers(p, q)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareMavenQualifiers compares qualifiers by rank, then alphabetically
+func compareMavenQualifiers(a, b string) int {
+	rankA, knownA := mavenQualifiers[a]
+	rankB, knownB := mavenQualifiers[b]
+	if !knownA {
+		rankA = 8
+	}
+	if !knownB {
+		rankB = 8
+	}
+	if c := compareInts(rankA, rankB); c != 0 {
+		return c
+	}
+	if !knownA {
+		return strings.Compare(a, b)
+	}
+	return 0
+}
+
+// compareSegments compares dotted versions the way RubyGems does: numeric
+// segments numerically, and a letter segment marks a prerelease that sorts
+// before the release
+func compareSegments(a, b string) int {
+	x := versionSegments(a)
+	y := versionSegments(b)
+	for i := 0; i < len(x) || i < len(y); i++ {
+		p, q := "0", "0"
+		if i < len(x) {
+			p = x[i]
+		}
+		if i < len(y) {
+			q = y[i]
+		}
+		var c int
+		switch {
+		case isDigits(p) && isDigits(q):
+			c = compareNumeric(p, q)
+		case isDigits(p):
+			c = 1
+		case isDigits(q):
+			c = -1
+		default:
+			c = strings.Compare(p, q)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// versionSegments splits a version into numeric and letter segments
+func versionSegments(v string) []string {
+	return versionSegment.FindAllString(strings.TrimPrefix(strings.TrimSpace(v), "v"), -1)
+}
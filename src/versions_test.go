@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+// checkOrder asserts that each version sorts strictly before the next one
+func checkOrder(t *testing.T, ecosystem string, versions []string) {
+	t.Helper()
+	for i := 0; i+1 < len(versions); i++ {
+		a, b := versions[i], versions[i+1]
+		if got := compareVersions(ecosystem, a, b); got != -1 {
+			t.Errorf("%s: compare(%q, %q) = %d, want -1", ecosystem, a, b, got)
+		}
+		if got := compareVersions(ecosystem, b, a); got != 1 {
+			t.Errorf("%s: compare(%q, %q) = %d, want 1", ecosystem, b, a, got)
+		}
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	checkOrder(t, "npm", []string{
+		"0.9.0", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.2", "1.10.0", "10.0.0",
+	})
+	checkOrder(t, "Go", []string{"v1.2.3-0.20230101000000-abcdef123456", "v1.2.3", "v2.0.0+incompatible"})
+
+	for _, pair := range [][2]string{{"1", "1.0.0"}, {"v1.2.3", "1.2.3"}, {"1.0.0+build.1", "1.0.0+build.2"}} {
+		if got := compareSemver(pair[0], pair[1]); got != 0 {
+			t.Errorf("compareSemver(%q, %q) = %d, want 0", pair[0], pair[1], got)
+		}
+	}
+}
+
+func TestComparePEP440(t *testing.T) {
+	checkOrder(t, "PyPI", []string{
+		"1.0.dev1", "1.0a1", "1.0a2.dev1", "1.0a2", "1.0b1", "1.0rc1", "1.0",
+		"1.0.post1.dev1", "1.0.post1", "1.1", "1.10", "1!0.5",
+	})
+
+	for _, pair := range [][2]string{{"1.0", "1.0.0"}, {"1.0-alpha1", "1.0a1"}, {"1.0-1", "1.0.post1"}, {"v2.0", "2.0"}} {
+		if got := comparePEP440(pair[0], pair[1]); got != 0 {
+			t.Errorf("comparePEP440(%q, %q) = %d, want 0", pair[0], pair[1], got)
+		}
+	}
+}
+
+func TestCompareMaven(t *testing.T) {
+	checkOrder(t, "Maven", []string{
+		"1.0-alpha1", "1.0-beta1", "1.0-milestone1", "1.0-rc1", "1.0-SNAPSHOT", "1.0",
+		"1.0-sp1", "1.0.1", "1.2", "1.10",
+	})
+
+	for _, pair := range [][2]string{{"1.0", "1.0.0"}, {"1.0", "1.0-final"}, {"1.0-GA", "1"}, {"1.0-cr1", "1.0-rc1"}} {
+		if got := compareMaven(pair[0], pair[1]); got != 0 {
+			t.Errorf("compareMaven(%q, %q) = %d, want 0", pair[0], pair[1], got)
+		}
+	}
+}
+
+func TestCompareSegments(t *testing.T) {
+	checkOrder(t, "RubyGems", []string{"1.0.0.pre", "1.0.0", "1.9", "1.10", "2.0.0.1"})
+}
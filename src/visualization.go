@@ -7,6 +7,7 @@ import (
 	"html/template"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 //go:embed visualizations/templates/*.html
@@ -27,6 +28,13 @@ func (c *Crawler) GenerateVisualization() error {
 		"formatBytes": formatBytes,
 		"toJSON":      toJSON,
 		"relPath":     relPath,
+		"repoPath": func(path string) string {
+			rel := relativePath(c.Config.TargetPath, path)
+			if strings.HasPrefix(rel, "..") {
+				return path // outside the repository, e.g. the Go module cache
+			}
+			return filepath.ToSlash(rel)
+		},
 	}
 
 	// Parse all template files
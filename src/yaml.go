@@ -0,0 +1,518 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAML decodes the block-style YAML used by lockfiles and package
+// manifests: mappings, sequences, flow collections, quoted and block scalars.
+// Scalars are returned as strings (null and ~ as nil) so that versions such as
+// 1.10 keep their exact text. Anchors, aliases, tags and multi-document streams
+// beyond the first document are not supported.
+func parseYAML(content []byte) (interface{}, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "---" || strings.HasPrefix(trimmed, "--- ") {
+			if len(p.lines) > 0 {
+				break
+			}
+			continue
+		}
+		if trimmed == "..." {
+			break
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "%") {
+			// Blank lines still matter inside block scalars
+			p.lines = append(p.lines, yamlLine{num: i + 1, indent: -1, raw: raw})
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: indent, text: strings.TrimRight(raw[indent:], " \t"), raw: raw})
+	}
+
+	p.skipBlank()
+	if p.eof() {
+		return nil, nil
+	}
+	return p.parseNode(p.lines[p.pos].indent)
+}
+
+type yamlLine struct {
+	num    int
+	indent int // -1 for blank and comment lines
+	text   string
+	raw    string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.pos < len(p.lines) {
+		line = p.lines[p.pos].num
+	}
+	return fmt.Errorf("yaml: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *yamlParser) eof() bool {
+	return p.pos >= len(p.lines)
+}
+
+func (p *yamlParser) skipBlank() {
+	for !p.eof() && p.lines[p.pos].indent < 0 {
+		p.pos++
+	}
+}
+
+// parseNode parses the block node starting at the current line
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	p.skipBlank()
+	if p.eof() {
+		return nil, nil
+	}
+
+	line := p.lines[p.pos]
+	if isYAMLSeqItem(line.text) {
+		return p.parseSequence(line.indent)
+	}
+	if _, _, ok := splitYAMLKey(line.text); ok {
+		return p.parseMapping(line.indent)
+	}
+
+	// A bare scalar, possibly a multi-line plain or flow value
+	p.pos++
+	text := line.text
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		text = p.joinFlow(text)
+		return parseYAMLFlow(text)
+	}
+	return yamlScalar(stripYAMLComment(text)), nil
+}
+
+func isYAMLSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+	seq := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return seq, nil
+		}
+		line := p.lines[p.pos]
+		if line.indent != indent || !isYAMLSeqItem(line.text) {
+			if line.indent > indent {
+				return nil, p.errorf("bad indentation in sequence")
+			}
+			return seq, nil
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			p.pos++
+			p.skipBlank()
+			if p.eof() || p.lines[p.pos].indent <= indent {
+				seq = append(seq, nil)
+				continue
+			}
+			value, err := p.parseNode(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, value)
+			continue
+		}
+
+		// Re-read the item content as a node indented to where it starts
+		offset := len(line.text) - len(rest)
+		p.lines[p.pos] = yamlLine{num: line.num, indent: indent + offset, text: rest, raw: line.raw}
+		value, err := p.parseNode(indent + offset)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, value)
+	}
+}
+
+func (p *yamlParser) parseMapping(indent int) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return m, nil
+		}
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			return m, nil
+		}
+		if line.indent > indent {
+			return nil, p.errorf("bad indentation in mapping")
+		}
+		if isYAMLSeqItem(line.text) {
+			return m, nil
+		}
+
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, p.errorf("expected key: value")
+		}
+		p.pos++
+		rest = stripYAMLComment(rest)
+
+		switch {
+		case rest == "":
+			p.skipBlank()
+			if p.eof() {
+				m[key] = nil
+				continue
+			}
+			next := p.lines[p.pos]
+			// Sequences may sit at the same indentation as their key
+			if next.indent > indent || next.indent == indent && isYAMLSeqItem(next.text) {
+				value, err := p.parseNode(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = value
+			} else {
+				m[key] = nil
+			}
+
+		case rest[0] == '|' || rest[0] == '>':
+			m[key] = p.parseBlockScalar(indent, rest)
+
+		case rest[0] == '[' || rest[0] == '{':
+			value, err := parseYAMLFlow(p.joinFlow(rest))
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			m[key] = value
+
+		default:
+			m[key] = yamlScalar(rest)
+		}
+	}
+}
+
+// joinFlow gathers the continuation lines of a flow collection spanning several lines
+func (p *yamlParser) joinFlow(text string) string {
+	for yamlFlowDepth(text) > 0 && !p.eof() {
+		if p.lines[p.pos].indent >= 0 {
+			text += " " + stripYAMLComment(p.lines[p.pos].text)
+		}
+		p.pos++
+	}
+	return text
+}
+
+func yamlFlowDepth(text string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// parseBlockScalar reads a literal (|) or folded (>) block scalar
+func (p *yamlParser) parseBlockScalar(parentIndent int, header string) string {
+	var lines []string
+	blockIndent := -1
+	for !p.eof() {
+		line := p.lines[p.pos]
+		if line.indent >= 0 && line.indent <= parentIndent {
+			break
+		}
+		if line.indent < 0 {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if blockIndent < 0 {
+			blockIndent = line.indent
+		}
+		lines = append(lines, line.raw[min(blockIndent, len(line.raw)):])
+		p.pos++
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	sep := "\n"
+	if header[0] == '>' {
+		sep = " "
+	}
+	text := strings.Join(lines, sep)
+	if !strings.Contains(header, "-") {
+		text += "\n"
+	}
+	return text
+}
+
+// splitYAMLKey splits "key: value" (the key may be quoted); ok is false if the
+// text is not a mapping entry
+func splitYAMLKey(text string) (string, string, bool) {
+	if text == "" {
+		return "", "", false
+	}
+
+	if text[0] == '"' || text[0] == '\'' {
+		end := closingQuote(text)
+		if end < 0 {
+			return "", "", false
+		}
+		after := text[end+1:]
+		if !strings.HasPrefix(after, ":") || len(after) > 1 && after[1] != ' ' && after[1] != '\t' {
+			return "", "", false
+		}
+		key := yamlScalar(text[:end+1])
+		keyStr, _ := key.(string)
+		return keyStr, strings.TrimSpace(after[1:]), true
+	}
+
+	if text[0] == '[' || text[0] == '{' || text[0] == '#' {
+		return "", "", false
+	}
+	if strings.HasPrefix(text, "? ") {
+		text = text[2:]
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == '#' && i > 0 && (text[i-1] == ' ' || text[i-1] == '\t') {
+			return "", "", false
+		}
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// closingQuote returns the index of the quote closing the scalar opened at text[0]
+func closingQuote(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripYAMLComment removes a trailing " # comment" outside quotes
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.ContainsRune(" \t[{,:", rune(text[i-1]))):
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimSpace(text[:i])
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// yamlScalar decodes a quoted or plain scalar
+func yamlScalar(text string) interface{} {
+	text = strings.TrimSpace(text)
+	for _, prefix := range []string{"!!str ", "!!int ", "!!bool "} {
+		text = strings.TrimPrefix(text, prefix)
+	}
+	if text == "" || text == "~" || text == "null" || text == "Null" || text == "NULL" {
+		return nil
+	}
+	if text[0] == '"' && len(text) >= 2 && text[len(text)-1] == '"' {
+		if s, err := strconv.Unquote(text); err == nil {
+			return s
+		}
+		return text[1 : len(text)-1]
+	}
+	if text[0] == '\'' && len(text) >= 2 && text[len(text)-1] == '\'' {
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'")
+	}
+	return text
+}
+
+// parseYAMLFlow decodes a flow sequence or mapping such as [a, "b"] or {x: 1}
+func parseYAMLFlow(text string) (interface{}, error) {
+	f := &yamlFlow{src: strings.TrimSpace(text)}
+	value, err := f.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+type yamlFlow struct {
+	src string
+	pos int
+}
+
+func (f *yamlFlow) skipSpaces() {
+	for f.pos < len(f.src) && (f.src[f.pos] == ' ' || f.src[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+func (f *yamlFlow) parseValue() (interface{}, error) {
+	f.skipSpaces()
+	if f.pos >= len(f.src) {
+		return nil, nil
+	}
+
+	switch f.src[f.pos] {
+	case '[':
+		f.pos++
+		seq := []interface{}{}
+		for {
+			f.skipSpaces()
+			if f.pos >= len(f.src) {
+				return nil, fmt.Errorf("yaml: unterminated flow sequence")
+			}
+			if f.src[f.pos] == ']' {
+				f.pos++
+				return seq, nil
+			}
+			start := f.pos
+			value, err := f.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			f.skipSpaces()
+			if f.pos < len(f.src) && f.src[f.pos] == ':' {
+				// "[a: b]" holds a single-pair mapping
+				key, _ := value.(string)
+				f.pos++
+				pairValue, err := f.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				value = map[string]interface{}{key: pairValue}
+			}
+			seq = append(seq, value)
+			f.skipSpaces()
+			if f.pos < len(f.src) && f.src[f.pos] == ',' {
+				f.pos++
+			}
+			if f.pos == start {
+				return nil, fmt.Errorf("yaml: unexpected %q in flow sequence", f.src[f.pos])
+			}
+		}
+
+	case '{':
+		f.pos++
+		m := make(map[string]interface{})
+		for {
+			f.skipSpaces()
+			if f.pos >= len(f.src) {
+				return nil, fmt.Errorf("yaml: unterminated flow mapping")
+			}
+			if f.src[f.pos] == '}' {
+				f.pos++
+				return m, nil
+			}
+			start := f.pos
+			keyValue, err := f.parseScalar(true)
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyValue.(string)
+			f.skipSpaces()
+			var value interface{}
+			if f.pos < len(f.src) && f.src[f.pos] == ':' {
+				f.pos++
+				if value, err = f.parseValue(); err != nil {
+					return nil, err
+				}
+			}
+			m[key] = value
+			f.skipSpaces()
+			if f.pos < len(f.src) && f.src[f.pos] == ',' {
+				f.pos++
+			}
+			if f.pos == start {
+				return nil, fmt.Errorf("yaml: unexpected %q in flow mapping", f.src[f.pos])
+			}
+		}
+	}
+
+	return f.parseScalar(false)
+}
+
+// parseScalar reads a quoted or plain scalar inside a flow collection
+func (f *yamlFlow) parseScalar(isKey bool) (interface{}, error) {
+	if c := f.src[f.pos]; c == '"' || c == '\'' {
+		end := closingQuote(f.src[f.pos:])
+		if end < 0 {
+			return nil, fmt.Errorf("yaml: unterminated quoted scalar")
+		}
+		text := f.src[f.pos : f.pos+end+1]
+		f.pos += end + 1
+		return yamlScalar(text), nil
+	}
+
+	start := f.pos
+	for f.pos < len(f.src) {
+		c := f.src[f.pos]
+		if c == ',' || c == ']' || c == '}' {
+			break
+		}
+		if c == ':' && (isKey || f.pos+1 == len(f.src) || f.src[f.pos+1] == ' ') {
+			break
+		}
+		f.pos++
+	}
+	return yamlScalar(f.src[start:f.pos]), nil
+}
+
+// yamlMap returns the mapping under key, or nil
+func yamlMap(node interface{}, key string) map[string]interface{} {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	child, _ := m[key].(map[string]interface{})
+	return child
+}
+
+// yamlString returns the string under key, or ""
+func yamlString(node interface{}, key string) string {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	s, _ := m[key].(string)
+	return s
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{
+			"flow collections",
+			"a: [x, \"y\", {k: v}]\nb: {m: [1, 2], n: 'q'}\n",
+			map[string]interface{}{
+				"a": []interface{}{"x", "y", map[string]interface{}{"k": "v"}},
+				"b": map[string]interface{}{"m": []interface{}{"1", "2"}, "n": "q"},
+			},
+		},
+		{
+			"flow sequence spanning lines",
+			"a: [b,\n  c] # trailing\n",
+			map[string]interface{}{"a": []interface{}{"b", "c"}},
+		},
+		{
+			"single-pair mapping in a flow sequence",
+			"a: [x: y]\n",
+			map[string]interface{}{"a": []interface{}{map[string]interface{}{"x": "y"}}},
+		},
+		{
+			"block scalars",
+			"a: |\n  one\n  two\nb: >\n  one\n  two\nc: |-\n  x\n",
+			map[string]interface{}{"a": "one\ntwo\n", "b": "one two\n", "c": "x"},
+		},
+		{
+			"nested blocks",
+			"list:\n  - a\n  - b: 1\n    c: 2\n  - - nested\nmap:\n  k: v\n",
+			map[string]interface{}{
+				"list": []interface{}{"a", map[string]interface{}{"b": "1", "c": "2"}, []interface{}{"nested"}},
+				"map":  map[string]interface{}{"k": "v"},
+			},
+		},
+		{
+			"quoted keys and comments",
+			"\"q:k\": v # c\n'/a@1.0.0': ~\n",
+			map[string]interface{}{"q:k": "v", "/a@1.0.0": nil},
+		},
+	}
+	for _, tt := range tests {
+		got, err := parseYAML([]byte(tt.input))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseYAMLFlowErrors(t *testing.T) {
+	for _, input := range []string{
+		"a: [:\n",
+		"a: {]\n",
+		"a: [b, }\n",
+		"a: {b: ]\n",
+		"a: [\"unterminated]\n",
+	} {
+		if _, err := parseYAML([]byte(input)); err == nil {
+			t.Errorf("parseYAML(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func FuzzParseYAML(f *testing.F) {
+	for _, seed := range []string{
+		"a: [x, {k: v}]\n",
+		"a: [:\n",
+		"a: {]\n",
+		"a: |\n  text\nb:\n  - c\n  - d: e\n",
+		"lockfileVersion: '9.0'\npackages:\n  /a@1.0.0:\n    resolution: {integrity: sha512-x}\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, content []byte) {
+		parseYAML(content)
+	})
+}